@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/models"
+)
+
+// isAdmin returns true if the caller can see and modify all recipes and households
+func isAdmin(identity *auth.Identity) bool {
+	return identity.HasScope(auth.ScopeAdmin)
+}
+
+// access decides which recipes a caller can see and modify, based on who owns each recipe and the households the
+// caller is a member of
+type access struct {
+	identity *auth.Identity
+	// households maps the id of each household the caller is a member of to their role in it
+	households map[string]string
+}
+
+// getAccess returns the access of the caller identified in the context, anonymous callers can only see public recipes
+func (api *FoodRecipeAPI) getAccess(ctx context.Context) (*access, error) {
+	a := &access{identity: auth.IdentityFromContext(ctx), households: map[string]string{}}
+	if a.identity == nil || isAdmin(a.identity) {
+		return a, nil
+	}
+
+	households, err := api.DataStore.GetHouseholds(ctx, a.identity.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range households {
+		a.households[households[i].ID] = households[i].Role(a.identity.Name)
+	}
+
+	return a, nil
+}
+
+// isOwner returns true if the caller owns the recipe. Recipes without an owner, such as those stored before recipes
+// had owners or loaded by the importer, are owned by admins only so other callers cannot replace, delete or change
+// the sharing of the shared catalogue.
+func (a *access) isOwner(recipe *models.Recipe) bool {
+	if isAdmin(a.identity) {
+		return true
+	}
+
+	return a.identity != nil && recipe.Owner != "" && recipe.Owner == a.identity.Name
+}
+
+// canView returns true if the caller can see the recipe, recipes without an owner can be seen by every caller
+func (a *access) canView(recipe *models.Recipe) bool {
+	if recipe.Owner == "" || recipe.IsPublic() || a.isOwner(recipe) {
+		return true
+	}
+
+	return recipe.Visibility == models.VisibilityHousehold && a.households[recipe.Household] != ""
+}
+
+// canModify returns true if the caller can change the recipe, editors and owners of a household can change the
+// recipes shared with it
+func (a *access) canModify(recipe *models.Recipe) bool {
+	if a.isOwner(recipe) {
+		return true
+	}
+
+	role := a.households[recipe.Household]
+	return recipe.Household != "" && (role == models.RoleOwner || role == models.RoleEditor)
+}
+
+// canDelete returns true if the caller can delete the recipe, owners of a household can delete the recipes shared
+// with it
+func (a *access) canDelete(recipe *models.Recipe) bool {
+	return a.isOwner(recipe) || (recipe.Household != "" && a.households[recipe.Household] == models.RoleOwner)
+}
+
+// checkSharing returns an error if the caller is not allowed to change who a recipe is shared with from the previous
+// version of the recipe, which is nil for a new recipe. Only the owner of a recipe can change its visibility or
+// household, and recipes can only be shared with a household the caller is an owner or editor of.
+func (a *access) checkSharing(previous, recipe *models.Recipe) *errs.Error {
+	if previous != nil && (previous.Visibility != recipe.Visibility || previous.Household != recipe.Household) && !a.isOwner(previous) {
+		return errs.ErrNotRecipeOwner
+	}
+
+	if recipe.Household == "" || (previous != nil && previous.Household == recipe.Household) || isAdmin(a.identity) {
+		return nil
+	}
+
+	if role := a.households[recipe.Household]; role != models.RoleOwner && role != models.RoleEditor {
+		return errs.ErrNotHouseholdEditor
+	}
+
+	return nil
+}
+
+// visibleRecipesFilter returns the filter selecting the recipes the caller can see, the same recipes as canView
+func (a *access) visibleRecipesFilter() models.RecipeFilter {
+	if isAdmin(a.identity) {
+		return models.RecipeFilter{All: true}
+	}
+
+	filter := models.RecipeFilter{}
+	if a.identity != nil {
+		filter.Owner = a.identity.Name
+	}
+
+	for id := range a.households {
+		filter.Households = append(filter.Households, id)
+	}
+	sort.Strings(filter.Households)
+
+	return filter
+}
+
+// hidden returns the error for a caller attempting to modify a recipe they cannot see, which is the same error as for a
+// recipe which does not exist so the response does not reveal that the recipe exists or who owns it
+func hidden(ctx context.Context, logData log.Data) error {
+	log.Warn(ctx, "caller attempted to modify a recipe they cannot see", logData)
+	return errs.ErrRecipeNotFound
+}
+
+// forbidden returns the error for a caller attempting to modify a recipe they can see but are not permitted to modify
+func forbidden(ctx context.Context, recipe *models.Recipe, err *errs.Error, logData log.Data) error {
+	logData["owner"] = recipe.Owner
+	log.Warn(ctx, "caller forbidden from modifying recipe", log.FormatErrors([]error{err}), logData)
+
+	if errors.Is(err, errs.ErrNotHouseholdEditor) {
+		return err.WithValues(map[string]string{"household": recipe.Household})
+	}
+
+	return err.WithValues(map[string]string{"owner": recipe.Owner})
+}
+
+// accessError returns the error for when the access of the caller cannot be determined
+func accessError(ctx context.Context, err error, logData log.Data) error {
+	log.Error(ctx, "unable to determine recipes accessible by caller", err, logData)
+	return fmt.Errorf("unable to determine recipes accessible by caller: %w", err)
+}
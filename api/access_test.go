@@ -0,0 +1,158 @@
+package api
+
+import (
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// callers who access recipes, alice is an editor of the smiths, a viewer of the joneses and an owner of the browns
+var callers = map[string]*access{
+	"anonymous": {households: map[string]string{}},
+	"alice": {
+		identity:   &auth.Identity{Name: "alice", Scopes: []auth.Scope{auth.ScopeRecipesWrite}},
+		households: map[string]string{"smiths": models.RoleEditor, "joneses": models.RoleViewer, "browns": models.RoleOwner},
+	},
+	"admin": {identity: &auth.Identity{Name: "admin", Scopes: []auth.Scope{auth.ScopeAdmin}}, households: map[string]string{}},
+}
+
+// recipes owned by alice, bob and nobody, shared in different ways
+var recipes = map[string]*models.Recipe{
+	"legacy":                 {ID: "legacy"},
+	"bob's public":           {ID: "bob-public", Owner: "bob", Visibility: models.VisibilityPublic},
+	"bob's unset":            {ID: "bob-unset", Owner: "bob"},
+	"bob's private":          {ID: "bob-private", Owner: "bob", Visibility: models.VisibilityPrivate},
+	"alice's private":        {ID: "alice-private", Owner: "alice", Visibility: models.VisibilityPrivate},
+	"bob's smiths":           {ID: "bob-smiths", Owner: "bob", Visibility: models.VisibilityHousehold, Household: "smiths"},
+	"bob's joneses":          {ID: "bob-joneses", Owner: "bob", Visibility: models.VisibilityHousehold, Household: "joneses"},
+	"bob's browns":           {ID: "bob-browns", Owner: "bob", Visibility: models.VisibilityHousehold, Household: "browns"},
+	"bob's greens":           {ID: "bob-greens", Owner: "bob", Visibility: models.VisibilityHousehold, Household: "greens"},
+	"bob's private smiths":   {ID: "bob-private-smiths", Owner: "bob", Visibility: models.VisibilityPrivate, Household: "smiths"},
+	"bob's public joneses":   {ID: "bob-public-joneses", Owner: "bob", Visibility: models.VisibilityPublic, Household: "joneses"},
+	"alice's private smiths": {ID: "alice-private-smiths", Owner: "alice", Visibility: models.VisibilityPrivate, Household: "smiths"},
+}
+
+func TestAccess(t *testing.T) {
+	tables := []struct {
+		caller         string
+		recipe         string
+		expectedView   bool
+		expectedModify bool
+		expectedDelete bool
+	}{
+		{"anonymous", "legacy", true, false, false},
+		{"anonymous", "bob's public", true, false, false},
+		{"anonymous", "bob's unset", true, false, false},
+		{"anonymous", "bob's private", false, false, false},
+		{"anonymous", "bob's smiths", false, false, false},
+
+		{"alice", "legacy", true, false, false},
+		{"alice", "bob's public", true, false, false},
+		{"alice", "bob's unset", true, false, false},
+		{"alice", "bob's private", false, false, false},
+		{"alice", "alice's private", true, true, true},
+		{"alice", "bob's smiths", true, true, false},
+		{"alice", "bob's joneses", true, false, false},
+		{"alice", "bob's browns", true, true, true},
+		{"alice", "bob's greens", false, false, false},
+		{"alice", "bob's private smiths", false, true, false},
+		{"alice", "bob's public joneses", true, false, false},
+		{"alice", "alice's private smiths", true, true, true},
+
+		{"admin", "legacy", true, true, true},
+		{"admin", "bob's private", true, true, true},
+		{"admin", "bob's greens", true, true, true},
+		{"admin", "alice's private", true, true, true},
+	}
+
+	for _, table := range tables {
+		Convey("Given "+table.caller+" accessing "+table.recipe, t, func() {
+			a := callers[table.caller]
+			recipe := recipes[table.recipe]
+
+			Convey("Then the caller can see, modify and delete the recipe as expected", func() {
+				So(a.canView(recipe), ShouldEqual, table.expectedView)
+				So(a.canModify(recipe), ShouldEqual, table.expectedModify)
+				So(a.canDelete(recipe), ShouldEqual, table.expectedDelete)
+			})
+		})
+	}
+}
+
+func TestVisibleRecipesFilter(t *testing.T) {
+	for caller, a := range callers {
+		Convey("Given the recipes visible to "+caller, t, func() {
+			filter := a.visibleRecipesFilter()
+
+			for name, recipe := range recipes {
+				Convey("Then the filter selects "+name+" only if the caller can see it", func() {
+					So(filter.Matches(recipe), ShouldEqual, a.canView(recipe))
+				})
+			}
+		})
+	}
+
+	Convey("Given the recipes visible to alice", t, func() {
+		filter := callers["alice"].visibleRecipesFilter()
+
+		Convey("Then the filter selects her recipes and those shared with every household she is a member of", func() {
+			So(filter, ShouldResemble, models.RecipeFilter{Owner: "alice", Households: []string{"browns", "joneses", "smiths"}})
+		})
+	})
+
+	Convey("Given the recipes visible to an admin", t, func() {
+		Convey("Then the filter selects every recipe", func() {
+			So(callers["admin"].visibleRecipesFilter(), ShouldResemble, models.RecipeFilter{All: true})
+		})
+	})
+}
+
+func TestCheckSharing(t *testing.T) {
+	tables := []struct {
+		givenTitle    string
+		caller        string
+		previous      *models.Recipe
+		recipe        *models.Recipe
+		expectedError *errs.Error
+	}{
+		{"Given a new recipe which is not shared", "alice", nil,
+			&models.Recipe{Owner: "alice", Visibility: models.VisibilityPrivate}, nil},
+		{"Given a new recipe shared with a household the caller edits", "alice", nil,
+			&models.Recipe{Owner: "alice", Visibility: models.VisibilityHousehold, Household: "smiths"}, nil},
+		{"Given a new recipe shared with a household the caller views", "alice", nil,
+			&models.Recipe{Owner: "alice", Visibility: models.VisibilityHousehold, Household: "joneses"}, errs.ErrNotHouseholdEditor},
+		{"Given a new recipe shared with a household the caller is not a member of", "alice", nil,
+			&models.Recipe{Owner: "alice", Visibility: models.VisibilityHousehold, Household: "greens"}, errs.ErrNotHouseholdEditor},
+		{"Given a new recipe shared with any household by an admin", "admin", nil,
+			&models.Recipe{Owner: "admin", Visibility: models.VisibilityHousehold, Household: "greens"}, nil},
+		{"Given an edit to a recipe which keeps who it is shared with", "alice", recipes["bob's smiths"],
+			&models.Recipe{Owner: "bob", Visibility: models.VisibilityHousehold, Household: "smiths", Notes: "edited"}, nil},
+		{"Given a change of visibility by an editor of the household", "alice", recipes["bob's smiths"],
+			&models.Recipe{Owner: "bob", Visibility: models.VisibilityPublic, Household: "smiths"}, errs.ErrNotRecipeOwner},
+		{"Given a change of household by an editor of the household", "alice", recipes["bob's smiths"],
+			&models.Recipe{Owner: "bob", Visibility: models.VisibilityHousehold, Household: "browns"}, errs.ErrNotRecipeOwner},
+		{"Given the owner moving a recipe to a household they view", "alice", recipes["alice's private smiths"],
+			&models.Recipe{Owner: "alice", Visibility: models.VisibilityHousehold, Household: "joneses"}, errs.ErrNotHouseholdEditor},
+		{"Given the owner making a shared recipe private", "alice", recipes["alice's private smiths"],
+			&models.Recipe{Owner: "alice", Visibility: models.VisibilityPrivate, Household: "smiths"}, nil},
+		{"Given an admin changing who a recipe is shared with", "admin", recipes["bob's smiths"],
+			&models.Recipe{Owner: "bob", Visibility: models.VisibilityHousehold, Household: "greens"}, nil},
+		{"Given a change of visibility of a recipe without an owner", "alice", recipes["legacy"],
+			&models.Recipe{Visibility: models.VisibilityPrivate}, errs.ErrNotRecipeOwner},
+		{"Given an admin changing the visibility of a recipe without an owner", "admin", recipes["legacy"],
+			&models.Recipe{Visibility: models.VisibilityPrivate}, nil},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			sharingErr := callers[table.caller].checkSharing(table.previous, table.recipe)
+
+			Convey("Then the change is allowed or rejected with the expected error", func() {
+				So(sharingErr, ShouldEqual, table.expectedError)
+			})
+		})
+	}
+}
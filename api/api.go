@@ -2,60 +2,240 @@ package api
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
 	"github.com/nshumoogum/food-recipes/models"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	authRealm    = "food-recipes"
+	bearerPrefix = "Bearer "
+)
+
+//go:generate moq -out mock/authenticator.go -pkg mock . Authenticator
+
+//go:generate moq -out mock/datastore.go -pkg mock . DataStore
+
+// Authenticator identifies the caller presenting a bearer token
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*auth.Identity, error)
+}
+
+// DataStore defines the methods required from the store holding the API's data
+type DataStore interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	GetAPIKey(ctx context.Context, name string) (*models.APIKey, error)
+	UpdateAPIKeyExpiry(ctx context.Context, name string, expiresAt *time.Time) error
+	RevokeAPIKey(ctx context.Context, name string, revokedAt time.Time) error
+	CreateHousehold(ctx context.Context, household *models.Household) error
+	GetHouseholds(ctx context.Context, member string) ([]models.Household, error)
+	GetHousehold(ctx context.Context, id string) (*models.Household, error)
+	InviteHouseholdMember(ctx context.Context, id string, invitation models.HouseholdInvitation) error
+	AcceptHouseholdInvitation(ctx context.Context, id string, invitation models.HouseholdInvitation) error
+	RemoveHouseholdInvitation(ctx context.Context, id, name string) error
+	RemoveHouseholdMember(ctx context.Context, id, name string) error
+	CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+	GetAuditEntries(ctx context.Context, filter models.AuditFilter, offset, limit int) ([]models.AuditEntry, int64, error)
+	GetRecipes(ctx context.Context, filter models.RecipeFilter, offset, limit int) ([]models.Recipe, int64, error)
+	GetRecipe(ctx context.Context, id string) (*models.Recipe, error)
+	GetRecipeAlias(ctx context.Context, id string) (*models.RecipeAlias, error)
+	CreateRecipe(ctx context.Context, recipe *models.Recipe) error
+	ReplaceRecipe(ctx context.Context, previous, recipe *models.Recipe) error
+	DeleteRecipe(ctx context.Context, recipe *models.Recipe) error
+}
+
 // FoodRecipeAPI manages access to food recipes
 type FoodRecipeAPI struct {
+	DataStore         DataStore
 	DefaultMaxResults int
-	MongoClient       *mongo.Client
+	ReservedKeyNames  []string
 	Router            *mux.Router
 }
 
 // NewFoodRecipeAPI create a new Food Recipe API instance and register the API routes based on the application configuration.
-func NewFoodRecipeAPI(ctx context.Context, connectionString string, mongoClient *mongo.Client, data map[string]models.Recipe, defaultMaxResults int, router *mux.Router) *FoodRecipeAPI {
+// Reserved key names are the names of API keys from configuration, which cannot be used for keys issued through the API.
+func NewFoodRecipeAPI(ctx context.Context, authenticator Authenticator, dataStore DataStore, reservedKeyNames []string,
+	defaultMaxResults int, router *mux.Router) *FoodRecipeAPI {
 	api := &FoodRecipeAPI{
+		DataStore:         dataStore,
 		DefaultMaxResults: defaultMaxResults,
-		MongoClient:       mongoClient,
+		ReservedKeyNames:  reservedKeyNames,
 		Router:            router,
 	}
 
-	api.Router.HandleFunc("/recipes", authorise(connectionString, api.createRecipe)).Methods("POST")
-	api.Router.HandleFunc("/recipes", api.getRecipes).Methods("GET")
-	api.Router.HandleFunc("/recipes/{id}", api.getRecipe).Methods("GET")
-	api.Router.HandleFunc("/recipes/{id}", authorise(connectionString, api.updateRecipe)).Methods("PUT")
-	api.Router.HandleFunc("/recipes/{id}", authorise(connectionString, api.partialRecipeUpdate)).Methods("PATCH")
-	api.Router.HandleFunc("/recipes/{id}", authorise(connectionString, api.removeRecipe)).Methods("DELETE")
+	api.Router.HandleFunc("/recipes", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.createRecipe))).Methods("POST")
+	api.Router.HandleFunc("/recipes", identify(authenticator, auth.ScopeRecipesRead, handle(api.getRecipes))).Methods("GET")
+	api.Router.HandleFunc("/recipes/{id}", identify(authenticator, auth.ScopeRecipesRead, handle(api.getRecipe))).Methods("GET")
+	api.Router.HandleFunc("/recipes/{id}", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.updateRecipe))).Methods("PUT")
+	api.Router.HandleFunc("/recipes/{id}", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.partialRecipeUpdate))).Methods("PATCH")
+	api.Router.HandleFunc("/recipes/{id}", authorise(authenticator, auth.ScopeRecipesDelete, handle(api.removeRecipe))).Methods("DELETE")
+
+	api.Router.HandleFunc("/households", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.createHousehold))).Methods("POST")
+	api.Router.HandleFunc("/households", authorise(authenticator, auth.ScopeRecipesRead, handle(api.getHouseholds))).Methods("GET")
+	api.Router.HandleFunc("/households/{id}", authorise(authenticator, auth.ScopeRecipesRead, handle(api.getHousehold))).Methods("GET")
+	api.Router.HandleFunc("/households/{id}/invitations", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.inviteHouseholdMember))).Methods("POST")
+	api.Router.HandleFunc("/households/{id}/invitations/accept", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.acceptHouseholdInvitation))).Methods("POST")
+	api.Router.HandleFunc("/households/{id}/invitations/{name}", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.removeHouseholdInvitation))).Methods("DELETE")
+	api.Router.HandleFunc("/households/{id}/members/{name}", authorise(authenticator, auth.ScopeRecipesWrite, handle(api.removeHouseholdMember))).Methods("DELETE")
+
+	api.Router.HandleFunc("/admin/keys", authorise(authenticator, auth.ScopeAdmin, handle(api.createAPIKey))).Methods("POST")
+	api.Router.HandleFunc("/admin/keys", authorise(authenticator, auth.ScopeAdmin, handle(api.getAPIKeys))).Methods("GET")
+	api.Router.HandleFunc("/admin/keys/{name}", authorise(authenticator, auth.ScopeAdmin, handle(api.getAPIKey))).Methods("GET")
+	api.Router.HandleFunc("/admin/keys/{name}", authorise(authenticator, auth.ScopeAdmin, handle(api.revokeAPIKey))).Methods("DELETE")
+	api.Router.HandleFunc("/admin/keys/{name}/expiry", authorise(authenticator, auth.ScopeAdmin, handle(api.updateAPIKeyExpiry))).Methods("PUT")
+
+	api.Router.HandleFunc("/admin/audit", authorise(authenticator, auth.ScopeAdmin, handle(api.getAuditEntries))).Methods("GET")
+
+	api.Router.HandleFunc("/openapi.json", getOpenAPI).Methods("GET")
 
 	return api
 }
 
-func authorise(connectionString string, handler http.HandlerFunc) http.HandlerFunc {
+// authorise only calls the handler if the caller presents a bearer token identifying them as a caller with the
+// required scope, the identity of the caller is added to the request context
+func authorise(authenticator Authenticator, scope auth.Scope, handler http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
-		logData := log.Data{"requested_uri": req.URL.RequestURI()}
-
-		authValue := req.Header.Get("Authorization")
+		logData := log.Data{"requested_uri": req.URL.RequestURI(), "required_scope": scope}
 
-		// Check connection string
-		if authValue != connectionString || authValue == "" {
-			log.Warn(ctx, "caller unauthorised to perform requested action", logData)
+		identity, ok := authenticate(ctx, w, req, authenticator, logData)
+		if !ok {
+			return
+		}
 
-			w.WriteHeader(401)
+		if !hasScope(w, req, identity, scope, logData) {
 			return
 		}
 
 		log.Info(ctx, "caller authorised to perform requested action", logData)
-		handler(w, req)
+		handler(w, req.WithContext(auth.WithIdentity(ctx, identity)))
 	})
 }
 
+// identify calls the handler for anonymous callers and for callers presenting a valid bearer token identifying them as
+// a caller with the required scope, in which case the identity of the caller is added to the request context. Invalid
+// tokens and callers without the scope are rejected rather than treated as anonymous.
+func identify(authenticator Authenticator, scope auth.Scope, handler http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") == "" {
+			handler(w, req)
+			return
+		}
+
+		ctx := req.Context()
+		logData := log.Data{"requested_uri": req.URL.RequestURI(), "required_scope": scope}
+
+		identity, ok := authenticate(ctx, w, req, authenticator, logData)
+		if !ok || !hasScope(w, req, identity, scope, logData) {
+			return
+		}
+
+		log.Info(ctx, "caller identified", logData)
+		handler(w, req.WithContext(auth.WithIdentity(ctx, identity)))
+	})
+}
+
+// hasScope returns true if the caller has been granted the scope, otherwise responding with a 403 and a challenge, as
+// described in RFC 6750, naming the scope required
+func hasScope(w http.ResponseWriter, req *http.Request, identity *auth.Identity, scope auth.Scope, logData log.Data) bool {
+	if identity.HasScope(scope) {
+		return true
+	}
+
+	log.Warn(req.Context(), "caller forbidden from performing requested action, missing scope", logData)
+	w.Header().Set("WWW-Authenticate", `Bearer realm="`+authRealm+`", error="insufficient_scope", scope="`+string(scope)+`"`)
+	ErrorResponse(w, req, errs.ErrInsufficientScope.WithValues(map[string]string{"required_scope": string(scope)}))
+
+	return false
+}
+
+// authenticate returns the identity of the caller presenting the bearer token in the request, responding with an
+// error and returning false if the caller cannot be authenticated
+func authenticate(ctx context.Context, w http.ResponseWriter, req *http.Request, authenticator Authenticator, logData log.Data) (*auth.Identity, bool) {
+	authValue := req.Header.Get("Authorization")
+	if len(authValue) < len(bearerPrefix) || !strings.EqualFold(authValue[:len(bearerPrefix)], bearerPrefix) {
+		log.Warn(ctx, "caller unauthorised to perform requested action, missing bearer token", logData)
+		unauthorised(w, req, nil)
+		return nil, false
+	}
+
+	identity, err := authenticator.Authenticate(ctx, strings.TrimSpace(authValue[len(bearerPrefix):]))
+	if err != nil && !errors.Is(err, errs.ErrInvalidCredentials) {
+		log.Error(ctx, "unable to authenticate caller", err, logData)
+		ErrorResponse(w, req, err)
+		return nil, false
+	}
+
+	if err != nil {
+		log.Warn(ctx, "caller unauthorised to perform requested action, invalid bearer token", log.FormatErrors([]error{err}), logData)
+		unauthorised(w, req, err)
+		return nil, false
+	}
+
+	logData["caller"] = identity.Name
+	logData["auth_method"] = identity.Method
+
+	return identity, true
+}
+
+// unauthorised responds with a 401 and a challenge, as described in RFC 6750, explaining why the token was rejected
+func unauthorised(w http.ResponseWriter, req *http.Request, err error) {
+	challenge := `Bearer realm="` + authRealm + `"`
+	apiErr := errs.ErrInvalidCredentials
+
+	if err != nil {
+		challenge += `, error="invalid_token"`
+
+		var tokenErr *auth.TokenError
+		if errors.As(err, &tokenErr) {
+			challenge += `, error_description="` + challengeDescription(tokenErr.Description) + `"`
+			apiErr = apiErr.WithValues(map[string]string{"reason": tokenErr.Description})
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", challenge)
+	ErrorResponse(w, req, apiErr)
+}
+
+// challengeDescription removes the characters RFC 6750 does not allow in the error_description of a challenge, so a
+// description can never break out of its quoted string
+func challengeDescription(description string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' {
+			return -1
+		}
+		return r
+	}, description)
+}
+
+// writeJSON marshals the value to json and writes it in the response body with the given status
+func writeJSON(w http.ResponseWriter, req *http.Request, status int, v interface{}, logData log.Data) {
+	ctx := req.Context()
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Error(ctx, "failed to marshal response to json", err, logData)
+		ErrorResponse(w, req, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Warn(ctx, "failed to write response data", log.FormatErrors([]error{err}), logData)
+	}
+}
+
 // DrainBody drains the body of the given HTTP request
 func DrainBody(r *http.Request) {
 	if r.Body == nil {
@@ -72,3 +252,16 @@ func DrainBody(r *http.Request) {
 		log.Error(r.Context(), "error closing request body", err)
 	}
 }
+
+// prefersMinimal returns true if the caller has asked for a minimal response using the Prefer header (RFC 7240)
+func prefersMinimal(req *http.Request) bool {
+	for _, header := range req.Header.Values("Prefer") {
+		for _, preference := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+			if strings.EqualFold(strings.ReplaceAll(strings.TrimSpace(preference), " ", ""), "return=minimal") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,103 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnauthorisedChallenge(t *testing.T) {
+	Convey("Given a request with a bearer token which is rejected with a description containing quotes and line breaks", t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/recipes", http.NoBody)
+		req.Header.Set("Authorization", "Bearer "+rejectedToken)
+
+		Convey("When the request is handled", func() {
+			w := httptest.NewRecorder()
+			newRouter(newDataStore()).ServeHTTP(w, req)
+
+			Convey("Then the challenge contains the description without the characters RFC 6750 does not allow", func() {
+				So(w.Code, ShouldEqual, http.StatusUnauthorized)
+				So(w.Header().Get("WWW-Authenticate"), ShouldEqual,
+					`Bearer realm="food-recipes", error="invalid_token", error_description="token kid isunknown"`)
+			})
+		})
+	})
+}
+
+func TestRecipeReadScope(t *testing.T) {
+	tables := []struct {
+		givenTitle     string
+		path           string
+		token          string
+		expectedStatus int
+	}{
+		{"Given an anonymous request for the recipes", "/recipes", "", http.StatusOK},
+		{"Given a request for the recipes by a caller with the read scope", "/recipes", writerToken, http.StatusOK},
+		{"Given a request for the recipes by an admin", "/recipes", adminToken, http.StatusOK},
+		{"Given a request for the recipes by a caller without the read scope", "/recipes", importerToken, http.StatusForbidden},
+		{"Given a request for a recipe by a caller without the read scope", "/recipes/pancakes", importerToken, http.StatusForbidden},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			w := serve(newRouter(newDataStore()), http.MethodGet, table.path, table.token, "")
+
+			Convey("Then the recipes are only returned to callers permitted to read them", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+
+				if table.expectedStatus == http.StatusForbidden {
+					So(errorCodes(w), ShouldResemble, []string{"insufficient_scope"})
+					So(w.Header().Get("WWW-Authenticate"), ShouldEqual,
+						`Bearer realm="food-recipes", error="insufficient_scope", scope="recipes:read"`)
+				}
+			})
+		})
+	}
+}
+
+// serve sends a request to the router as the caller with the token, if any, and returns the response
+func serve(router http.Handler, method, path, token, body string) *httptest.ResponseRecorder {
+	header := http.Header{}
+	if body != "" {
+		header.Set("Content-Type", "application/json")
+	}
+
+	return serveWithHeader(router, method, path, token, body, header)
+}
+
+// serveWithHeader sends a request with the header to the router as the caller with the token, if any, and returns the
+// response
+func serveWithHeader(router http.Handler, method, path, token, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
+
+// errorCodes returns the codes of the errors in the response body
+func errorCodes(w *httptest.ResponseRecorder) []string {
+	var body models.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		return nil
+	}
+
+	codes := make([]string, 0, len(body.Errors))
+	for _, e := range body.Errors {
+		codes = append(codes, e.Code)
+	}
+
+	return codes
+}
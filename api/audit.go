@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/helpers"
+	"github.com/nshumoogum/food-recipes/models"
+)
+
+// recordAudit records a change made to a recipe by the caller in the audit log, with the state of the recipe before and
+// after the change. The change has already been made, so failing to record it is logged rather than returned.
+func (api *FoodRecipeAPI) recordAudit(ctx context.Context, entry models.AuditEntry, before, after *models.Recipe, logData log.Data) {
+	identity := auth.IdentityFromContext(ctx)
+	if identity != nil {
+		entry.Caller = identity.Name
+		entry.AuthMethod = identity.Method
+	}
+
+	entry.RequestID = request.GetRequestId(ctx)
+	entry.Timestamp = time.Now().UTC()
+
+	var err error
+	if entry.BeforeDigest, err = before.Digest(); err == nil {
+		entry.AfterDigest, err = after.Digest()
+	}
+
+	if err == nil {
+		err = api.DataStore.CreateAuditEntry(ctx, &entry)
+	}
+
+	if err != nil {
+		log.Error(ctx, "failed to record change to recipe in audit log", err, logData)
+	}
+}
+
+// getAuditEntries returns a page of the audit log, optionally only including changes to a single recipe and changes
+// made since a time given in RFC 3339 format
+func (api *FoodRecipeAPI) getAuditEntries(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	filter := models.AuditFilter{RecipeID: req.FormValue("recipe_id")}
+	logData := log.Data{"recipe_id": filter.RecipeID}
+
+	limit, limitErr := helpers.CalculateLimit(ctx, defaultLimit, api.DefaultMaxResults, req.FormValue("limit"))
+	offset, offsetErr := helpers.CalculateOffset(ctx, req.FormValue("offset"))
+
+	validationErr := errs.Append(limitErr, offsetErr)
+
+	if since := req.FormValue("since"); since != "" {
+		logData["since"] = since
+
+		sinceTime, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			validationErr = errs.Append(validationErr, errs.ErrInvalidSince.WithValues(map[string]string{"since": since}))
+		} else {
+			filter.Since = &sinceTime
+		}
+	}
+
+	if validationErr != nil {
+		return validationErr
+	}
+
+	entries, count, err := api.DataStore.GetAuditEntries(ctx, filter, offset, limit)
+	if err != nil {
+		log.Error(ctx, "get audit entries: failed to retrieve audit entries", err, logData)
+		return fmt.Errorf("failed to retrieve audit entries: %w", err)
+	}
+
+	list := models.AuditEntries{
+		Count:      len(entries),
+		Items:      entries,
+		Limit:      limit,
+		Offset:     offset,
+		TotalCount: count,
+	}
+
+	writeJSON(w, req, http.StatusOK, list, logData)
+
+	log.Info(ctx, "get audit entries: request successful", logData)
+	return nil
+}
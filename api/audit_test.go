@@ -0,0 +1,98 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/middleware"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecordAudit(t *testing.T) {
+	const mergePatch = `{"notes": "add lemon"}`
+
+	tables := []struct {
+		givenTitle         string
+		method             string
+		path               string
+		body               string
+		contentType        string
+		recipeID           string
+		expectedAction     string
+		expectedBefore     bool
+		expectedAfter      bool
+		expectedPatch      string
+		expectedPatchMedia string
+	}{
+		{"Given a request to create a recipe", http.MethodPost, "/recipes", `{"title": "Waffles", ` + recipeBody[1:],
+			"application/json", "waffles", models.AuditActionCreate, false, true, "", ""},
+		{"Given a request to create a recipe by replacing it", http.MethodPut, "/recipes/waffles", recipeBody,
+			"application/json", "waffles", models.AuditActionCreate, false, true, "", ""},
+		{"Given a request to replace a recipe", http.MethodPut, "/recipes/pancakes", recipeBody,
+			"application/json", "pancakes", models.AuditActionReplace, true, true, "", ""},
+		{"Given a request to patch a recipe", http.MethodPatch, "/recipes/pancakes", mergePatch,
+			patch.MergePatchMediaType, "pancakes", models.AuditActionPatch, true, true, mergePatch, patch.MergePatchMediaType},
+		{"Given a request to delete a recipe", http.MethodDelete, "/recipes/pancakes", "",
+			"", "pancakes", models.AuditActionDelete, true, false, "", ""},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			before := store.recipes[table.recipeID]
+
+			header := http.Header{middleware.RequestIDHeader: {"audit-request-1"}}
+			if table.contentType != "" {
+				header.Set("Content-Type", table.contentType)
+			}
+
+			w := serveWithHeader(middleware.RequestID(newRouter(store)), table.method, table.path, writerToken, table.body, header)
+			So(w.Code, ShouldBeLessThan, http.StatusMultipleChoices)
+
+			Convey("Then the change is recorded in the audit log with the caller and the recipe before and after", func() {
+				So(store.audit, ShouldHaveLength, 2)
+
+				entry := store.audit[1]
+				So(entry.RecipeID, ShouldEqual, table.recipeID)
+				So(entry.Action, ShouldEqual, table.expectedAction)
+				So(entry.Caller, ShouldEqual, "writer")
+				So(entry.AuthMethod, ShouldEqual, "api_key")
+				So(entry.RequestID, ShouldEqual, "audit-request-1")
+				So(entry.Timestamp.IsZero(), ShouldBeFalse)
+				So(entry.Patch, ShouldEqual, table.expectedPatch)
+				So(entry.PatchMediaType, ShouldEqual, table.expectedPatchMedia)
+
+				expectedBefore := ""
+				if table.expectedBefore {
+					expectedBefore = digest(before)
+				}
+				So(entry.BeforeDigest, ShouldEqual, expectedBefore)
+
+				expectedAfter := ""
+				if table.expectedAfter {
+					expectedAfter = digest(store.recipes[table.recipeID])
+				}
+				So(entry.AfterDigest, ShouldEqual, expectedAfter)
+			})
+		})
+	}
+
+	Convey("Given a request which does not change a recipe", t, func() {
+		store := newDataStore()
+		w := serve(newRouter(store), http.MethodDelete, "/recipes/waffles", writerToken, "")
+		So(w.Code, ShouldEqual, http.StatusNoContent)
+
+		Convey("Then nothing is recorded in the audit log", func() {
+			So(store.audit, ShouldHaveLength, 1)
+		})
+	})
+}
+
+// digest returns the digest of the recipe, failing the test if it cannot be calculated
+func digest(recipe *models.Recipe) string {
+	d, err := recipe.Digest()
+	So(err, ShouldBeNil)
+	return d
+}
@@ -1,30 +1,25 @@
 package api
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 
-	"github.com/ONSdigital/go-ns/log"
-	errs "github.com/nshumoogum/food-recipes/apierrors"
 	"github.com/nshumoogum/food-recipes/models"
-	"github.com/pkg/errors"
 )
 
-// ErrorResponse sets the structured error message in the http response body
-func ErrorResponse(ctx context.Context, w http.ResponseWriter, status int, errorResponse *models.ErrorResponse) {
-	b, err := json.Marshal(errorResponse)
-	if err != nil {
-		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+// handlerFunc handles a request, returning the error to respond with if the request is unsuccessful
+type handlerFunc func(w http.ResponseWriter, req *http.Request) error
 
-	if _, err := w.Write(b); err != nil {
-		log.ErrorCtx(ctx, errors.WithMessage(err, "failed to write error response body"), nil)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+// handle adapts a handler returning an error to an http.HandlerFunc, responding with the error if one is returned
+func handle(handler handlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := handler(w, req); err != nil {
+			ErrorResponse(w, req, err)
+		}
 	}
 }
+
+// ErrorResponse sets the structured error message in the http response body, along with the ID of the request, in the
+// same format as errors responded with by the middleware
+func ErrorResponse(w http.ResponseWriter, req *http.Request, err error) {
+	models.WriteErrorResponse(w, req, err)
+}
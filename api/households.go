@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/slug"
+)
+
+// createHousehold creates a household with the caller as its owner, the id of the household is generated from its name
+func (api *FoodRecipeAPI) createHousehold(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errs.ErrUnableToReadMessage
+	}
+
+	var newHousehold models.NewHousehold
+	if err = json.Unmarshal(b, &newHousehold); err != nil {
+		return errs.ErrUnableToParseJSON
+	}
+
+	caller := auth.IdentityFromContext(ctx).Name
+	household := &models.Household{
+		ID:        slug.Make(newHousehold.Name),
+		Name:      newHousehold.Name,
+		Members:   []models.HouseholdMember{{Name: caller, Role: models.RoleOwner}},
+		CreatedAt: time.Now().UTC(),
+	}
+	logData := log.Data{"household_id": household.ID, "caller": caller}
+
+	if household.ID == "" {
+		return errs.ErrInvalidHouseholdName.WithField("/name").WithValues(map[string]string{"name": newHousehold.Name})
+	}
+
+	if err = api.DataStore.CreateHousehold(ctx, household); err != nil {
+		return householdError(ctx, "create household", err, logData)
+	}
+
+	w.Header().Set("Location", "/households/"+household.ID)
+	writeJSON(w, req, http.StatusCreated, household, logData)
+
+	log.Info(ctx, "create household: request successful", logData)
+	return nil
+}
+
+// getHouseholds returns the households the caller is a member of, or all households for admins
+func (api *FoodRecipeAPI) getHouseholds(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	identity := auth.IdentityFromContext(ctx)
+
+	member := identity.Name
+	if isAdmin(identity) {
+		member = ""
+	}
+
+	households, err := api.DataStore.GetHouseholds(ctx, member)
+	if err != nil {
+		log.Error(ctx, "get households: failed to retrieve households", err)
+		return fmt.Errorf("failed to retrieve households: %w", err)
+	}
+
+	list := models.Households{
+		Count: len(households),
+		Items: households,
+	}
+
+	writeJSON(w, req, http.StatusOK, list, nil)
+
+	log.Info(ctx, "get households: request successful")
+	return nil
+}
+
+// getHousehold returns a household the caller is a member of, any household for admins
+func (api *FoodRecipeAPI) getHousehold(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	id := mux.Vars(req)["id"]
+	logData := log.Data{"household_id": id}
+
+	household, err := api.getMemberHousehold(ctx, id)
+	if err != nil {
+		return householdError(ctx, "get household", err, logData)
+	}
+
+	writeJSON(w, req, http.StatusOK, household, logData)
+
+	log.Info(ctx, "get household: request successful", logData)
+	return nil
+}
+
+// inviteHouseholdMember invites a caller to join a household with the given role, only owners of the household can
+// invite members. The caller becomes a member once they accept the invitation.
+func (api *FoodRecipeAPI) inviteHouseholdMember(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	id := mux.Vars(req)["id"]
+	logData := log.Data{"household_id": id}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errs.ErrUnableToReadMessage
+	}
+
+	var member models.HouseholdMember
+	if err = json.Unmarshal(b, &member); err != nil {
+		return errs.ErrUnableToParseJSON
+	}
+	logData["member"] = member.Name
+
+	if err = member.Validate(); err != nil {
+		return err
+	}
+
+	household, err := api.getMemberHousehold(ctx, id)
+	if err != nil {
+		return householdError(ctx, "invite household member", err, logData)
+	}
+
+	identity := auth.IdentityFromContext(ctx)
+	if household.Role(identity.Name) != models.RoleOwner && !isAdmin(identity) {
+		log.Warn(ctx, "invite household member: caller is not an owner of the household", logData)
+		return errs.ErrNotHouseholdOwner
+	}
+
+	invitation := models.HouseholdInvitation{
+		Name:      member.Name,
+		Role:      member.Role,
+		InvitedBy: identity.Name,
+		InvitedAt: time.Now().UTC(),
+	}
+
+	if err = api.DataStore.InviteHouseholdMember(ctx, id, invitation); err != nil {
+		return householdError(ctx, "invite household member", err, logData)
+	}
+
+	household.Invitations = append(household.Invitations, invitation)
+
+	w.Header().Set("Location", "/households/"+id)
+	writeJSON(w, req, http.StatusCreated, household, logData)
+
+	log.Info(ctx, "invite household member: request successful", logData)
+	return nil
+}
+
+// acceptHouseholdInvitation makes the caller a member of a household they have been invited to, with the role they
+// were invited with. Households the caller has not been invited to are reported as not found.
+func (api *FoodRecipeAPI) acceptHouseholdInvitation(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	id := mux.Vars(req)["id"]
+	caller := auth.IdentityFromContext(ctx).Name
+	logData := log.Data{"household_id": id, "member": caller}
+
+	household, err := api.DataStore.GetHousehold(ctx, id)
+	if err != nil {
+		return householdError(ctx, "accept household invitation", err, logData)
+	}
+
+	invitation := household.Invitation(caller)
+	switch {
+	case invitation != nil:
+	case household.Role(caller) != "":
+		return householdError(ctx, "accept household invitation", errs.ErrInvitationNotFound, logData)
+	default:
+		return householdError(ctx, "accept household invitation", errs.ErrHouseholdNotFound, logData)
+	}
+
+	if err = api.DataStore.AcceptHouseholdInvitation(ctx, id, *invitation); err != nil {
+		return householdError(ctx, "accept household invitation", err, logData)
+	}
+
+	household.Members = append(household.Members, models.HouseholdMember{Name: invitation.Name, Role: invitation.Role})
+	household.Invitations = withoutInvitation(household.Invitations, caller)
+
+	writeJSON(w, req, http.StatusOK, household, logData)
+
+	log.Info(ctx, "accept household invitation: request successful", logData)
+	return nil
+}
+
+// removeHouseholdInvitation removes an invitation to a household, owners of the household can cancel any invitation
+// and invited callers can decline their own
+func (api *FoodRecipeAPI) removeHouseholdInvitation(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	vars := mux.Vars(req)
+	id := vars["id"]
+	name := vars["name"]
+	logData := log.Data{"household_id": id, "member": name}
+
+	household, err := api.DataStore.GetHousehold(ctx, id)
+	if err != nil {
+		return householdError(ctx, "remove household invitation", err, logData)
+	}
+
+	identity := auth.IdentityFromContext(ctx)
+	isInvitee := name == identity.Name && household.Invitation(name) != nil
+	role := household.Role(identity.Name)
+
+	switch {
+	case isInvitee || role == models.RoleOwner || isAdmin(identity):
+	case role == "":
+		return householdError(ctx, "remove household invitation", errs.ErrHouseholdNotFound, logData)
+	default:
+		log.Warn(ctx, "remove household invitation: caller is not an owner of the household", logData)
+		return errs.ErrNotHouseholdOwner
+	}
+
+	if err = api.DataStore.RemoveHouseholdInvitation(ctx, id, name); err != nil {
+		return householdError(ctx, "remove household invitation", err, logData)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Info(ctx, "remove household invitation: request successful", logData)
+	return nil
+}
+
+func withoutInvitation(invitations []models.HouseholdInvitation, name string) []models.HouseholdInvitation {
+	remaining := make([]models.HouseholdInvitation, 0, len(invitations))
+	for i := range invitations {
+		if invitations[i].Name != name {
+			remaining = append(remaining, invitations[i])
+		}
+	}
+
+	return remaining
+}
+
+// removeHouseholdMember removes a caller from a household, owners of the household can remove any member and other
+// members can only remove themselves. The last owner of a household cannot be removed.
+func (api *FoodRecipeAPI) removeHouseholdMember(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	vars := mux.Vars(req)
+	id := vars["id"]
+	name := vars["name"]
+	logData := log.Data{"household_id": id, "member": name}
+
+	household, err := api.getMemberHousehold(ctx, id)
+	if err != nil {
+		return householdError(ctx, "remove household member", err, logData)
+	}
+
+	identity := auth.IdentityFromContext(ctx)
+	if name != identity.Name && household.Role(identity.Name) != models.RoleOwner && !isAdmin(identity) {
+		log.Warn(ctx, "remove household member: caller is not an owner of the household", logData)
+		return errs.ErrNotHouseholdOwner
+	}
+
+	// the store refuses to remove the last owner, so concurrent removals cannot leave the household without an owner
+	if err = api.DataStore.RemoveHouseholdMember(ctx, id, name); err != nil {
+		return householdError(ctx, "remove household member", err, logData)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Info(ctx, "remove household member: request successful", logData)
+	return nil
+}
+
+// getMemberHousehold returns the household if the caller is a member of it or an admin, households the caller cannot
+// see are reported as not found
+func (api *FoodRecipeAPI) getMemberHousehold(ctx context.Context, id string) (*models.Household, error) {
+	household, err := api.DataStore.GetHousehold(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	identity := auth.IdentityFromContext(ctx)
+	if household.Role(identity.Name) == "" && !isAdmin(identity) {
+		return nil, errs.ErrHouseholdNotFound
+	}
+
+	return household, nil
+}
+
+// householdError logs the error from accessing a household or its members and returns the error to respond with
+func householdError(ctx context.Context, action string, err error, logData log.Data) error {
+	if models.Status(err) < http.StatusInternalServerError {
+		log.Warn(ctx, action+": "+err.Error(), logData)
+		return err
+	}
+
+	log.Error(ctx, action+": failed to access household", err, logData)
+	return fmt.Errorf("%s: %w", action, err)
+}
@@ -0,0 +1,223 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateHousehold(t *testing.T) {
+	Convey("Given a request to create a household", t, func() {
+		store := newDataStore()
+
+		w := serve(newRouter(store), http.MethodPost, "/households", writerToken, `{"name": "The Joneses"}`)
+
+		Convey("Then the household is created with the caller as its only owner", func() {
+			So(w.Code, ShouldEqual, http.StatusCreated)
+			So(w.Header().Get("Location"), ShouldEqual, "/households/the-joneses")
+			So(store.households["the-joneses"].Members, ShouldResemble, []models.HouseholdMember{{Name: "writer", Role: models.RoleOwner}})
+		})
+	})
+
+	Convey("Given a request to create a household with a name without letters or numbers", t, func() {
+		w := serve(newRouter(newDataStore()), http.MethodPost, "/households", writerToken, `{"name": "!!!"}`)
+
+		Convey("Then the request is rejected", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(errorCodes(w), ShouldResemble, []string{"invalid_household_name"})
+		})
+	})
+}
+
+func TestGetHouseholds(t *testing.T) {
+	tables := []struct {
+		givenTitle  string
+		token       string
+		expectedIDs []string
+	}{
+		{"Given a request for households by a member of one household", writerToken, []string{"smiths"}},
+		{"Given a request for households by an admin", adminToken, []string{"greens", "smiths"}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			w := serve(newRouter(newDataStore()), http.MethodGet, "/households", table.token, "")
+
+			Convey("Then only the households the caller can see are returned", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				var households models.Households
+				So(json.Unmarshal(w.Body.Bytes(), &households), ShouldBeNil)
+
+				ids := []string{}
+				for i := range households.Items {
+					ids = append(ids, households.Items[i].ID)
+				}
+				So(ids, ShouldHaveLength, len(table.expectedIDs))
+				for _, id := range table.expectedIDs {
+					So(ids, ShouldContain, id)
+				}
+			})
+		})
+	}
+
+	Convey("Given a request for a household the caller has only been invited to", t, func() {
+		w := serve(newRouter(newDataStore()), http.MethodGet, "/households/greens", writerToken, "")
+
+		Convey("Then the household is reported as not found", func() {
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(errorCodes(w), ShouldResemble, []string{"household_not_found"})
+		})
+	})
+}
+
+func TestHouseholdInvitations(t *testing.T) {
+	Convey("Given an owner of a household", t, func() {
+		store := newDataStore()
+		router := newRouter(store)
+
+		Convey("When they invite a caller to join", func() {
+			w := serve(router, http.MethodPost, "/households/smiths/invitations", adminToken, `{"name": "alice", "role": "editor"}`)
+
+			Convey("Then the invitation is stored without making the caller a member", func() {
+				So(w.Code, ShouldEqual, http.StatusCreated)
+
+				household := store.households["smiths"]
+				So(household.Role("alice"), ShouldBeEmpty)
+				So(household.Invitation("alice"), ShouldNotBeNil)
+				So(household.Invitation("alice").Role, ShouldEqual, models.RoleEditor)
+				So(household.Invitation("alice").InvitedBy, ShouldEqual, "admin")
+			})
+
+			Convey("Then inviting the caller again is rejected", func() {
+				w = serve(router, http.MethodPost, "/households/smiths/invitations", adminToken, `{"name": "alice", "role": "viewer"}`)
+
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(errorCodes(w), ShouldResemble, []string{"invitation_already_exists"})
+			})
+		})
+
+		Convey("When they invite an existing member", func() {
+			w := serve(router, http.MethodPost, "/households/smiths/invitations", adminToken, `{"name": "writer", "role": "owner"}`)
+
+			Convey("Then the invitation is rejected", func() {
+				So(w.Code, ShouldEqual, http.StatusConflict)
+				So(errorCodes(w), ShouldResemble, []string{"member_already_exists"})
+				So(store.households["smiths"].Role("writer"), ShouldEqual, models.RoleViewer)
+			})
+		})
+	})
+
+	Convey("Given a viewer of a household", t, func() {
+		store := newDataStore()
+
+		Convey("When they invite a caller to join", func() {
+			w := serve(newRouter(store), http.MethodPost, "/households/smiths/invitations", writerToken, `{"name": "bob", "role": "owner"}`)
+
+			Convey("Then the request is forbidden", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(errorCodes(w), ShouldResemble, []string{"not_household_owner"})
+				So(store.households["smiths"].Invitation("bob"), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a caller invited to a household", t, func() {
+		store := newDataStore()
+		router := newRouter(store)
+
+		Convey("When they accept the invitation", func() {
+			w := serve(router, http.MethodPost, "/households/greens/invitations/accept", writerToken, "")
+
+			Convey("Then they become a member with the role they were invited with", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+
+				household := store.households["greens"]
+				So(household.Role("writer"), ShouldEqual, models.RoleEditor)
+				So(household.Invitation("writer"), ShouldBeNil)
+
+				var returned models.Household
+				So(json.Unmarshal(w.Body.Bytes(), &returned), ShouldBeNil)
+				So(returned.Role("writer"), ShouldEqual, models.RoleEditor)
+				So(returned.Invitations, ShouldBeEmpty)
+			})
+
+			Convey("Then they can see the household", func() {
+				w = serve(router, http.MethodGet, "/households/greens", writerToken, "")
+				So(w.Code, ShouldEqual, http.StatusOK)
+			})
+		})
+
+		Convey("When they decline the invitation", func() {
+			w := serve(router, http.MethodDelete, "/households/greens/invitations/writer", writerToken, "")
+
+			Convey("Then the invitation is removed without making them a member", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(store.households["greens"].Invitation("writer"), ShouldBeNil)
+				So(store.households["greens"].Role("writer"), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a caller who has not been invited to a household", t, func() {
+		store := newDataStore()
+		router := newRouter(store)
+
+		Convey("When they accept an invitation to it", func() {
+			w := serve(router, http.MethodPost, "/households/smiths/invitations/accept", writerToken, "")
+
+			Convey("Then no invitation is found", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(errorCodes(w), ShouldResemble, []string{"invitation_not_found"})
+			})
+		})
+
+		Convey("When they remove an invitation to a household they are not a member of", func() {
+			store.households["greens"].Invitations = append(store.households["greens"].Invitations,
+				models.HouseholdInvitation{Name: "alice", Role: models.RoleViewer})
+			w := serve(router, http.MethodDelete, "/households/greens/invitations/alice", writerToken, "")
+
+			Convey("Then the household is reported as not found and the invitation is kept", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(errorCodes(w), ShouldResemble, []string{"household_not_found"})
+				So(store.households["greens"].Invitation("alice"), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestRemoveHouseholdMember(t *testing.T) {
+	tables := []struct {
+		givenTitle     string
+		path           string
+		token          string
+		expectedStatus int
+		expectedCodes  []string
+	}{
+		{"Given a member removing themselves", "/households/smiths/members/writer", writerToken, http.StatusNoContent, nil},
+		{"Given a viewer removing another member", "/households/smiths/members/admin", writerToken, http.StatusForbidden,
+			[]string{"not_household_owner"}},
+		{"Given an owner removing the last owner", "/households/smiths/members/admin", adminToken, http.StatusConflict,
+			[]string{"last_household_owner"}},
+		{"Given an owner removing a caller who is not a member", "/households/smiths/members/alice", adminToken, http.StatusNotFound,
+			[]string{"member_not_found"}},
+		{"Given a caller removing a member of a household they are not in", "/households/greens/members/green", writerToken,
+			http.StatusNotFound, []string{"household_not_found"}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			w := serve(newRouter(newDataStore()), http.MethodDelete, table.path, table.token, "")
+
+			Convey("Then the member is removed or the expected error is returned", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+				if table.expectedCodes != nil {
+					So(errorCodes(w), ShouldResemble, table.expectedCodes)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/helpers"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/slug"
+)
+
+func (api *FoodRecipeAPI) createAPIKey(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errs.ErrUnableToReadMessage
+	}
+
+	var newKey models.NewAPIKey
+	if err = json.Unmarshal(b, &newKey); err != nil {
+		return errs.ErrUnableToParseJSON
+	}
+
+	logData := log.Data{"key_name": newKey.Name}
+	now := time.Now().UTC()
+
+	var validationErr error
+	if newKey.Name == "" || slug.Make(newKey.Name) != newKey.Name {
+		validationErr = errs.Append(validationErr, errs.ErrInvalidKeyName.WithField("/name").WithValues(map[string]string{"name": newKey.Name}))
+	}
+
+	validationErr = errs.Append(validationErr, validateScopes(newKey.Scopes))
+
+	if newKey.ExpiresAt != nil && !newKey.ExpiresAt.After(now) {
+		expiresAt := map[string]string{"expires_at": newKey.ExpiresAt.Format(time.RFC3339)}
+		validationErr = errs.Append(validationErr, errs.ErrInvalidExpiry.WithField("/expires_at").WithValues(expiresAt))
+	}
+
+	if validationErr != nil {
+		return validationErr
+	}
+
+	// names identify the caller in logs so cannot be shared with a key from configuration
+	if api.isReservedKeyName(newKey.Name) {
+		log.Warn(ctx, "create api key: name is used by an api key from configuration", logData)
+		return errs.ErrAPIKeyAlreadyExists
+	}
+
+	key, err := auth.GenerateKey()
+	if err != nil {
+		log.Error(ctx, "create api key: failed to generate key", err, logData)
+		return fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	issued := &models.IssuedAPIKey{
+		APIKey: models.APIKey{
+			Name:      newKey.Name,
+			Hash:      auth.HashKey(key),
+			Scopes:    newKey.Scopes,
+			CreatedAt: now,
+			ExpiresAt: newKey.ExpiresAt,
+		},
+		Key: key,
+	}
+
+	if err = api.DataStore.CreateAPIKey(ctx, &issued.APIKey); err != nil {
+		if err == errs.ErrAPIKeyAlreadyExists {
+			log.Warn(ctx, "create api key: api key already exists", logData)
+			return err
+		}
+
+		log.Error(ctx, "create api key: failed to store api key", err, logData)
+		return fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	// the key is only ever returned in this response
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Location", "/admin/keys/"+issued.Name)
+	writeJSON(w, req, http.StatusCreated, issued, logData)
+
+	log.Info(ctx, "create api key: request successful", logData)
+	return nil
+}
+
+func (api *FoodRecipeAPI) getAPIKeys(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	keys, err := api.DataStore.GetAPIKeys(ctx)
+	if err != nil {
+		log.Error(ctx, "get api keys: failed to retrieve api keys", err)
+		return fmt.Errorf("failed to retrieve api keys: %w", err)
+	}
+
+	list := models.APIKeys{
+		Count: len(keys),
+		Items: keys,
+	}
+
+	writeJSON(w, req, http.StatusOK, list, nil)
+
+	log.Info(ctx, "get api keys: request successful")
+	return nil
+}
+
+func (api *FoodRecipeAPI) getAPIKey(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	name := mux.Vars(req)["name"]
+	logData := log.Data{"key_name": name}
+
+	key, err := api.DataStore.GetAPIKey(ctx, name)
+	if err != nil {
+		return apiKeyError(ctx, "get api key", err, logData)
+	}
+
+	writeJSON(w, req, http.StatusOK, key, logData)
+
+	log.Info(ctx, "get api key: request successful", logData)
+	return nil
+}
+
+func (api *FoodRecipeAPI) updateAPIKeyExpiry(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	name := mux.Vars(req)["name"]
+	logData := log.Data{"key_name": name}
+
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return errs.ErrUnableToReadMessage
+	}
+
+	var expiry models.APIKeyExpiry
+	if err = json.Unmarshal(b, &expiry); err != nil {
+		return errs.ErrUnableToParseJSON
+	}
+
+	if expiry.ExpiresAt != nil && !expiry.ExpiresAt.After(time.Now()) {
+		return errs.ErrInvalidExpiry.WithField("/expires_at").WithValues(map[string]string{"expires_at": expiry.ExpiresAt.Format(time.RFC3339)})
+	}
+
+	if err = api.DataStore.UpdateAPIKeyExpiry(ctx, name, expiry.ExpiresAt); err != nil {
+		return apiKeyError(ctx, "update api key expiry", err, logData)
+	}
+
+	key, err := api.DataStore.GetAPIKey(ctx, name)
+	if err != nil {
+		return apiKeyError(ctx, "update api key expiry", err, logData)
+	}
+
+	writeJSON(w, req, http.StatusOK, key, logData)
+
+	log.Info(ctx, "update api key expiry: request successful", logData)
+	return nil
+}
+
+func (api *FoodRecipeAPI) revokeAPIKey(w http.ResponseWriter, req *http.Request) error {
+	defer DrainBody(req)
+	ctx := req.Context()
+
+	name := mux.Vars(req)["name"]
+	logData := log.Data{"key_name": name}
+
+	if err := api.DataStore.RevokeAPIKey(ctx, name, time.Now().UTC()); err != nil {
+		return apiKeyError(ctx, "revoke api key", err, logData)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	log.Info(ctx, "revoke api key: request successful", logData)
+	return nil
+}
+
+func (api *FoodRecipeAPI) isReservedKeyName(name string) bool {
+	for _, reserved := range api.ReservedKeyNames {
+		if reserved == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateScopes(scopes []string) error {
+	var invalid []string
+	for _, scope := range scopes {
+		if !auth.Scope(scope).IsValid() {
+			invalid = append(invalid, scope)
+		}
+	}
+
+	if len(scopes) == 0 || len(invalid) > 0 {
+		return errs.ErrInvalidScopes.WithField("/scopes").WithValues(map[string]string{"scopes": helpers.StringifyWords(invalid)})
+	}
+
+	return nil
+}
+
+// apiKeyError logs the error from accessing an api key and returns the error to respond with
+func apiKeyError(ctx context.Context, action string, err error, logData log.Data) error {
+	if err == errs.ErrAPIKeyNotFound {
+		log.Warn(ctx, action+": api key not found", logData)
+		return err
+	}
+
+	log.Error(ctx, action+": failed to access api key", err, logData)
+	return fmt.Errorf("%s: %w", action, err)
+}
@@ -0,0 +1,165 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateAPIKey(t *testing.T) {
+	Convey("Given a request to issue an api key", t, func() {
+		store := newDataStore()
+		router := newRouter(store)
+
+		Convey("When the request is handled", func() {
+			w := serve(router, http.MethodPost, "/admin/keys", adminToken, `{"name": "importer", "scopes": ["recipes:write"]}`)
+
+			Convey("Then the key is returned once and only its hash is stored", func() {
+				So(w.Code, ShouldEqual, http.StatusCreated)
+				So(w.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+				So(w.Header().Get("Location"), ShouldEqual, "/admin/keys/importer")
+
+				var issued models.IssuedAPIKey
+				So(json.Unmarshal(w.Body.Bytes(), &issued), ShouldBeNil)
+				So(issued.Key, ShouldStartWith, "frk_")
+				So(w.Body.String(), ShouldNotContainSubstring, `"hash"`)
+
+				stored := store.keys["importer"]
+				So(stored, ShouldNotBeNil)
+				So(stored.Hash, ShouldEqual, auth.HashKey(issued.Key))
+				So(stored.Scopes, ShouldResemble, []string{"recipes:write"})
+			})
+		})
+	})
+
+	tables := []struct {
+		givenTitle     string
+		body           string
+		expectedStatus int
+		expectedCodes  []string
+	}{
+		{"Given a request to issue an api key with the name of a key from configuration", `{"name": "reserved", "scopes": ["admin"]}`,
+			http.StatusConflict, []string{"api_key_already_exists"}},
+		{"Given a request to issue an api key with the name of an issued key", `{"name": "ci", "scopes": ["admin"]}`,
+			http.StatusConflict, []string{"api_key_already_exists"}},
+		{"Given a request to issue an api key without scopes", `{"name": "importer"}`,
+			http.StatusBadRequest, []string{"invalid_scopes"}},
+		{"Given a request to issue an api key with an invalid name and expiry", `{"name": "The Importer", "scopes": ["admin"],
+			"expires_at": "2000-01-01T00:00:00Z"}`, http.StatusBadRequest, []string{"invalid_key_name", "invalid_expiry"}},
+		{"Given a request to issue an api key with a body which is not json", `name=importer`,
+			http.StatusBadRequest, []string{"invalid_json"}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			keys := len(store.keys)
+
+			w := serve(newRouter(store), http.MethodPost, "/admin/keys", adminToken, table.body)
+
+			Convey("Then the request is rejected and no key is stored", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+				So(errorCodes(w), ShouldResemble, table.expectedCodes)
+				So(store.keys, ShouldHaveLength, keys)
+			})
+		})
+	}
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	Convey("Given an issued api key", t, func() {
+		store := newDataStore()
+		store.keys["ci"].Hash = auth.HashKey("frk_secret")
+
+		Convey("When the api keys are requested", func() {
+			w := serve(newRouter(store), http.MethodGet, "/admin/keys", adminToken, "")
+
+			Convey("Then the keys are returned without their hashes", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Body.String(), ShouldNotContainSubstring, store.keys["ci"].Hash)
+
+				var keys models.APIKeys
+				So(json.Unmarshal(w.Body.Bytes(), &keys), ShouldBeNil)
+				So(keys.Count, ShouldEqual, 1)
+				So(keys.Items[0].Name, ShouldEqual, "ci")
+			})
+		})
+	})
+}
+
+func TestUpdateAPIKeyExpiry(t *testing.T) {
+	Convey("Given an api key which expires", t, func() {
+		store := newDataStore()
+		expiresAt := time.Now().Add(time.Hour)
+		store.keys["ci"].ExpiresAt = &expiresAt
+		router := newRouter(store)
+
+		Convey("When the expiry is removed", func() {
+			w := serve(router, http.MethodPut, "/admin/keys/ci/expiry", adminToken, `{"expires_at": null}`)
+
+			Convey("Then the key no longer expires", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(store.keys["ci"].ExpiresAt, ShouldBeNil)
+			})
+		})
+
+		Convey("When the expiry is changed to a time in the past", func() {
+			w := serve(router, http.MethodPut, "/admin/keys/ci/expiry", adminToken, `{"expires_at": "2000-01-01T00:00:00Z"}`)
+
+			Convey("Then the request is rejected and the expiry is unchanged", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+				So(errorCodes(w), ShouldResemble, []string{"invalid_expiry"})
+				So(*store.keys["ci"].ExpiresAt, ShouldEqual, expiresAt)
+			})
+		})
+
+		Convey("When the expiry of an unknown key is changed", func() {
+			w := serve(router, http.MethodPut, "/admin/keys/cd/expiry", adminToken, `{"expires_at": null}`)
+
+			Convey("Then a not found error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(errorCodes(w), ShouldResemble, []string{"api_key_not_found"})
+			})
+		})
+	})
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	Convey("Given an issued api key", t, func() {
+		store := newDataStore()
+		router := newRouter(store)
+
+		Convey("When the key is revoked", func() {
+			w := serve(router, http.MethodDelete, "/admin/keys/ci", adminToken, "")
+
+			Convey("Then the key is kept but marked as revoked", func() {
+				So(w.Code, ShouldEqual, http.StatusNoContent)
+				So(store.keys["ci"].RevokedAt, ShouldNotBeNil)
+				So(store.keys["ci"].IsActive(time.Now()), ShouldBeFalse)
+			})
+		})
+
+		Convey("When an unknown key is revoked", func() {
+			w := serve(router, http.MethodDelete, "/admin/keys/cd", adminToken, "")
+
+			Convey("Then a not found error is returned", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+				So(errorCodes(w), ShouldResemble, []string{"api_key_not_found"})
+			})
+		})
+
+		Convey("When the key is revoked by a caller without the admin scope", func() {
+			w := serve(router, http.MethodDelete, "/admin/keys/ci", writerToken, "")
+
+			Convey("Then the request is forbidden and the key is not revoked", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(store.keys["ci"].RevokedAt, ShouldBeNil)
+			})
+		})
+	})
+}
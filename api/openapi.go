@@ -0,0 +1,23 @@
+package api
+
+import (
+	_ "embed" // embeds the OpenAPI document
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// OpenAPI is the OpenAPI 3 document describing the routes of the API
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// getOpenAPI responds with the OpenAPI document, which does not require authentication
+func getOpenAPI(w http.ResponseWriter, req *http.Request) {
+	defer DrainBody(req)
+
+	w.Header().Set("Content-Type", "application/json")
+	if _, err := w.Write(OpenAPI); err != nil {
+		log.Error(req.Context(), "get openapi: failed to write response data", err)
+	}
+}
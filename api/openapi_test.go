@@ -0,0 +1,555 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"github.com/nshumoogum/food-recipes/api"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	adminToken    = "admin-token"
+	writerToken   = "writer-token"
+	importerToken = "importer-token"
+	rejectedToken = "rejected-token"
+
+	recipeBody = `{"cook_time": 30, "difficulty": "easy", "ingredients": [{"item": "flour", "quantity": 200, "unit": "g"}],
+		"location": {"cook_book": "Family favourites", "page": 12}, "portion_size": 4}`
+)
+
+// authenticator identifies callers by a fixed set of tokens
+type authenticator map[string]*auth.Identity
+
+func (a authenticator) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	if token == rejectedToken {
+		return nil, &auth.TokenError{Description: "token \"kid\" is\r\nunknown"}
+	}
+
+	if identity, ok := a[token]; ok {
+		return identity, nil
+	}
+
+	return nil, errs.ErrInvalidCredentials
+}
+
+// dataStore holds recipes, aliases of recipes, households, api keys and audit entries in memory
+type dataStore struct {
+	recipes    map[string]*models.Recipe
+	aliases    map[string]string
+	households map[string]*models.Household
+	keys       map[string]*models.APIKey
+	audit      []models.AuditEntry
+	// afterGetRecipe, if set, is called each time a recipe is read to change the stored recipes as another caller would
+	// between a recipe being read and written
+	afterGetRecipe func()
+}
+
+func newDataStore() *dataStore {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &dataStore{
+		recipes: map[string]*models.Recipe{
+			"pancakes":     newRecipe("pancakes", "writer", models.VisibilityPublic),
+			"roast-dinner": newRecipe("roast-dinner", "admin", models.VisibilityPublic),
+			"secret-sauce": newRecipe("secret-sauce", "admin", models.VisibilityPrivate),
+		},
+		aliases: map[string]string{
+			"pancake":      "pancakes",
+			"secret":       "secret-sauce",
+			"sunday-roast": "roast",
+		},
+		households: map[string]*models.Household{
+			"smiths": {ID: "smiths", Name: "Smiths", CreatedAt: created, Members: []models.HouseholdMember{
+				{Name: "admin", Role: models.RoleOwner},
+				{Name: "writer", Role: models.RoleViewer},
+			}},
+			"greens": {ID: "greens", Name: "Greens", CreatedAt: created,
+				Members: []models.HouseholdMember{{Name: "green", Role: models.RoleOwner}},
+				Invitations: []models.HouseholdInvitation{
+					{Name: "writer", Role: models.RoleEditor, InvitedBy: "green", InvitedAt: created},
+				},
+			},
+		},
+		keys: map[string]*models.APIKey{
+			"ci": {Name: "ci", Scopes: []string{"recipes:write"}, CreatedAt: created},
+		},
+		audit: []models.AuditEntry{
+			{ID: "1", RecipeID: "pancakes", Action: models.AuditActionCreate, Caller: "writer", AuthMethod: "api_key", Timestamp: created},
+		},
+	}
+}
+
+func newRecipe(id, owner, visibility string) *models.Recipe {
+	return &models.Recipe{
+		ID:          id,
+		Title:       strings.ReplaceAll(id, "-", " "),
+		CookTime:    20,
+		Difficulty:  "easy",
+		Ingredients: []models.Ingredient{{Item: "flour", Quantity: 200, Unit: "g"}},
+		Location:    models.Location{CookBook: "Family favourites", Page: 12},
+		PortionSize: 4,
+		Owner:       owner,
+		Visibility:  visibility,
+	}
+}
+
+func (d *dataStore) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	if _, ok := d.keys[key.Name]; ok {
+		return errs.ErrAPIKeyAlreadyExists
+	}
+
+	d.keys[key.Name] = key
+	return nil
+}
+
+func (d *dataStore) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys := make([]models.APIKey, 0, len(d.keys))
+	for _, key := range d.keys {
+		keys = append(keys, *key)
+	}
+
+	return keys, nil
+}
+
+func (d *dataStore) GetAPIKey(ctx context.Context, name string) (*models.APIKey, error) {
+	key, ok := d.keys[name]
+	if !ok {
+		return nil, errs.ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (d *dataStore) UpdateAPIKeyExpiry(ctx context.Context, name string, expiresAt *time.Time) error {
+	key, err := d.GetAPIKey(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	key.ExpiresAt = expiresAt
+	return nil
+}
+
+func (d *dataStore) RevokeAPIKey(ctx context.Context, name string, revokedAt time.Time) error {
+	key, err := d.GetAPIKey(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	key.RevokedAt = &revokedAt
+	return nil
+}
+
+func (d *dataStore) CreateHousehold(ctx context.Context, household *models.Household) error {
+	if _, ok := d.households[household.ID]; ok {
+		return errs.ErrHouseholdAlreadyExists
+	}
+
+	d.households[household.ID] = household
+	return nil
+}
+
+func (d *dataStore) GetHouseholds(ctx context.Context, member string) ([]models.Household, error) {
+	households := []models.Household{}
+	for _, household := range d.households {
+		if member == "" || household.Role(member) != "" {
+			households = append(households, *household)
+		}
+	}
+
+	return households, nil
+}
+
+func (d *dataStore) GetHousehold(ctx context.Context, id string) (*models.Household, error) {
+	household, ok := d.households[id]
+	if !ok {
+		return nil, errs.ErrHouseholdNotFound
+	}
+
+	copied := *household
+	copied.Members = append([]models.HouseholdMember{}, household.Members...)
+	return &copied, nil
+}
+
+func (d *dataStore) InviteHouseholdMember(ctx context.Context, id string, invitation models.HouseholdInvitation) error {
+	household, ok := d.households[id]
+	if !ok {
+		return errs.ErrHouseholdNotFound
+	}
+
+	if household.Role(invitation.Name) != "" {
+		return errs.ErrMemberAlreadyExists
+	}
+
+	if household.Invitation(invitation.Name) != nil {
+		return errs.ErrInvitationExists
+	}
+
+	household.Invitations = append(household.Invitations, invitation)
+	return nil
+}
+
+func (d *dataStore) AcceptHouseholdInvitation(ctx context.Context, id string, invitation models.HouseholdInvitation) error {
+	household, ok := d.households[id]
+	if !ok || household.Invitation(invitation.Name) == nil {
+		return errs.ErrInvitationNotFound
+	}
+
+	if err := d.RemoveHouseholdInvitation(ctx, id, invitation.Name); err != nil {
+		return err
+	}
+
+	household.Members = append(household.Members, models.HouseholdMember{Name: invitation.Name, Role: invitation.Role})
+	return nil
+}
+
+func (d *dataStore) RemoveHouseholdInvitation(ctx context.Context, id, name string) error {
+	household, ok := d.households[id]
+	if !ok {
+		return errs.ErrInvitationNotFound
+	}
+
+	for i := range household.Invitations {
+		if household.Invitations[i].Name == name {
+			household.Invitations = append(household.Invitations[:i], household.Invitations[i+1:]...)
+			return nil
+		}
+	}
+
+	return errs.ErrInvitationNotFound
+}
+
+func (d *dataStore) RemoveHouseholdMember(ctx context.Context, id, name string) error {
+	household, ok := d.households[id]
+	if !ok {
+		return errs.ErrHouseholdNotFound
+	}
+
+	role := household.Role(name)
+	if role == "" {
+		return errs.ErrMemberNotFound
+	}
+
+	if role == models.RoleOwner && household.Owners() == 1 {
+		return errs.ErrLastHouseholdOwner
+	}
+
+	for i := range household.Members {
+		if household.Members[i].Name == name {
+			household.Members = append(household.Members[:i], household.Members[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (d *dataStore) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	d.audit = append(d.audit, *entry)
+	return nil
+}
+
+func (d *dataStore) GetAuditEntries(ctx context.Context, filter models.AuditFilter, offset, limit int) ([]models.AuditEntry, int64, error) {
+	return d.audit, int64(len(d.audit)), nil
+}
+
+func (d *dataStore) GetRecipes(ctx context.Context, filter models.RecipeFilter, offset, limit int) ([]models.Recipe, int64, error) {
+	ids := make([]string, 0, len(d.recipes))
+	for id, recipe := range d.recipes {
+		if filter.Matches(recipe) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	recipes := []models.Recipe{}
+	for i := offset; i < len(ids) && i < offset+limit; i++ {
+		recipes = append(recipes, *d.recipes[ids[i]])
+	}
+
+	return recipes, int64(len(ids)), nil
+}
+
+func (d *dataStore) GetRecipe(ctx context.Context, id string) (*models.Recipe, error) {
+	recipe, ok := d.recipes[id]
+	if d.afterGetRecipe != nil {
+		d.afterGetRecipe()
+	}
+
+	if !ok {
+		return nil, errs.ErrRecipeNotFound
+	}
+
+	copied := *recipe
+	return &copied, nil
+}
+
+func (d *dataStore) GetRecipeAlias(ctx context.Context, id string) (*models.RecipeAlias, error) {
+	recipeID, ok := d.aliases[id]
+	if !ok {
+		return nil, errs.ErrRecipeNotFound
+	}
+
+	return &models.RecipeAlias{ID: id, RecipeID: recipeID}, nil
+}
+
+func (d *dataStore) CreateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if _, ok := d.recipes[recipe.ID]; ok {
+		return errs.ErrRecipeAlreadyExists
+	}
+
+	copied := *recipe
+	d.recipes[recipe.ID] = &copied
+	return nil
+}
+
+func (d *dataStore) ReplaceRecipe(ctx context.Context, previous, recipe *models.Recipe) error {
+	if stored, ok := d.recipes[previous.ID]; !ok || stored.Owner != previous.Owner || stored.Household != previous.Household {
+		return errs.ErrRecipeChanged
+	}
+
+	copied := *recipe
+	d.recipes[recipe.ID] = &copied
+	return nil
+}
+
+func (d *dataStore) DeleteRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if stored, ok := d.recipes[recipe.ID]; !ok || stored.Owner != recipe.Owner || stored.Household != recipe.Household {
+		return errs.ErrRecipeChanged
+	}
+
+	delete(d.recipes, recipe.ID)
+	return nil
+}
+
+func loadOpenAPI(t *testing.T) *openapi3.T {
+	doc, err := openapi3.NewLoader().LoadFromData(api.OpenAPI)
+	if err != nil {
+		t.Fatalf("unable to load openapi document: %v", err)
+	}
+
+	return doc
+}
+
+// newRouter returns a router serving the API backed by the data store
+func newRouter(dataStore *dataStore) *mux.Router {
+	authenticator := authenticator{
+		adminToken: {Name: "admin", Method: "api_key", Scopes: []auth.Scope{auth.ScopeAdmin}},
+		writerToken: {Name: "writer", Method: "api_key", Scopes: []auth.Scope{auth.ScopeRecipesRead, auth.ScopeRecipesWrite,
+			auth.ScopeRecipesDelete}},
+		importerToken: {Name: "importer", Method: "api_key", Scopes: []auth.Scope{auth.ScopeRecipesWrite}},
+	}
+
+	router := mux.NewRouter()
+	api.NewFoodRecipeAPI(context.Background(), authenticator, dataStore, []string{"reserved"}, 1000, router)
+
+	return router
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	Convey("Given the OpenAPI document", t, func() {
+		Convey("Then the document is valid", func() {
+			So(doc.Validate(context.Background()), ShouldBeNil)
+		})
+
+		Convey("Then every route registered by the API is described", func() {
+			var undocumented []string
+
+			err := newRouter(newDataStore()).Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+				template, err := route.GetPathTemplate()
+				if err != nil {
+					return err
+				}
+
+				methods, err := route.GetMethods()
+				if err != nil {
+					return err
+				}
+
+				for _, method := range methods {
+					path := doc.Paths.Find(template)
+					if path == nil || path.GetOperation(method) == nil {
+						undocumented = append(undocumented, method+" "+template)
+					}
+				}
+
+				return nil
+			})
+
+			So(err, ShouldBeNil)
+			So(undocumented, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestHandlerResponsesMatchOpenAPI(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	specRouter, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		t.Fatalf("unable to create router from openapi document: %v", err)
+	}
+
+	tables := []struct {
+		givenTitle     string
+		method         string
+		path           string
+		token          string
+		contentType    string
+		accept         string
+		body           string
+		expectedStatus int
+	}{
+		{"Given a request for the OpenAPI document", http.MethodGet, "/openapi.json", "", "", "", "", http.StatusOK},
+		{"Given a request for recipes", http.MethodGet, "/recipes?limit=2", "", "", "", "", http.StatusOK},
+		{"Given a request for recipes including the caller's own", http.MethodGet, "/recipes", adminToken, "", "", "", http.StatusOK},
+		{"Given a request for a recipe", http.MethodGet, "/recipes/pancakes", "", "", "", "", http.StatusOK},
+		{"Given a request for a recipe which does not exist", http.MethodGet, "/recipes/waffles", "", "", "", "", http.StatusNotFound},
+		{"Given a request to create a recipe", http.MethodPost, "/recipes", writerToken, "application/json", "",
+			`{"title": "Waffles", ` + recipeBody[1:], http.StatusCreated},
+		{"Given a request to create a recipe which exists", http.MethodPost, "/recipes", writerToken, "application/json", "",
+			`{"title": "Pancakes", ` + recipeBody[1:], http.StatusConflict},
+		{"Given a request to replace a recipe", http.MethodPut, "/recipes/pancakes", writerToken, "application/json", "", recipeBody,
+			http.StatusOK},
+		{"Given a request to replace a recipe which does not exist", http.MethodPut, "/recipes/waffles", writerToken, "application/json", "",
+			recipeBody, http.StatusCreated},
+		{"Given a request to patch a recipe", http.MethodPatch, "/recipes/pancakes", writerToken, "application/json-patch+json", "",
+			`[{"op": "replace", "path": "/cook_time", "value": 30}]`, http.StatusOK},
+		{"Given a request to merge patch a recipe", http.MethodPatch, "/recipes/pancakes", writerToken, "application/merge-patch+json", "",
+			`{"notes": "Serve with syrup"}`, http.StatusOK},
+		{"Given a request to patch a recipe which does not exist", http.MethodPatch, "/recipes/waffles", writerToken,
+			"application/merge-patch+json", "", `{"cook_time": 30}`, http.StatusNotFound},
+		{"Given a request to delete a recipe", http.MethodDelete, "/recipes/pancakes", writerToken, "", "", "", http.StatusNoContent},
+		{"Given a request for recipes with an invalid limit", http.MethodGet, "/recipes?limit=ten", "", "", "", "", http.StatusBadRequest},
+		{"Given a request to create a recipe without a bearer token", http.MethodPost, "/recipes", "", "application/json", "", "{}",
+			http.StatusUnauthorized},
+		{"Given a request to create an invalid recipe", http.MethodPost, "/recipes", writerToken, "application/json", "",
+			`{"title": "Pancakes", "difficulty": "impossible", "portion_size": -1}`, http.StatusBadRequest},
+		{"Given a request to create an invalid recipe accepting problem details", http.MethodPost, "/recipes", writerToken,
+			"application/json", "application/problem+json", `{"title": "Pancakes"}`, http.StatusBadRequest},
+		{"Given a request to patch a recipe with an unsupported content type", http.MethodPatch, "/recipes/pancakes", writerToken,
+			"text/plain", "", "title", http.StatusUnsupportedMediaType},
+		{"Given a request to patch a recipe the caller cannot see", http.MethodPatch, "/recipes/secret-sauce", writerToken,
+			"application/merge-patch+json", "", `{"cook_time": 30}`, http.StatusNotFound},
+		{"Given a request to patch a recipe owned by another caller", http.MethodPatch, "/recipes/roast-dinner", writerToken,
+			"application/merge-patch+json", "", `{"cook_time": 30}`, http.StatusForbidden},
+		{"Given a request to replace a recipe the caller cannot see", http.MethodPut, "/recipes/secret-sauce", writerToken,
+			"application/json", "", recipeBody, http.StatusNotFound},
+		{"Given a request to replace a recipe owned by another caller", http.MethodPut, "/recipes/roast-dinner", writerToken,
+			"application/json", "", recipeBody, http.StatusForbidden},
+		{"Given a request to delete a recipe the caller cannot see", http.MethodDelete, "/recipes/secret-sauce", writerToken, "", "", "",
+			http.StatusNoContent},
+		{"Given a request to delete a recipe owned by another caller", http.MethodDelete, "/recipes/roast-dinner", writerToken, "", "", "",
+			http.StatusForbidden},
+		{"Given a request for households", http.MethodGet, "/households", writerToken, "", "", "", http.StatusOK},
+		{"Given a request to create a household", http.MethodPost, "/households", writerToken, "application/json", "",
+			`{"name": "Joneses"}`, http.StatusCreated},
+		{"Given a request to create a household which exists", http.MethodPost, "/households", writerToken, "application/json", "",
+			`{"name": "Smiths"}`, http.StatusConflict},
+		{"Given a request for a household", http.MethodGet, "/households/smiths", writerToken, "", "", "", http.StatusOK},
+		{"Given a request for a household which does not exist", http.MethodGet, "/households/browns", writerToken, "", "", "",
+			http.StatusNotFound},
+		{"Given a request to invite a household member", http.MethodPost, "/households/smiths/invitations", adminToken, "application/json",
+			"", `{"name": "alice", "role": "editor"}`, http.StatusCreated},
+		{"Given a request to invite an invalid household member", http.MethodPost, "/households/smiths/invitations", adminToken,
+			"application/json", "", `{"role": "chef"}`, http.StatusBadRequest},
+		{"Given a request to invite a household member by a viewer", http.MethodPost, "/households/smiths/invitations", writerToken,
+			"application/json", "", `{"name": "bob", "role": "viewer"}`, http.StatusForbidden},
+		{"Given a request to invite an existing household member", http.MethodPost, "/households/smiths/invitations", adminToken,
+			"application/json", "", `{"name": "writer", "role": "editor"}`, http.StatusConflict},
+		{"Given a request to accept an invitation to a household", http.MethodPost, "/households/greens/invitations/accept", writerToken,
+			"", "", "", http.StatusOK},
+		{"Given a request to accept an invitation to a household the caller was not invited to", http.MethodPost,
+			"/households/smiths/invitations/accept", adminToken, "", "", "", http.StatusNotFound},
+		{"Given a request to decline an invitation to a household", http.MethodDelete, "/households/greens/invitations/writer",
+			writerToken, "", "", "", http.StatusNoContent},
+		{"Given a request to cancel an invitation which does not exist", http.MethodDelete, "/households/smiths/invitations/alice",
+			adminToken, "", "", "", http.StatusNotFound},
+		{"Given a request to remove a household member", http.MethodDelete, "/households/smiths/members/writer", writerToken, "", "", "",
+			http.StatusNoContent},
+		{"Given a request to remove the last owner of a household", http.MethodDelete, "/households/smiths/members/admin", adminToken,
+			"", "", "", http.StatusConflict},
+		{"Given a request for api keys", http.MethodGet, "/admin/keys", adminToken, "", "", "", http.StatusOK},
+		{"Given a request for api keys without the admin scope", http.MethodGet, "/admin/keys", writerToken, "", "", "",
+			http.StatusForbidden},
+		{"Given a request to issue an api key", http.MethodPost, "/admin/keys", adminToken, "application/json", "",
+			`{"name": "importer", "scopes": ["recipes:write"]}`, http.StatusCreated},
+		{"Given a request to issue an invalid api key", http.MethodPost, "/admin/keys", adminToken, "application/json", "",
+			`{"name": "Importer", "scopes": ["recipes:everything"]}`, http.StatusBadRequest},
+		{"Given a request for an api key", http.MethodGet, "/admin/keys/ci", adminToken, "", "", "", http.StatusOK},
+		{"Given a request for an api key which does not exist", http.MethodGet, "/admin/keys/cd", adminToken, "", "", "",
+			http.StatusNotFound},
+		{"Given a request to change the expiry of an api key", http.MethodPut, "/admin/keys/ci/expiry", adminToken, "application/json", "",
+			`{"expires_at": "2100-01-01T00:00:00Z"}`, http.StatusOK},
+		{"Given a request to revoke an api key", http.MethodDelete, "/admin/keys/ci", adminToken, "", "", "", http.StatusNoContent},
+		{"Given a request for the audit log", http.MethodGet, "/admin/audit?recipe_id=pancakes", adminToken, "", "", "", http.StatusOK},
+		{"Given a request for the audit log with an invalid since", http.MethodGet, "/admin/audit?since=yesterday", adminToken, "", "", "",
+			http.StatusBadRequest},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			router := newRouter(newDataStore())
+
+			req := httptest.NewRequest(table.method, table.path, strings.NewReader(table.body))
+			if table.token != "" {
+				req.Header.Set("Authorization", "Bearer "+table.token)
+			}
+			if table.contentType != "" {
+				req.Header.Set("Content-Type", table.contentType)
+			}
+			if table.accept != "" {
+				req.Header.Set("Accept", table.accept)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			Convey("Then the response is described by the OpenAPI document", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+				So(validateResponse(specRouter, req, w), ShouldBeNil)
+			})
+		})
+	}
+}
+
+// validateResponse checks the response to the request matches the operation described for it in the OpenAPI document
+func validateResponse(specRouter routers.Router, req *http.Request, w *httptest.ResponseRecorder) error {
+	route, pathParams, err := specRouter.FindRoute(req)
+	if err != nil {
+		return err
+	}
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		},
+		Status: w.Code,
+		Header: w.Header(),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+			MultiError:            true,
+		},
+	}
+	input.SetBodyBytes(w.Body.Bytes())
+
+	return openapi3filter.ValidateResponse(context.Background(), input)
+}
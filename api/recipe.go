@@ -3,7 +3,10 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"strconv"
 	"strings"
@@ -17,35 +20,26 @@ import (
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
 	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
 	"github.com/nshumoogum/food-recipes/helpers"
 	"github.com/nshumoogum/food-recipes/models"
 	"github.com/nshumoogum/food-recipes/patch"
-	"go.mongodb.org/mongo-driver/mongo"
-	"gopkg.in/mgo.v2/bson"
+	"github.com/nshumoogum/food-recipes/slug"
 )
 
 const defaultLimit = 20
 
 var casing = cases.Title(language.English)
 
-func (api *FoodRecipeAPI) getRecipes(w http.ResponseWriter, req *http.Request) {
+func (api *FoodRecipeAPI) getRecipes(w http.ResponseWriter, req *http.Request) error {
 	defer DrainBody(req)
 	ctx := req.Context()
 
 	requestedOffset := req.FormValue("offset")
 	requestedLimit := req.FormValue("limit")
 
-	var errorObjects []*models.ErrorObject
-
-	limit, err := helpers.CalculateLimit(ctx, defaultLimit, api.DefaultMaxResults, requestedLimit)
-	if err != nil {
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error(), ErrorValues: err.(*errs.ErrorObject).Values()})
-	}
-
-	offset, err := helpers.CalculateOffset(ctx, requestedOffset)
-	if err != nil {
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error(), ErrorValues: err.(*errs.ErrorObject).Values()})
-	}
+	limit, limitErr := helpers.CalculateLimit(ctx, defaultLimit, api.DefaultMaxResults, requestedLimit)
+	offset, offsetErr := helpers.CalculateOffset(ctx, requestedOffset)
 
 	page := models.PageVariables{
 		DefaultMaxResults: api.DefaultMaxResults,
@@ -53,96 +47,47 @@ func (api *FoodRecipeAPI) getRecipes(w http.ResponseWriter, req *http.Request) {
 		Offset:            offset,
 	}
 
-	if errorObject := models.ValidatePage(page); errorObject != nil {
-		errorObjects = append(errorObjects, errorObject...)
+	if err := errs.Append(limitErr, offsetErr, models.ValidatePage(page)); err != nil {
+		return err
 	}
 
-	if len(errorObjects) != 0 {
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+	access, err := api.getAccess(ctx)
+	if err != nil {
+		return accessError(ctx, err, nil)
 	}
 
-	var list models.Recipes
-	list.Items = []models.Recipe{}
-
-	collection := api.MongoClient.Database("food-recipes").Collection("recipes")
-
-	count, err := collection.CountDocuments(ctx, bson.M{})
+	recipes, count, err := api.DataStore.GetRecipes(ctx, access.visibleRecipesFilter(), page.Offset, page.Limit)
 	if err != nil {
-		log.Error(ctx, "get recipes: error returned attempting to count documents", err)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		log.Error(ctx, "get recipes: error returned retrieving a list of recipes", err)
+		return fmt.Errorf("failed to get recipes: %w", err)
 	}
 
-	if count > 0 {
-		var cur *mongo.Cursor
-		cur, err = collection.Find(ctx, bson.M{})
-		if err != nil {
-			log.Error(ctx, "get recipes: error returned retrieving a list of recipes", err)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-			ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-			return
-		}
-		defer cur.Close(ctx)
-
-		var offsetCounter, limitCounter int
-
-		for cur.Next(ctx) {
-			offsetCounter++
-			if offsetCounter <= page.Offset {
-				continue
-			}
-
-			if limitCounter >= page.Limit {
-				break
-			}
-			limitCounter++
-
-			item := &models.Recipe{}
-
-			err = cur.Decode(item)
-			if err != nil {
-				log.Error(ctx, "get recipes: unable to decode recipe", err)
-				errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-				ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-				return
-			}
-
-			list.Items = append(list.Items, *item)
-		}
-		if err = cur.Err(); err != nil {
-			log.Error(ctx, "get recipes: mongo db cursor error", err)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-			ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-			return
-		}
+	list := models.Recipes{
+		Count:      len(recipes),
+		Items:      recipes,
+		Limit:      page.Limit,
+		Offset:     page.Offset,
+		TotalCount: count,
 	}
 
-	list.Count = len(list.Items)
-	list.Limit = page.Limit
-	list.Offset = page.Offset
-	list.TotalCount = count
-
 	b, err := json.Marshal(list)
 	if err != nil {
 		log.Error(ctx, "get recipes: error returned from json marshal", err)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to marshal recipes: %w", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if _, err = w.Write(b); err != nil {
 		log.Error(ctx, "get recipes: failed to write response data", err)
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil
 	}
 
 	log.Info(ctx, "get recipes: request successful")
+	return nil
 }
 
-func (api *FoodRecipeAPI) getRecipe(w http.ResponseWriter, req *http.Request) {
+func (api *FoodRecipeAPI) getRecipe(w http.ResponseWriter, req *http.Request) error {
 	defer DrainBody(req)
 	ctx := req.Context()
 
@@ -150,102 +95,135 @@ func (api *FoodRecipeAPI) getRecipe(w http.ResponseWriter, req *http.Request) {
 	id := vars["id"]
 	logData := log.Data{"id": id}
 
-	var recipe models.Recipe
-	var errorObjects []*models.ErrorObject
-
-	collection := api.MongoClient.Database("food-recipes").Collection("recipes")
-	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&recipe); err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Warn(ctx, "get recipes: failed to find recipe", log.FormatErrors([]error{err}), logData)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrRecipeNotFound.Error()})
-			ErrorResponse(ctx, w, http.StatusNotFound, &models.ErrorResponse{Errors: errorObjects})
-			return
-		}
+	access, err := api.getAccess(ctx)
+	if err != nil {
+		return accessError(ctx, err, logData)
+	}
 
+	recipe, err := api.DataStore.GetRecipe(ctx, id)
+	if errors.Is(err, errs.ErrRecipeNotFound) {
+		// recipe may have been re-slugged, redirect callers using the previous identifier
+		return api.redirectAlias(ctx, w, req, access, logData)
+	}
+	if err != nil {
 		log.Error(ctx, "get recipes: failed to find recipe, bad connection?", err)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to find recipe: %w", err)
+	}
+
+	// respond as if recipes the caller cannot see do not exist
+	if !access.canView(recipe) {
+		log.Warn(ctx, "get recipe: caller cannot view recipe", logData)
+		return errs.ErrRecipeNotFound
 	}
 
 	b, err := json.Marshal(recipe)
 	if err != nil {
 		log.Error(ctx, "error returned from json marshal", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to marshal recipe: %w", err)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	if _, err = w.Write(b); err != nil {
 		log.Error(ctx, "get recipe: failed to write response data", err, logData)
 		w.WriteHeader(http.StatusInternalServerError)
-		return
+		return nil
 	}
 
 	log.Info(ctx, "get recipe: request successful", logData)
+	return nil
 }
 
-func (api *FoodRecipeAPI) createRecipe(w http.ResponseWriter, req *http.Request) {
+// redirectAlias redirects callers using the previous identifier of a re-slugged recipe to the recipe, as long as the
+// caller can see the recipe, so the new identifier of a recipe is not revealed to callers who cannot see it. Otherwise
+// the recipe is reported as not found.
+func (api *FoodRecipeAPI) redirectAlias(ctx context.Context, w http.ResponseWriter, req *http.Request, a *access, logData log.Data) error {
+	alias, err := api.DataStore.GetRecipeAlias(ctx, mux.Vars(req)["id"])
+	if err != nil {
+		log.Warn(ctx, "get recipes: failed to find recipe", log.FormatErrors([]error{errs.ErrRecipeNotFound}), logData)
+		return errs.ErrRecipeNotFound
+	}
+
+	logData["recipe_id"] = alias.RecipeID
+
+	recipe, err := api.DataStore.GetRecipe(ctx, alias.RecipeID)
+	if errors.Is(err, errs.ErrRecipeNotFound) {
+		log.Warn(ctx, "get recipe: alias refers to a recipe which does not exist", logData)
+		return errs.ErrRecipeNotFound
+	}
+	if err != nil {
+		log.Error(ctx, "get recipes: failed to find recipe, bad connection?", err, logData)
+		return fmt.Errorf("failed to find recipe: %w", err)
+	}
+
+	if !a.canView(recipe) {
+		log.Warn(ctx, "get recipe: caller cannot view recipe the alias refers to", logData)
+		return errs.ErrRecipeNotFound
+	}
+
+	log.Info(ctx, "get recipe: redirecting to recipe using alias", logData)
+	http.Redirect(w, req, "/recipes/"+alias.RecipeID, http.StatusMovedPermanently)
+	return nil
+}
+
+func (api *FoodRecipeAPI) createRecipe(w http.ResponseWriter, req *http.Request) error {
 	defer DrainBody(req)
 	ctx := req.Context()
 
-	var errorObjects []*models.ErrorObject
-
 	recipe, err := unmarshalRecipe(ctx, req.Body)
 	if err != nil {
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error()})
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return err
 	}
 
-	recipe.ID = strings.ToLower(strings.ReplaceAll(recipe.Title, " ", "-"))
-	logData := log.Data{"id": recipe.ID}
+	recipe.ID = slug.Make(recipe.Title)
+	recipe.Owner = auth.IdentityFromContext(ctx).Name
+	logData := log.Data{"id": recipe.ID, "owner": recipe.Owner}
 
 	// validate recipe fields
-	if errorObjects = recipe.Validate(); len(errorObjects) != 0 {
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+	err = recipe.Validate()
+	if recipe.Title != "" && recipe.ID == "" {
+		err = errs.Append(err, errs.ErrInvalidTitle.WithField("/title").WithValues(map[string]string{"title": recipe.Title}))
 	}
 
-	recipe.Title = casing.String(recipe.Title)
+	if err != nil {
+		return err
+	}
 
-	collection := api.MongoClient.Database("food-recipes").Collection("recipes")
+	access, err := api.getAccess(ctx)
+	if err != nil {
+		return accessError(ctx, err, logData)
+	}
 
-	if _, err = collection.InsertOne(ctx, recipe); err != nil {
-		if strings.Contains(err.Error(), "E11000 duplicate key error collection") {
-			log.Error(ctx, "add recipe: failed to insert recipe, recipe already exists", err, logData)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrRecipeAlreadyExists.Error()})
-			ErrorResponse(ctx, w, http.StatusConflict, &models.ErrorResponse{Errors: errorObjects})
-			return
+	if sharingErr := access.checkSharing(nil, recipe); sharingErr != nil {
+		return forbidden(ctx, recipe, sharingErr, logData)
+	}
+
+	recipe.Title = casing.String(recipe.Title)
+	if recipe.Visibility == "" {
+		recipe.Visibility = models.VisibilityPublic
+	}
+
+	if err = api.DataStore.CreateRecipe(ctx, recipe); err != nil {
+		if errors.Is(err, errs.ErrRecipeAlreadyExists) {
+			log.Warn(ctx, "add recipe: failed to insert recipe, recipe already exists", logData)
+			return err
 		}
 
 		log.Error(ctx, "add recipe: failed to insert recipe", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to insert recipe: %w", err)
 	}
 
-	b, err := json.Marshal(recipe)
-	if err != nil {
-		log.Error(ctx, "add recipe: failed to marshal instance to json", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
-	}
+	api.recordAudit(ctx, models.AuditEntry{RecipeID: recipe.ID, Action: models.AuditActionCreate}, nil, recipe, logData)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	if _, err = w.Write(b); err != nil {
-		log.Warn(ctx, "add recipe: failed to write response data", log.FormatErrors([]error{err}), logData)
-		return
-	}
+	w.Header().Set("Location", "/recipes/"+recipe.ID)
+	writeRecipe(w, req, http.StatusCreated, recipe, logData)
 
 	log.Info(ctx, "add recipe: request successful", logData)
+	return nil
 }
 
-// partialRecipeUpdate - how the operations in patch should work: https://jsonpatch.com/#operations
-func (api *FoodRecipeAPI) partialRecipeUpdate(w http.ResponseWriter, req *http.Request) {
+// partialRecipeUpdate applies either a JSON Patch (RFC 6902) or a JSON Merge Patch (RFC 7396) to a recipe depending on the
+// Content-Type of the request - how the operations in a JSON Patch should work: https://jsonpatch.com/#operations
+func (api *FoodRecipeAPI) partialRecipeUpdate(w http.ResponseWriter, req *http.Request) error {
 	defer DrainBody(req)
 	ctx := req.Context()
 
@@ -253,108 +231,241 @@ func (api *FoodRecipeAPI) partialRecipeUpdate(w http.ResponseWriter, req *http.R
 	id := vars["id"]
 	logData := log.Data{"id": id}
 
-	var errorObjects []*models.ErrorObject
+	var (
+		prepared *preparedPatch
+		err      error
+	)
 
-	patchJSON, recipePatches, err := patch.Get(ctx, req.Body)
-	if err != nil {
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error()})
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
-	}
+	allowlist := recipeAllowlist(id)
 
-	// Validate patch request
-	for i, recipePatch := range *recipePatches {
-		if err = recipePatch.Validate(nil); err != nil {
-			if _, ok := err.(*validator.InvalidValidationError); ok {
-				errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-				ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-				return
-			}
+	contentType := req.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
 
-			for _, err := range err.(validator.ValidationErrors) {
-				errorObjects = append(errorObjects, models.HandleValidationErrors(strconv.Itoa(i), err.ActualTag(), err.StructField(), err.Value().(string), err.Param()))
-			}
-		}
-	}
-	if len(errorObjects) > 0 {
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+	switch mediaType {
+	case patch.JSONPatchMediaType:
+		prepared, err = getJSONPatch(ctx, req.Body, allowlist)
+	case patch.MergePatchMediaType:
+		prepared, err = getMergePatch(ctx, req.Body, allowlist)
+	default:
+		logData["content_type"] = contentType
+		log.Warn(ctx, "patch recipe: unsupported content type", logData)
+
+		w.Header().Set("Accept-Patch", patch.JSONPatchMediaType+", "+patch.MergePatchMediaType)
+		return errs.ErrUnsupportedMediaType.WithValues(map[string]string{"content_type": contentType})
 	}
 
-	// apply patch against recipe resource
-	p, err := jsonpatch.DecodePatch(patchJSON)
 	if err != nil {
-		log.Error(ctx, "patch recipe: unable to decode patch", err)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error()})
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return err
 	}
 
 	// find current recipe doc
-	var recipe models.Recipe
+	recipe, err := api.getStoredRecipe(ctx, id, "patch recipe", logData)
+	if err != nil {
+		return err
+	}
 
-	collection := api.MongoClient.Database("food-recipes").Collection("recipes")
-	if err = collection.FindOne(ctx, bson.M{"_id": id}).Decode(&recipe); err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Warn(ctx, "patch recipe: failed to find recipe", log.FormatErrors([]error{err}), logData)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrRecipeNotFound.Error()})
-			ErrorResponse(ctx, w, http.StatusNotFound, &models.ErrorResponse{Errors: errorObjects})
-			return
-		}
+	access, err := api.getAccess(ctx)
+	if err != nil {
+		return accessError(ctx, err, logData)
+	}
 
-		log.Error(ctx, "patch recipe: failed to find recipe, bad connection?", err)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+	if !access.canView(recipe) {
+		return hidden(ctx, logData)
+	}
+
+	if !access.canModify(recipe) {
+		return forbidden(ctx, recipe, errs.ErrNotRecipeOwner, logData)
 	}
+	previous := *recipe
 
 	b, err := json.Marshal(recipe)
 	if err != nil {
 		log.Error(ctx, "patch recipe: error returned from json marshal", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to marshal recipe: %w", err)
 	}
 
 	// apply patch to existing recipe
-	modified, err := p.Apply(b)
+	modified, err := prepared.apply(b)
 	if err != nil {
 		log.Error(ctx, "patch recipe: unable to apply patch to recipe", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error()})
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return errs.ErrUnableToApplyPatch.WithMessage(err.Error())
 	}
 
-	err = json.Unmarshal(modified, &recipe)
+	// unmarshal into an empty recipe so members removed by the patch are not carried over
+	recipe = &models.Recipe{}
+	err = json.Unmarshal(modified, recipe)
 	if err != nil {
 		log.Error(ctx, "patch recipe: unmarshal modified recipe into recipe struct", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error()})
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return errs.ErrUnableToApplyPatch.WithMessage(err.Error())
+	}
+
+	// validate patched recipe fields
+	if err = recipe.Validate(); err != nil {
+		log.Warn(ctx, "patch recipe: patched recipe is invalid", logData)
+		return models.AttributeToPatches(err, prepared.patchedPaths)
+	}
+
+	if sharingErr := access.checkSharing(&previous, recipe); sharingErr != nil {
+		return forbidden(ctx, recipe, sharingErr, logData)
 	}
 
-	// store new recipe
-	if _, err = collection.ReplaceOne(ctx, bson.M{"_id": id}, recipe); err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Error(ctx, "update recipe: failed to update recipe, recipe deos not exists", err, logData)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrRecipeNotFound.Error()})
-			ErrorResponse(ctx, w, http.StatusNotFound, &models.ErrorResponse{Errors: errorObjects})
-			return
+	// store new recipe, which fails if the recipe was removed or its owner or household changed after it was retrieved
+	// to apply the patch
+	if err = api.DataStore.ReplaceRecipe(ctx, &previous, recipe); err != nil {
+		if errors.Is(err, errs.ErrRecipeChanged) {
+			log.Warn(ctx, "update recipe: failed to update recipe, recipe was changed", logData)
+			return err
 		}
 
 		log.Error(ctx, "update recipe: failed to insert recipe", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to replace recipe: %w", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	auditEntry := models.AuditEntry{RecipeID: id, Action: models.AuditActionPatch, Patch: string(prepared.raw), PatchMediaType: mediaType}
+	api.recordAudit(ctx, auditEntry, &previous, recipe, logData)
+
+	writeRecipe(w, req, http.StatusOK, recipe, logData)
 
 	log.Info(ctx, "update recipe: request successful", logData)
+	return nil
+}
+
+// preparedPatch is a validated patch request which is ready to be applied to a recipe
+type preparedPatch struct {
+	apply        func(doc []byte) ([]byte, error)
+	patchedPaths []models.PatchedPath
+	raw          []byte
+}
+
+// recipeAllowlist returns the members of a recipe which can be patched. The id and owner cannot be changed and, as the
+// id is generated from the title, the title can only be changed to a title which produces the same id, e.g. to correct
+// the accents or punctuation in it.
+func recipeAllowlist(id string) patch.Allowlist {
+	return patch.Allowlist{
+		"cook_time":         nil,
+		"difficulty":        nil,
+		"extra_ingredients": nil,
+		"favourite":         nil,
+		"ingredients":       nil,
+		"location":          nil,
+		"notes":             nil,
+		"portion_size":      nil,
+		"tags":              nil,
+		"visibility":        nil,
+		"household":         nil,
+		"title": func(p *patch.Patch) error {
+			switch p.Op {
+			case patch.OpTest.String():
+				return nil
+			case patch.OpAdd.String(), patch.OpReplace.String():
+				if title, ok := p.Value.(string); ok && p.Path == "/title" && slug.Make(title) == id {
+					return nil
+				}
+			}
+
+			return errs.ErrUnableToRenameTitle
+		},
+	}
+}
+
+// getJSONPatch reads and validates a list of JSON Patch operations from the request body, returning the patch to apply
+// to a recipe or the errors to respond with if the patch is invalid
+func getJSONPatch(ctx context.Context, body io.ReadCloser, allowlist patch.Allowlist) (*preparedPatch, error) {
+	patchJSON, recipePatches, err := patch.Get(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate patch request
+	var patchErr error
+	for i, recipePatch := range *recipePatches {
+		if err = recipePatch.Validate(nil); err != nil {
+			if _, ok := err.(*validator.InvalidValidationError); ok {
+				return nil, fmt.Errorf("failed to validate patch: %w", err)
+			}
+
+			for _, validationErr := range err.(validator.ValidationErrors) {
+				patchErr = errs.Append(patchErr, models.HandleValidationErrors(strconv.Itoa(i), validationErr.ActualTag(), validationErr.StructField(),
+					validationErr.Value().(string), validationErr.Param()))
+			}
+			continue
+		}
+
+		if err = allowlist.Check(&(*recipePatches)[i]); err != nil {
+			patchErr = errs.Append(patchErr, patchPathError(strconv.Itoa(i), err))
+		}
+	}
+	if patchErr != nil {
+		return nil, patchErr
+	}
+
+	p, err := jsonpatch.DecodePatch(patchJSON)
+	if err != nil {
+		log.Error(ctx, "patch recipe: unable to decode patch", err)
+		return nil, errs.ErrUnableToParsePatch.WithMessage(err.Error())
+	}
+
+	patchedPaths := make([]models.PatchedPath, 0, len(*recipePatches))
+	for i, recipePatch := range *recipePatches {
+		patchedPaths = append(patchedPaths, models.PatchedPath{Index: strconv.Itoa(i), Path: recipePatch.Path})
+	}
+
+	return &preparedPatch{apply: p.Apply, patchedPaths: patchedPaths, raw: patchJSON}, nil
+}
+
+// getMergePatch reads a JSON Merge Patch from the request body, returning the patch to apply to a recipe or the errors
+// to respond with if the patch is invalid. Errors refer to the top level member of the merge patch in place of the
+// index used for JSON Patch operations.
+func getMergePatch(ctx context.Context, body io.ReadCloser, allowlist patch.Allowlist) (*preparedPatch, error) {
+	mergePatch, err := patch.GetMerge(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+
+	ops, err := patch.MergeOps(mergePatch)
+	if err != nil {
+		return nil, errs.ErrUnableToParseMergePatch.WithMessage(err.Error())
+	}
+
+	var patchErr error
+	patchedPaths := make([]models.PatchedPath, 0, len(ops))
+	for i := range ops {
+		member := patch.Member(ops[i].Path)
+		if err = allowlist.Check(&ops[i]); err != nil {
+			patchErr = errs.Append(patchErr, patchPathError(member, err))
+		}
+
+		patchedPaths = append(patchedPaths, models.PatchedPath{Index: member, Path: ops[i].Path})
+	}
+	if patchErr != nil {
+		return nil, patchErr
+	}
+
+	apply := func(doc []byte) ([]byte, error) {
+		return jsonpatch.MergePatch(doc, mergePatch)
+	}
+
+	return &preparedPatch{apply: apply, patchedPaths: patchedPaths, raw: mergePatch}, nil
 }
 
-func (api *FoodRecipeAPI) updateRecipe(w http.ResponseWriter, req *http.Request) {
+// patchPathError returns the error for a patch with a path rejected by the allowlist
+func patchPathError(patchIndex string, err error) error {
+	var (
+		pathErr *patch.PathError
+		apiErr  *errs.Error
+	)
+	if !errors.As(err, &pathErr) || !errors.As(err, &apiErr) {
+		return err
+	}
+
+	return models.HandlePatchPathError(patchIndex, pathErr.Member, pathErr.Path, apiErr)
+}
+
+// updateRecipe creates or replaces the recipe with the given id, the title of the recipe is derived from the id. Recipes
+// can only be created where the id is a valid slug, existing recipes can be replaced regardless by callers permitted to
+// modify them. The owner of a recipe is kept when it is replaced, as is who it is shared with unless a new visibility
+// is given.
+func (api *FoodRecipeAPI) updateRecipe(w http.ResponseWriter, req *http.Request) error {
 	defer DrainBody(req)
 	ctx := req.Context()
 
@@ -362,46 +473,98 @@ func (api *FoodRecipeAPI) updateRecipe(w http.ResponseWriter, req *http.Request)
 	id := vars["id"]
 	logData := log.Data{"id": id}
 
-	var errorObjects []*models.ErrorObject
-
 	recipe, err := unmarshalUpdateRecipe(ctx, req.Body)
 	if err != nil {
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: err.Error()})
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return err
 	}
 
 	// validate recipe fields
-	if errorObjects = recipe.Validate(); len(errorObjects) != 0 {
-		ErrorResponse(ctx, w, http.StatusBadRequest, &models.ErrorResponse{Errors: errorObjects})
-		return
+	if err = recipe.Validate(); err != nil {
+		return err
 	}
 
 	recipe.Title = casing.String(strings.ReplaceAll(id, "-", " "))
+	stored := recipe.ToRecipe(id)
+	stored.Owner = auth.IdentityFromContext(ctx).Name
+
+	isCreatable := slug.Make(id) == id
+
+	access, err := api.getAccess(ctx)
+	if err != nil {
+		return accessError(ctx, err, logData)
+	}
+
+	existing, err := api.DataStore.GetRecipe(ctx, id)
+	switch {
+	case errors.Is(err, errs.ErrRecipeNotFound):
+		existing = nil
+	case err != nil:
+		log.Error(ctx, "update recipe: failed to find recipe, bad connection?", err, logData)
+		return fmt.Errorf("failed to find recipe: %w", err)
+	case !access.canView(existing):
+		return hidden(ctx, logData)
+	case !access.canModify(existing):
+		return forbidden(ctx, existing, errs.ErrNotRecipeOwner, logData)
+	default:
+		stored.Owner = existing.Owner
+		if stored.Visibility == "" {
+			stored.Visibility = existing.Visibility
+			if stored.Household == "" {
+				stored.Household = existing.Household
+			}
+		}
+	}
+
+	if stored.Visibility == "" {
+		stored.Visibility = models.VisibilityPublic
+	}
 
-	collection := api.MongoClient.Database("food-recipes").Collection("recipes")
+	if sharingErr := access.checkSharing(existing, stored); sharingErr != nil {
+		return forbidden(ctx, stored, sharingErr, logData)
+	}
 
-	if _, err = collection.ReplaceOne(ctx, bson.M{"_id": id}, recipe); err != nil {
-		if err == mongo.ErrNoDocuments {
-			log.Error(ctx, "update recipe: failed to update recipe, recipe deos not exists", err, logData)
-			errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrRecipeNotFound.Error()})
-			ErrorResponse(ctx, w, http.StatusNotFound, &models.ErrorResponse{Errors: errorObjects})
-			return
+	status := http.StatusOK
+	action := models.AuditActionReplace
+
+	if existing == nil {
+		if !isCreatable {
+			log.Warn(ctx, "update recipe: unable to create recipe, id is not a valid slug", logData)
+			return errs.ErrInvalidRecipeID.WithValues(map[string]string{"id": id})
+		}
+
+		logData["created"] = true
+		status = http.StatusCreated
+		action = models.AuditActionCreate
+		if err = api.DataStore.CreateRecipe(ctx, stored); errors.Is(err, errs.ErrRecipeAlreadyExists) {
+			err = errs.ErrRecipeChanged
+		}
+	} else {
+		err = api.DataStore.ReplaceRecipe(ctx, existing, stored)
+	}
+
+	if err != nil {
+		if errors.Is(err, errs.ErrRecipeChanged) {
+			log.Warn(ctx, "update recipe: recipe was changed while being replaced", logData)
+			return err
 		}
 
 		log.Error(ctx, "update recipe: failed to insert recipe", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return fmt.Errorf("failed to replace recipe: %w", err)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	if status == http.StatusCreated {
+		w.Header().Set("Location", "/recipes/"+id)
+	}
+
+	api.recordAudit(ctx, models.AuditEntry{RecipeID: id, Action: action}, existing, stored, logData)
+
+	writeRecipe(w, req, status, stored, logData)
 
 	log.Info(ctx, "update recipe: request successful", logData)
+	return nil
 }
 
-func (api *FoodRecipeAPI) removeRecipe(w http.ResponseWriter, req *http.Request) {
+func (api *FoodRecipeAPI) removeRecipe(w http.ResponseWriter, req *http.Request) error {
 	defer DrainBody(req)
 	ctx := req.Context()
 
@@ -409,26 +572,78 @@ func (api *FoodRecipeAPI) removeRecipe(w http.ResponseWriter, req *http.Request)
 	id := vars["id"]
 	logData := log.Data{"id": id}
 
-	var errorObjects []*models.ErrorObject
-
-	collection := api.MongoClient.Database("food-recipes").Collection("recipes")
-
-	res, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	access, err := api.getAccess(ctx)
 	if err != nil {
-		log.Error(ctx, "delete recipe: failed to remove recipe", err, logData)
-		errorObjects = append(errorObjects, &models.ErrorObject{Error: errs.ErrInternalServer.Error()})
-		ErrorResponse(ctx, w, http.StatusInternalServerError, &models.ErrorResponse{Errors: errorObjects})
-		return
+		return accessError(ctx, err, logData)
 	}
 
-	if res.DeletedCount == 0 {
+	existing, err := api.DataStore.GetRecipe(ctx, id)
+	switch {
+	case errors.Is(err, errs.ErrRecipeNotFound):
+		existing = nil
+	case err != nil:
+		log.Error(ctx, "delete recipe: failed to find recipe, bad connection?", err, logData)
+		return fmt.Errorf("failed to find recipe: %w", err)
+	case !access.canView(existing):
+		// respond as if the recipe does not exist, so the response does not reveal the recipe to the caller
+		existing = nil
+	case !access.canDelete(existing):
+		return forbidden(ctx, existing, errs.ErrNotRecipeOwner, logData)
+	}
+
+	if existing == nil {
 		log.Warn(ctx, "delete recipe: failed to remove recipe as it does not exist", logData)
+	} else {
+		// fails if the recipe was removed or its owner or household changed after it was retrieved
+		if err = api.DataStore.DeleteRecipe(ctx, existing); err != nil {
+			if errors.Is(err, errs.ErrRecipeChanged) {
+				log.Warn(ctx, "delete recipe: recipe was changed while being removed", logData)
+				return err
+			}
+
+			log.Error(ctx, "delete recipe: failed to remove recipe", err, logData)
+			return fmt.Errorf("failed to remove recipe: %w", err)
+		}
+
+		api.recordAudit(ctx, models.AuditEntry{RecipeID: id, Action: models.AuditActionDelete}, existing, nil, logData)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusNoContent)
 
 	log.Info(ctx, "delete recipe: request successful", logData)
+	return nil
+}
+
+// getStoredRecipe returns the stored recipe with the given id, or the error to respond with if it cannot be found
+func (api *FoodRecipeAPI) getStoredRecipe(ctx context.Context, id, action string, logData log.Data) (*models.Recipe, error) {
+	recipe, err := api.DataStore.GetRecipe(ctx, id)
+	if errors.Is(err, errs.ErrRecipeNotFound) {
+		log.Warn(ctx, action+": failed to find recipe", log.FormatErrors([]error{err}), logData)
+		return nil, err
+	}
+	if err != nil {
+		log.Error(ctx, action+": failed to find recipe, bad connection?", err, logData)
+		return nil, fmt.Errorf("failed to find recipe: %w", err)
+	}
+
+	return recipe, nil
+}
+
+// writeRecipe responds with the stored recipe, unless the caller prefers a minimal response in which case the body is
+// omitted and a 200 status is replaced with 204 No Content
+func writeRecipe(w http.ResponseWriter, req *http.Request, status int, recipe *models.Recipe, logData log.Data) {
+	if prefersMinimal(req) {
+		if status == http.StatusOK {
+			status = http.StatusNoContent
+		}
+
+		w.Header().Set("Preference-Applied", "return=minimal")
+		w.WriteHeader(status)
+		return
+	}
+
+	writeJSON(w, req, status, recipe, logData)
 }
 
 func unmarshalRecipe(ctx context.Context, reader io.Reader) (*models.Recipe, error) {
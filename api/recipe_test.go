@@ -0,0 +1,366 @@
+package api_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGetRecipeAlias(t *testing.T) {
+	tables := []struct {
+		givenTitle       string
+		path             string
+		token            string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{"Given an alias of a public recipe", "/recipes/pancake", "", http.StatusMovedPermanently, "/recipes/pancakes"},
+		{"Given an alias of a private recipe requested by its owner", "/recipes/secret", adminToken, http.StatusMovedPermanently,
+			"/recipes/secret-sauce"},
+		{"Given an alias of a private recipe requested by another caller", "/recipes/secret", writerToken, http.StatusNotFound, ""},
+		{"Given an alias of a private recipe requested anonymously", "/recipes/secret", "", http.StatusNotFound, ""},
+		{"Given an alias of a recipe which no longer exists", "/recipes/sunday-roast", adminToken, http.StatusNotFound, ""},
+		{"Given an id which is neither a recipe nor an alias", "/recipes/waffles", "", http.StatusNotFound, ""},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			w := serve(newRouter(newDataStore()), http.MethodGet, table.path, table.token, "")
+
+			Convey("Then the caller is only redirected to a recipe they can see", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+				So(w.Header().Get("Location"), ShouldEqual, table.expectedLocation)
+
+				if table.expectedStatus == http.StatusNotFound {
+					So(errorCodes(w), ShouldResemble, []string{"recipe_not_found"})
+				}
+			})
+		})
+	}
+}
+
+func TestRecipeWithoutOwner(t *testing.T) {
+	jsonPatch := http.Header{"Content-Type": {patch.JSONPatchMediaType}}
+	replace := http.Header{"Content-Type": {"application/json"}}
+
+	tables := []struct {
+		givenTitle     string
+		method         string
+		token          string
+		body           string
+		header         http.Header
+		expectedStatus int
+		expectedCodes  []string
+	}{
+		{"Given an anonymous request for a recipe without an owner", http.MethodGet, "", "", nil, http.StatusOK, nil},
+		{"Given a writer replacing a recipe without an owner", http.MethodPut, writerToken, recipeBody,
+			replace, http.StatusForbidden, []string{"not_recipe_owner"}},
+		{"Given a writer patching a recipe without an owner", http.MethodPatch, writerToken,
+			`[{"op": "replace", "path": "/notes", "value": "mine now"}]`, jsonPatch, http.StatusForbidden, []string{"not_recipe_owner"}},
+		{"Given a writer deleting a recipe without an owner", http.MethodDelete, writerToken, "", nil, http.StatusForbidden,
+			[]string{"not_recipe_owner"}},
+		{"Given an admin patching a recipe without an owner", http.MethodPatch, adminToken,
+			`[{"op": "replace", "path": "/visibility", "value": "private"}]`, jsonPatch, http.StatusOK, nil},
+		{"Given an admin deleting a recipe without an owner", http.MethodDelete, adminToken, "", nil, http.StatusNoContent, nil},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			store.recipes["imported"] = newRecipe("imported", "", "")
+
+			w := serveWithHeader(newRouter(store), table.method, "/recipes/imported", table.token, table.body, table.header)
+
+			Convey("Then only an admin can change the recipe", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+				if table.expectedCodes != nil {
+					So(errorCodes(w), ShouldResemble, table.expectedCodes)
+					So(store.recipes["imported"], ShouldResemble, newRecipe("imported", "", ""))
+				}
+			})
+		})
+	}
+}
+
+func TestWriteRecipeResponse(t *testing.T) {
+	tables := []struct {
+		givenTitle       string
+		method           string
+		path             string
+		body             string
+		contentType      string
+		id               string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{"Given a request to create a recipe", http.MethodPost, "/recipes", `{"title": "Waffles", ` + recipeBody[1:],
+			"application/json", "waffles", http.StatusCreated, "/recipes/waffles"},
+		{"Given a request to replace a recipe which does not exist", http.MethodPut, "/recipes/waffles", recipeBody,
+			"application/json", "waffles", http.StatusCreated, "/recipes/waffles"},
+		{"Given a request to replace a recipe", http.MethodPut, "/recipes/pancakes", recipeBody, "application/json", "pancakes",
+			http.StatusOK, ""},
+		{"Given a request to patch a recipe", http.MethodPatch, "/recipes/pancakes", `{"notes": "add lemon"}`,
+			patch.MergePatchMediaType, "pancakes", http.StatusOK, ""},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			router := newRouter(store)
+
+			Convey("When the full representation is requested by default", func() {
+				w := serveWithHeader(router, table.method, table.path, writerToken, table.body,
+					http.Header{"Content-Type": {table.contentType}})
+
+				Convey("Then the stored recipe is returned with the location of any new recipe", func() {
+					So(w.Code, ShouldEqual, table.expectedStatus)
+					So(w.Header().Get("Location"), ShouldEqual, table.expectedLocation)
+					So(w.Header().Get("Preference-Applied"), ShouldBeEmpty)
+
+					var returned models.Recipe
+					So(json.Unmarshal(w.Body.Bytes(), &returned), ShouldBeNil)
+					So(&returned, ShouldResemble, store.recipes[table.id])
+				})
+			})
+
+			Convey("When a minimal response is preferred", func() {
+				w := serveWithHeader(router, table.method, table.path, writerToken, table.body,
+					http.Header{"Content-Type": {table.contentType}, "Prefer": {"return=minimal"}})
+
+				Convey("Then the recipe is stored and the response has no body", func() {
+					expectedStatus := table.expectedStatus
+					if expectedStatus == http.StatusOK {
+						expectedStatus = http.StatusNoContent
+					}
+
+					So(w.Code, ShouldEqual, expectedStatus)
+					So(w.Header().Get("Location"), ShouldEqual, table.expectedLocation)
+					So(w.Header().Get("Preference-Applied"), ShouldEqual, "return=minimal")
+					So(w.Body.Len(), ShouldEqual, 0)
+					So(store.recipes[table.id], ShouldNotBeNil)
+				})
+			})
+		})
+	}
+}
+
+func TestUpdateRecipe(t *testing.T) {
+	tables := []struct {
+		givenTitle     string
+		path           string
+		expectedStatus int
+		expectedOwner  string
+	}{
+		{"Given a request to replace a recipe which does not exist", "/recipes/waffles", http.StatusCreated, "admin"},
+		{"Given a request to replace a recipe owned by another caller", "/recipes/pancakes", http.StatusOK, "writer"},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			w := serve(newRouter(store), http.MethodPut, table.path, adminToken, recipeBody)
+
+			Convey("Then the recipe is created or replaced, keeping the owner of an existing recipe", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+				So(store.recipes[path.Base(table.path)].Owner, ShouldEqual, table.expectedOwner)
+			})
+		})
+	}
+
+	Convey("Given a request to replace a recipe with an id which is not a valid slug", t, func() {
+		store := newDataStore()
+		w := serve(newRouter(store), http.MethodPut, "/recipes/Waffles", writerToken, recipeBody)
+
+		Convey("Then the recipe is not created", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(errorCodes(w), ShouldResemble, []string{"invalid_recipe_id"})
+			So(store.recipes, ShouldNotContainKey, "Waffles")
+		})
+	})
+
+	Convey("Given a recipe shared with a household", t, func() {
+		store := newDataStore()
+		stew := newRecipe("family-stew", "writer", models.VisibilityHousehold)
+		stew.Household = "smiths"
+		store.recipes["family-stew"] = stew
+
+		Convey("When an admin replaces it without saying who it is shared with", func() {
+			w := serve(newRouter(store), http.MethodPut, "/recipes/family-stew", adminToken, recipeBody)
+
+			Convey("Then the recipe keeps its owner, visibility and household", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(store.recipes["family-stew"].Owner, ShouldEqual, "writer")
+				So(store.recipes["family-stew"].Visibility, ShouldEqual, models.VisibilityHousehold)
+				So(store.recipes["family-stew"].Household, ShouldEqual, "smiths")
+			})
+		})
+
+		Convey("When its owner replaces it with a new visibility", func() {
+			w := serve(newRouter(store), http.MethodPut, "/recipes/family-stew", writerToken,
+				`{"visibility": "private", `+recipeBody[1:])
+
+			Convey("Then the recipe is no longer shared with the household", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(store.recipes["family-stew"].Visibility, ShouldEqual, models.VisibilityPrivate)
+				So(store.recipes["family-stew"].Household, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a recipe whose owner changes while it is being replaced", t, func() {
+		store := newDataStore()
+		store.afterGetRecipe = func() { store.recipes["pancakes"] = newRecipe("pancakes", "admin", models.VisibilityPrivate) }
+
+		w := serve(newRouter(store), http.MethodPut, "/recipes/pancakes", writerToken, recipeBody)
+
+		Convey("Then the request conflicts and the recipe is not replaced", func() {
+			So(w.Code, ShouldEqual, http.StatusConflict)
+			So(errorCodes(w), ShouldResemble, []string{"recipe_changed"})
+			So(store.recipes["pancakes"].Owner, ShouldEqual, "admin")
+		})
+	})
+
+	Convey("Given a recipe which is created by another caller while it is being created", t, func() {
+		store := newDataStore()
+		store.afterGetRecipe = func() { store.recipes["waffles"] = newRecipe("waffles", "admin", models.VisibilityPrivate) }
+
+		w := serve(newRouter(store), http.MethodPut, "/recipes/waffles", writerToken, recipeBody)
+
+		Convey("Then the request conflicts and the other caller's recipe is kept", func() {
+			So(w.Code, ShouldEqual, http.StatusConflict)
+			So(errorCodes(w), ShouldResemble, []string{"recipe_changed"})
+			So(store.recipes["waffles"].Owner, ShouldEqual, "admin")
+		})
+	})
+}
+
+func TestPatchRecipeContentType(t *testing.T) {
+	tables := []struct {
+		givenTitle     string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{"Given a JSON Patch", patch.JSONPatchMediaType, `[{"op": "replace", "path": "/notes", "value": "add lemon"}]`, http.StatusOK},
+		{"Given a JSON Merge Patch", patch.MergePatchMediaType + "; charset=utf-8", `{"notes": "add lemon"}`, http.StatusOK},
+		{"Given a patch with an unsupported content type", "text/plain", `notes: add lemon`, http.StatusUnsupportedMediaType},
+		{"Given a patch without a content type", "", `{"notes": "add lemon"}`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			w := serveWithHeader(newRouter(store), http.MethodPatch, "/recipes/pancakes", writerToken, table.body,
+				http.Header{"Content-Type": {table.contentType}})
+
+			Convey("Then the patch is applied or the supported patch formats are returned", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+
+				if table.expectedStatus == http.StatusOK {
+					So(store.recipes["pancakes"].Notes, ShouldEqual, "add lemon")
+					return
+				}
+
+				So(errorCodes(w), ShouldResemble, []string{"unsupported_media_type"})
+				So(w.Header().Get("Accept-Patch"), ShouldEqual, patch.JSONPatchMediaType+", "+patch.MergePatchMediaType)
+				So(store.recipes["pancakes"].Notes, ShouldBeEmpty)
+			})
+		})
+	}
+}
+
+func TestMergePatchRecipeRestrictedMembers(t *testing.T) {
+	tables := []struct {
+		givenTitle     string
+		body           string
+		expectedErrors []*models.ErrorObject
+	}{
+		{"Given a merge patch changing the id", `{"id": "crepes", "notes": "add lemon"}`, []*models.ErrorObject{
+			{Code: "path_not_patchable", Error: errs.ErrPathNotPatchable.Error(), Field: "/id", ErrorValues: map[string]string{"[id].path": "/id"}},
+		}},
+		{"Given a merge patch removing the owner", `{"owner": null}`, []*models.ErrorObject{
+			{Code: "path_not_patchable", Error: errs.ErrPathNotPatchable.Error(), Field: "/owner",
+				ErrorValues: map[string]string{"[owner].path": "/owner"}},
+		}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			w := serveWithHeader(newRouter(store), http.MethodPatch, "/recipes/pancakes", writerToken, table.body,
+				http.Header{"Content-Type": {patch.MergePatchMediaType}})
+
+			Convey("Then the patch is rejected against the member and the recipe is unchanged", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+
+				var response models.ErrorResponse
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Errors, ShouldResemble, table.expectedErrors)
+				So(store.recipes["pancakes"], ShouldResemble, newRecipe("pancakes", "writer", models.VisibilityPublic))
+			})
+		})
+	}
+}
+
+func TestJSONPatchRecipeValidation(t *testing.T) {
+	tables := []struct {
+		givenTitle     string
+		body           string
+		expectedErrors []*models.ErrorObject
+	}{
+		{"Given a patch replacing the id", `[{"op": "replace", "path": "/id", "value": "crepes"}]`, []*models.ErrorObject{
+			{Code: "path_not_patchable", Error: errs.ErrPathNotPatchable.Error(), Field: "/0/path",
+				ErrorValues: map[string]string{"[0].path": "/id"}},
+		}},
+		{"Given a patch moving the owner into another member", `[{"op": "move", "from": "/owner", "path": "/notes"}]`, []*models.ErrorObject{
+			{Code: "path_not_patchable", Error: errs.ErrPathNotPatchable.Error(), Field: "/0/from",
+				ErrorValues: map[string]string{"[0].from": "/owner"}},
+		}},
+		{"Given a patch renaming the title to one with a different id", `[{"op": "replace", "path": "/title", "value": "Crepes"}]`,
+			[]*models.ErrorObject{
+				{Code: "title_not_renameable", Error: errs.ErrUnableToRenameTitle.Error(), Field: "/0/path",
+					ErrorValues: map[string]string{"[0].path": "/title"}},
+			}},
+		{"Given patches which leave the recipe invalid", `[{"op": "replace", "path": "/notes", "value": "add lemon"},
+			{"op": "replace", "path": "/difficulty", "value": "impossible"}, {"op": "remove", "path": "/portion_size"}]`,
+			[]*models.ErrorObject{
+				{Code: "invalid_difficulty", Error: errs.ErrInvalidDifficulty.Error(), Field: "/difficulty",
+					ErrorValues: map[string]string{"[1].difficulty": "impossible"}},
+				{Code: "missing_fields", Error: errs.ErrMissingFields.Error(), ErrorValues: map[string]string{"[2].portion_size": ""}},
+			}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			store := newDataStore()
+			w := serveWithHeader(newRouter(store), http.MethodPatch, "/recipes/pancakes", writerToken, table.body,
+				http.Header{"Content-Type": {patch.JSONPatchMediaType}})
+
+			Convey("Then the patch is rejected with errors referring to the patch responsible and the recipe is unchanged", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+
+				var response models.ErrorResponse
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Errors, ShouldResemble, table.expectedErrors)
+				So(store.recipes["pancakes"], ShouldResemble, newRecipe("pancakes", "writer", models.VisibilityPublic))
+			})
+		})
+	}
+
+	Convey("Given a patch correcting the punctuation of the title", t, func() {
+		store := newDataStore()
+		w := serveWithHeader(newRouter(store), http.MethodPatch, "/recipes/pancakes", writerToken,
+			`[{"op": "replace", "path": "/title", "value": "Pancakes!"}]`, http.Header{"Content-Type": {patch.JSONPatchMediaType}})
+
+		Convey("Then the title is changed as the id is unchanged", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(store.recipes["pancakes"].Title, ShouldEqual, "Pancakes!")
+		})
+	})
+}
@@ -2,58 +2,228 @@ package apierrors
 
 import (
 	"errors"
+	"net/http"
+	"strings"
 )
 
-// New returns an error that formats as the given text.
-func New(err error, status int, values map[string]string) error {
-	return &ErrorObject{
-		Code:    status,
-		Keys:    values,
-		Message: err.Error(),
-	}
-}
+// ProblemTypePrefix is prefixed to the code of an error to give the URI identifying its type in problem details, as
+// described in RFC 7807
+const ProblemTypePrefix = "urn:food-recipes:problem:"
 
-// ErrorObject is a trivial implementation of error.
-type ErrorObject struct {
-	Code    int
-	Keys    map[string]string
+// Error is an error the API responds with. Each error has a stable code which callers can rely on, unlike the message,
+// and the status the API responds with. Errors can be given the field of the request they relate to and values
+// describing what caused them, creating a copy of the error which is still matched by errors.Is.
+type Error struct {
+	Code    string
+	Status  int
 	Message string
+	// Field is a JSON pointer to the member of the request body the error relates to, if any
+	Field string
+	// Values describe the values which caused the error
+	Values map[string]string
+
+	// base is the error this is a copy of
+	base *Error
+}
+
+// codes are the codes of the errors created, so no two errors are given the same code
+var codes = map[string]bool{}
+
+// newError creates an error identified by a code, which must be unique and never change once released
+func newError(code string, status int, message string) *Error {
+	if codes[code] {
+		panic("apierrors: duplicate error code " + code)
+	}
+	codes[code] = true
+
+	return &Error{Code: code, Status: status, Message: message}
 }
 
-func (e *ErrorObject) Error() string {
+func (e *Error) Error() string {
 	return e.Message
 }
 
-// Status represents the status code to return from error
-func (e *ErrorObject) Status() int {
-	return e.Code
+// Type returns the URI identifying the type of the error in problem details
+func (e *Error) Type() string {
+	return ProblemTypePrefix + e.Code
+}
+
+// Title returns a short summary of the type of the error, which unlike the message is the same for every error with
+// the same code
+func (e *Error) Title() string {
+	title := strings.ReplaceAll(e.Code, "_", " ")
+	if title == "" {
+		return title
+	}
+
+	return strings.ToUpper(title[:1]) + title[1:]
+}
+
+// Is reports whether the error is the target error or a copy of it
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+
+	return e.root() == t.root()
+}
+
+func (e *Error) root() *Error {
+	if e.base != nil {
+		return e.base
+	}
+	return e
+}
+
+func (e *Error) copy() *Error {
+	c := *e
+	c.base = e.root()
+	return &c
+}
+
+// WithField returns a copy of the error relating to the member of the request body at the JSON pointer
+func (e *Error) WithField(pointer string) *Error {
+	c := e.copy()
+	c.Field = pointer
+	return c
+}
+
+// WithValues returns a copy of the error with values describing what caused it
+func (e *Error) WithValues(values map[string]string) *Error {
+	c := e.copy()
+	c.Values = values
+	return c
+}
+
+// WithMessage returns a copy of the error with a more specific message, the code is unchanged
+func (e *Error) WithMessage(message string) *Error {
+	c := e.copy()
+	c.Message = message
+	return c
+}
+
+// Errors is a list of errors found together, such as every problem with a request body, which are all reported to the
+// caller
+type Errors []error
+
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+
+	return strings.Join(messages, "; ")
 }
 
-// Values represents a list of key value pairs to return from error
-func (e *ErrorObject) Values() map[string]string {
-	return e.Keys
+// Append adds errors to a list, returning nil if there are no errors in the list so it can be returned as an error
+func Append(list error, errs ...error) error {
+	var all Errors
+	if list != nil {
+		if !errors.As(list, &all) {
+			all = Errors{list}
+		}
+	}
+
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		var nested Errors
+		if errors.As(err, &nested) {
+			all = append(all, nested...)
+			continue
+		}
+		all = append(all, err)
+	}
+
+	if len(all) == 0 {
+		return nil
+	}
+
+	return all
 }
 
-// A list of error messages for Dataset API
+// A list of errors returned by the API, the first argument of each is its code
 var (
-	ErrLimitWrongType  = errors.New("limit value needs to be a number")
-	ErrNegativeLimit   = errors.New("limit needs to be a positive number, limit cannot be lower than 0")
-	ErrOffsetWrongType = errors.New("offset value needs to be a number")
-	ErrNegativeOffset  = errors.New("offset needs to be a positive number, offset cannot be lower than 0")
-
-	ErrRecipeNotFound      = errors.New("recipe not found")
-	ErrRecipeAlreadyExists = errors.New("recipe already exists, use different title")
-
-	ErrMissingFields       = errors.New("missing mandatory fields")
-	ErrInvalidUnits        = errors.New("invalid units for ingredient")
-	ErrInvalidPortionSize  = errors.New("invalid portion size, cannot be less than 1")
-	ErrUnableToChangeTitle = errors.New("not allowed to change the existing title for recipe")
-
-	ErrInvalidOperation             = errors.New("patch operation is invalid, has to be one of the following: add copy move remove replace test")
-	ErrUnsupportedOperation         = errors.New("patch operation not supported")
-	ErrPathAndFromFieldsCannotMatch = errors.New("path and from values cannot match")
-
-	ErrUnableToParseJSON   = errors.New("failed to parse json body")
-	ErrUnableToReadMessage = errors.New("failed to read message body")
-	ErrInternalServer      = errors.New("internal server error")
+	ErrLimitWrongType  = newError("invalid_limit", http.StatusBadRequest, "limit value needs to be a number")
+	ErrNegativeLimit   = newError("negative_limit", http.StatusBadRequest, "limit needs to be a positive number, limit cannot be lower than 0")
+	ErrLimitExceeded   = newError("limit_exceeded", http.StatusBadRequest, "limit exceeded maximum value")
+	ErrOffsetWrongType = newError("invalid_offset", http.StatusBadRequest, "offset value needs to be a number")
+	ErrNegativeOffset  = newError("negative_offset", http.StatusBadRequest,
+		"offset needs to be a positive number, offset cannot be lower than 0")
+	ErrOffsetExceeded = newError("offset_exceeded", http.StatusBadRequest, "the maximum offset has been reached")
+
+	ErrRecipeNotFound      = newError("recipe_not_found", http.StatusNotFound, "recipe not found")
+	ErrRecipeAlreadyExists = newError("recipe_already_exists", http.StatusConflict, "recipe already exists, use different title")
+	ErrRecipeChanged       = newError("recipe_changed", http.StatusConflict, "recipe was changed or removed while being updated, retry")
+	ErrInvalidRecipeID     = newError("invalid_recipe_id", http.StatusBadRequest,
+		"recipe can only be created with an id containing lower case letters, numbers and hyphens")
+
+	ErrMissingFields       = newError("missing_fields", http.StatusBadRequest, "missing mandatory fields")
+	ErrInvalidDifficulty   = newError("invalid_difficulty", http.StatusBadRequest, "invalid difficulty")
+	ErrInvalidUnits        = newError("invalid_units", http.StatusBadRequest, "invalid units for ingredient")
+	ErrInvalidPortionSize  = newError("invalid_portion_size", http.StatusBadRequest, "invalid portion size, cannot be less than 1")
+	ErrInvalidLocation     = newError("invalid_location", http.StatusBadRequest, "invalid location")
+	ErrUnableToChangeTitle = newError("title_not_changeable", http.StatusBadRequest, "not allowed to change the existing title for recipe")
+	ErrInvalidTitle        = newError("invalid_title", http.StatusBadRequest, "invalid title, must contain at least one letter or number")
+	ErrInvalidVisibility   = newError("invalid_visibility", http.StatusBadRequest,
+		"invalid visibility, must be one of: private household public")
+
+	ErrInvalidOperation = newError("invalid_patch_operation", http.StatusBadRequest,
+		"patch operation is invalid, has to be one of the following: add copy move remove replace test")
+	ErrUnsupportedOperation         = newError("unsupported_patch_operation", http.StatusBadRequest, "patch operation not supported")
+	ErrPathAndFromFieldsCannotMatch = newError("patch_path_matches_from", http.StatusBadRequest, "path and from values cannot match")
+	ErrPathNotPatchable             = newError("path_not_patchable", http.StatusBadRequest, "patch path cannot be modified")
+	ErrUnableToRenameTitle          = newError("title_not_renameable", http.StatusBadRequest,
+		"title can only be changed if the recipe id remains the same")
+	ErrEmptyRequestBody        = newError("empty_request_body", http.StatusBadRequest, "empty request body given")
+	ErrUnableToParsePatch      = newError("invalid_patch", http.StatusBadRequest, "failed to unmarshal patch request body")
+	ErrUnableToParseMergePatch = newError("invalid_merge_patch", http.StatusBadRequest,
+		"failed to unmarshal merge patch request body, expected a json object")
+	ErrNoPatches          = newError("empty_patch", http.StatusBadRequest, "no patches given in request body")
+	ErrNoChanges          = newError("empty_merge_patch", http.StatusBadRequest, "no changes given in request body")
+	ErrUnableToApplyPatch = newError("patch_failed", http.StatusBadRequest, "unable to apply patch to recipe")
+
+	ErrInvalidCredentials = newError("invalid_credentials", http.StatusUnauthorized, "missing or invalid credentials")
+	ErrInsufficientScope  = newError("insufficient_scope", http.StatusForbidden, "caller is not permitted to perform the requested action")
+	ErrNotRecipeOwner     = newError("not_recipe_owner", http.StatusForbidden, "recipe is owned by another caller")
+
+	ErrClientCertificateRequired = newError("client_certificate_required", http.StatusForbidden,
+		"a verified client certificate is required to modify data")
+
+	ErrHouseholdNotFound      = newError("household_not_found", http.StatusNotFound, "household not found")
+	ErrHouseholdAlreadyExists = newError("household_already_exists", http.StatusConflict, "household already exists, use different name")
+	ErrInvalidHouseholdName   = newError("invalid_household_name", http.StatusBadRequest,
+		"invalid household name, must contain at least one letter or number")
+	ErrInvalidRole         = newError("invalid_role", http.StatusBadRequest, "invalid role, must be one of: owner editor viewer")
+	ErrMemberNotFound      = newError("member_not_found", http.StatusNotFound, "household member not found")
+	ErrMemberAlreadyExists = newError("member_already_exists", http.StatusConflict, "caller is already a member of the household")
+	ErrInvitationNotFound  = newError("invitation_not_found", http.StatusNotFound, "household invitation not found")
+	ErrInvitationExists    = newError("invitation_already_exists", http.StatusConflict, "caller has already been invited to the household")
+	ErrLastHouseholdOwner  = newError("last_household_owner", http.StatusConflict, "unable to remove the last owner of a household")
+	ErrNotHouseholdOwner   = newError("not_household_owner", http.StatusForbidden, "only owners of a household can manage its members")
+	ErrNotHouseholdEditor  = newError("not_household_editor", http.StatusForbidden,
+		"recipes can only be shared with a household the caller is an owner or editor of")
+	ErrHouseholdRequired = newError("household_required", http.StatusBadRequest,
+		"recipes with household visibility must be shared with a household")
+
+	ErrAPIKeyNotFound      = newError("api_key_not_found", http.StatusNotFound, "api key not found")
+	ErrAPIKeyAlreadyExists = newError("api_key_already_exists", http.StatusConflict, "api key already exists, use different name")
+	ErrInvalidKeyName      = newError("invalid_key_name", http.StatusBadRequest,
+		"invalid api key name, must contain lower case letters, numbers and hyphens")
+	ErrInvalidScopes = newError("invalid_scopes", http.StatusBadRequest,
+		"invalid scopes, must be one or more of: recipes:read recipes:write recipes:delete admin")
+	ErrInvalidExpiry = newError("invalid_expiry", http.StatusBadRequest, "invalid expiry, must be in the future")
+
+	ErrInvalidSince = newError("invalid_since", http.StatusBadRequest, "invalid since, must be a time in RFC 3339 format")
+
+	ErrUnableToParseJSON    = newError("invalid_json", http.StatusBadRequest, "failed to parse json body")
+	ErrUnsupportedMediaType = newError("unsupported_media_type", http.StatusUnsupportedMediaType,
+		"unsupported content type, patch requests must be application/json-patch+json or application/merge-patch+json")
+	ErrUnableToReadMessage = newError("unreadable_body", http.StatusBadRequest, "failed to read message body")
+	ErrRequestBodyTooLarge = newError("request_body_too_large", http.StatusRequestEntityTooLarge, "request body too large")
+	ErrTooManyRequests     = newError("too_many_requests", http.StatusTooManyRequests, "too many requests, retry later")
+	ErrInternalServer      = newError("internal_server_error", http.StatusInternalServerError, "internal server error")
 )
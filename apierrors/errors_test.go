@@ -0,0 +1,74 @@
+package apierrors_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestError(t *testing.T) {
+	Convey("Given an error with a field and values", t, func() {
+		err := errs.ErrInvalidPortionSize.WithField("/portion_size").WithValues(map[string]string{"portion_size": "0"})
+
+		Convey("Then the error keeps the code, status and message of the original error", func() {
+			So(err.Code, ShouldEqual, "invalid_portion_size")
+			So(err.Status, ShouldEqual, http.StatusBadRequest)
+			So(err.Error(), ShouldEqual, errs.ErrInvalidPortionSize.Error())
+			So(err.Field, ShouldEqual, "/portion_size")
+			So(err.Values, ShouldResemble, map[string]string{"portion_size": "0"})
+		})
+
+		Convey("Then the original error is unchanged", func() {
+			So(errs.ErrInvalidPortionSize.Field, ShouldBeEmpty)
+			So(errs.ErrInvalidPortionSize.Values, ShouldBeNil)
+		})
+
+		Convey("Then the error is matched by the original error, including when wrapped", func() {
+			So(errors.Is(err, errs.ErrInvalidPortionSize), ShouldBeTrue)
+			So(errors.Is(fmt.Errorf("create recipe: %w", err), errs.ErrInvalidPortionSize), ShouldBeTrue)
+			So(errors.Is(err, errs.ErrInvalidUnits), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given an error with a more specific message", t, func() {
+		err := errs.ErrLimitExceeded.WithMessage("limit exceeded maximum value, limit cannot be greater than [1000]")
+
+		Convey("Then the message is changed but the code is not", func() {
+			So(err.Error(), ShouldEqual, "limit exceeded maximum value, limit cannot be greater than [1000]")
+			So(err.Code, ShouldEqual, errs.ErrLimitExceeded.Code)
+			So(errors.Is(err, errs.ErrLimitExceeded), ShouldBeTrue)
+		})
+	})
+}
+
+func TestAppend(t *testing.T) {
+	Convey("Given no errors", t, func() {
+		Convey("Then nil is returned", func() {
+			So(errs.Append(nil), ShouldBeNil)
+			So(errs.Append(nil, nil, nil), ShouldBeNil)
+		})
+	})
+
+	Convey("Given a single error", t, func() {
+		err := errs.Append(nil, errs.ErrMissingFields)
+
+		Convey("Then a list containing the error is returned", func() {
+			So(err, ShouldResemble, errs.Errors{errs.ErrMissingFields})
+			So(errors.Is(err, errs.ErrMissingFields), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given errors appended to a list of errors", t, func() {
+		list := errs.Append(nil, errs.ErrMissingFields, nil)
+		err := errs.Append(list, errs.Append(nil, errs.ErrInvalidUnits, errs.ErrInvalidTitle))
+
+		Convey("Then the lists are flattened in order", func() {
+			So(err, ShouldResemble, errs.Errors{errs.ErrMissingFields, errs.ErrInvalidUnits, errs.ErrInvalidTitle})
+			So(err.Error(), ShouldEqual, "missing mandatory fields; invalid units for ingredient; "+errs.ErrInvalidTitle.Error())
+		})
+	})
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// Scope grants access to a group of operations on the API
+type Scope string
+
+// Possible scopes granted to callers
+const (
+	ScopeRecipesRead   Scope = "recipes:read"
+	ScopeRecipesWrite  Scope = "recipes:write"
+	ScopeRecipesDelete Scope = "recipes:delete"
+	ScopeAdmin         Scope = "admin"
+)
+
+var validScopes = map[Scope]bool{
+	ScopeRecipesRead:   true,
+	ScopeRecipesWrite:  true,
+	ScopeRecipesDelete: true,
+	ScopeAdmin:         true,
+}
+
+// IsValid returns true if the scope is one of the scopes known to the API
+func (s Scope) IsValid() bool {
+	return validScopes[s]
+}
+
+// Methods used to authenticate a caller
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// Identity represents an authenticated caller, the name is either the name of an API key or the subject of a JWT
+type Identity struct {
+	Name   string
+	Method string
+	Scopes []Scope
+}
+
+// HasScope returns true if the caller has been granted the scope, the admin scope grants all other scopes
+func (i *Identity) HasScope(scope Scope) bool {
+	if i == nil {
+		return false
+	}
+
+	for _, s := range i.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Authenticator authenticates bearer tokens, passing tokens in the form of a JWT to the JWT authenticator, if
+// JWT authentication is enabled, and all other tokens to the key store
+type Authenticator struct {
+	Keys *KeyStore
+	JWT  *JWTAuthenticator
+}
+
+// Authenticate returns the identity of the caller presenting the bearer token
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	if a.JWT != nil && strings.Count(token, ".") == 2 {
+		return a.JWT.Authenticate(ctx, token)
+	}
+
+	return a.Keys.Authenticate(ctx, token)
+}
+
+type contextKey string
+
+const identityKey contextKey = "identity"
+
+// WithIdentity returns a copy of the context containing the identity of the caller
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// IdentityFromContext returns the identity of the caller stored in the context, or nil for an anonymous caller
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey).(*Identity)
+	return identity
+}
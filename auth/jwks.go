@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+const (
+	minRSAKeyBits   = 2048
+	minHMACKeyBytes = 32
+)
+
+// Supported JWT signing algorithms
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgHS256 = "HS256"
+)
+
+// keyTypes maps each supported algorithm to the only type of key it can be verified with, preventing a token
+// from choosing an algorithm which would misuse a key, e.g. verifying a HS256 signature with an RSA public key
+var keyTypes = map[string]string{
+	AlgRS256: "RSA",
+	AlgES256: "EC",
+	AlgHS256: "oct",
+}
+
+// JSONWebKey is a key used to verify the signature of a JWT, see RFC 7517
+type JSONWebKey struct {
+	ID        string
+	Algorithm string
+	Key       interface{}
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+
+	// RSA public key members
+	N string `json:"n"`
+	E string `json:"e"`
+
+	// EC public key members
+	Curve string `json:"crv"`
+	X     string `json:"x"`
+	Y     string `json:"y"`
+
+	// symmetric key members
+	K string `json:"k"`
+}
+
+// LoadJWKS reads a JSON Web Key Set from a local file. Keys which are not used for signatures are ignored.
+func LoadJWKS(path string) ([]JSONWebKey, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // path is provided by configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read jwks file: %w", err)
+	}
+
+	return ParseJWKS(b)
+}
+
+// ParseJWKS parses a JSON Web Key Set. Keys which are not used for signatures are ignored.
+func ParseJWKS(b []byte) ([]JSONWebKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(b, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse jwks: %w", err)
+	}
+
+	keys := make([]JSONWebKey, 0, len(set.Keys))
+	for i := range set.Keys {
+		jwk := &set.Keys[i]
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.parse()
+		if err != nil {
+			return nil, fmt.Errorf("invalid key [%d] %q in jwks: %w", i, jwk.KeyID, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("jwks does not contain any signing keys")
+	}
+
+	return keys, nil
+}
+
+func (jwk *jsonWebKey) parse() (JSONWebKey, error) {
+	key := JSONWebKey{ID: jwk.KeyID, Algorithm: jwk.Algorithm}
+
+	if jwk.Algorithm != "" && keyTypes[jwk.Algorithm] != jwk.KeyType {
+		return key, fmt.Errorf("algorithm %q is not supported for key type %q", jwk.Algorithm, jwk.KeyType)
+	}
+
+	var err error
+
+	switch jwk.KeyType {
+	case "RSA":
+		key.Key, err = jwk.rsaPublicKey()
+	case "EC":
+		key.Key, err = jwk.ecdsaPublicKey()
+	case "oct":
+		key.Key, err = jwk.hmacKey()
+	default:
+		err = fmt.Errorf("unsupported key type %q", jwk.KeyType)
+	}
+
+	return key, err
+}
+
+func (jwk *jsonWebKey) hmacKey() ([]byte, error) {
+	key, err := decodeSegment(jwk.K)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(key) < minHMACKeyBytes {
+		return nil, fmt.Errorf("hmac key must be at least %d bytes", minHMACKeyBytes)
+	}
+
+	return key, nil
+}
+
+func (jwk *jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeSegment(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := decodeSegment(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid rsa exponent")
+	}
+
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}
+	if key.N.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("rsa key must be at least %d bits", minRSAKeyBits)
+	}
+
+	return key, nil
+}
+
+func (jwk *jsonWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	if jwk.Curve != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Curve)
+	}
+
+	x, err := decodeSegment(jwk.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := decodeSegment(jwk.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !key.Curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("point is not on curve")
+	}
+
+	return key, nil
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("missing key member")
+	}
+
+	return base64.RawURLEncoding.DecodeString(s)
+}
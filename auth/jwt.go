@@ -0,0 +1,276 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+)
+
+const es256SignatureLength = 64
+
+// TokenError describes why a bearer token was rejected, it is reported to the caller in the WWW-Authenticate header
+type TokenError struct {
+	Description string
+}
+
+func (e *TokenError) Error() string {
+	return "invalid token: " + e.Description
+}
+
+// Unwrap allows a TokenError to be treated as invalid credentials
+func (e *TokenError) Unwrap() error {
+	return errs.ErrInvalidCredentials
+}
+
+func invalidToken(description string) error {
+	return &TokenError{Description: description}
+}
+
+// JWTConfig contains the settings used to validate JWTs
+type JWTConfig struct {
+	// Audience, if set, must be one of the audiences of the token
+	Audience string
+	// Issuer, if set, must match the issuer of the token
+	Issuer string
+	// Leeway allows for clock skew when checking the times in a token
+	Leeway time.Duration
+	// ScopeClaim is the claim containing the scopes granted to the caller, either a space separated string or a list
+	ScopeClaim string
+}
+
+// JWTAuthenticator authenticates callers presenting a JWT signed by one of a set of JSON Web Keys
+type JWTAuthenticator struct {
+	config JWTConfig
+	keys   []JSONWebKey
+	now    func() time.Time
+}
+
+// NewJWTAuthenticator creates an authenticator verifying tokens with the given keys
+func NewJWTAuthenticator(keys []JSONWebKey, config JWTConfig) *JWTAuthenticator {
+	if config.ScopeClaim == "" {
+		config.ScopeClaim = "scope"
+	}
+
+	return &JWTAuthenticator{
+		config: config,
+		keys:   keys,
+		now:    time.Now,
+	}
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+	Type      string `json:"typ"`
+}
+
+// Authenticate verifies the signature and claims of a JWT and returns the identity of its subject, with the known
+// scopes from the scope claim. Unknown scopes are ignored.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return nil, invalidToken("token is not a jwt")
+	}
+
+	var header jwtHeader
+	if err := decodeJSONSegment(segments[0], &header); err != nil {
+		return nil, invalidToken("token header is malformed")
+	}
+
+	if _, ok := keyTypes[header.Algorithm]; !ok {
+		return nil, invalidToken("unsupported signing algorithm")
+	}
+
+	signature, err := decodeSegment(segments[2])
+	if err != nil {
+		return nil, invalidToken("token signature is malformed")
+	}
+
+	if err = a.verify(&header, []byte(segments[0]+"."+segments[1]), signature); err != nil {
+		return nil, err
+	}
+
+	claims := map[string]interface{}{}
+	if err = decodeJSONSegment(segments[1], &claims); err != nil {
+		return nil, invalidToken("token claims are malformed")
+	}
+
+	if err = a.validateClaims(claims); err != nil {
+		return nil, err
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return nil, invalidToken("token is missing the sub claim")
+	}
+
+	return &Identity{Name: subject, Method: MethodJWT, Scopes: scopesFromClaim(claims[a.config.ScopeClaim])}, nil
+}
+
+// verify checks the signature using the key identified by the token, or any key supporting the algorithm of the
+// token if the token does not identify a key
+func (a *JWTAuthenticator) verify(header *jwtHeader, signingInput, signature []byte) error {
+	keyType := keyTypes[header.Algorithm]
+
+	var found bool
+	for i := range a.keys {
+		key := &a.keys[i]
+		if header.KeyID != "" && key.ID != header.KeyID {
+			continue
+		}
+
+		if (key.Algorithm != "" && key.Algorithm != header.Algorithm) || !isKeyType(key.Key, keyType) {
+			continue
+		}
+
+		found = true
+		if verifySignature(header.Algorithm, key.Key, signingInput, signature) {
+			return nil
+		}
+	}
+
+	if !found {
+		return invalidToken("no key found to verify token")
+	}
+
+	return invalidToken("token signature is invalid")
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]interface{}) error {
+	now := a.now()
+
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return invalidToken("token is missing the exp claim")
+	}
+
+	if !now.Before(exp.Add(a.config.Leeway)) {
+		return invalidToken("token has expired")
+	}
+
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(a.config.Leeway).Before(nbf) {
+		return invalidToken("token is not valid yet")
+	}
+
+	if a.config.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.config.Issuer {
+			return invalidToken("token issuer is not trusted")
+		}
+	}
+
+	if a.config.Audience != "" && !hasAudience(claims["aud"], a.config.Audience) {
+		return invalidToken("token audience does not include this api")
+	}
+
+	return nil
+}
+
+func verifySignature(algorithm string, key interface{}, signingInput, signature []byte) bool {
+	digest := sha256.Sum256(signingInput)
+
+	switch algorithm {
+	case AlgRS256:
+		return rsa.VerifyPKCS1v15(key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case AlgES256:
+		if len(signature) != es256SignatureLength {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:es256SignatureLength/2])
+		s := new(big.Int).SetBytes(signature[es256SignatureLength/2:])
+		return ecdsa.Verify(key.(*ecdsa.PublicKey), digest[:], r, s)
+	case AlgHS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write(signingInput)
+		return hmac.Equal(mac.Sum(nil), signature)
+	}
+
+	return false
+}
+
+func isKeyType(key interface{}, keyType string) bool {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return keyType == "RSA"
+	case *ecdsa.PublicKey:
+		return keyType == "EC"
+	case []byte:
+		return keyType == "oct"
+	}
+
+	return false
+}
+
+func decodeJSONSegment(segment string, v interface{}) error {
+	b, err := decodeSegment(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	return decoder.Decode(v)
+}
+
+func numericDate(claim interface{}) (time.Time, bool) {
+	number, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(seconds), 0), true
+}
+
+func hasAudience(claim interface{}, audience string) bool {
+	switch aud := claim.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func scopesFromClaim(claim interface{}) []Scope {
+	var values []string
+
+	switch c := claim.(type) {
+	case string:
+		values = strings.Fields(c)
+	case []interface{}:
+		for _, v := range c {
+			if s, ok := v.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	var scopes []Scope
+	for _, v := range values {
+		if scope := Scope(v); scope.IsValid() {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return scopes
+}
@@ -0,0 +1,300 @@
+package auth_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	issuer   = "https://identity.internal"
+	audience = "food-recipes"
+)
+
+var hmacSecret = []byte("a-shared-secret-of-at-least-32-bytes")
+
+type signingKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func TestJWTAuthenticator(t *testing.T) {
+	keys := generateSigningKeys(t)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(jwksPath, keys.jwks(t), 0o600); err != nil {
+		t.Fatalf("unable to write jwks: %v", err)
+	}
+
+	jwks, err := auth.LoadJWKS(jwksPath)
+	if err != nil {
+		t.Fatalf("unable to load jwks: %v", err)
+	}
+
+	authenticator := auth.NewJWTAuthenticator(jwks, auth.JWTConfig{Audience: audience, Issuer: issuer, Leeway: time.Second})
+	ctx := context.Background()
+
+	for _, alg := range []string{auth.AlgRS256, auth.AlgES256, auth.AlgHS256} {
+		Convey("Given a valid token signed with "+alg, t, func() {
+			token := keys.sign(t, alg, "", validClaims())
+
+			Convey("Then the identity of the subject is returned with the known scopes", func() {
+				identity, err := authenticator.Authenticate(ctx, token)
+
+				So(err, ShouldBeNil)
+				So(identity.Name, ShouldEqual, "alice")
+				So(identity.Method, ShouldEqual, auth.MethodJWT)
+				So(identity.Scopes, ShouldResemble, []auth.Scope{auth.ScopeRecipesRead, auth.ScopeRecipesWrite})
+			})
+		})
+	}
+
+	Convey("Given a token with scopes given as a list", t, func() {
+		claims := validClaims()
+		claims["scope"] = []string{"recipes:delete"}
+		token := keys.sign(t, auth.AlgRS256, "rsa-key", claims)
+
+		Convey("Then the scopes are granted", func() {
+			identity, err := authenticator.Authenticate(ctx, token)
+
+			So(err, ShouldBeNil)
+			So(identity.HasScope(auth.ScopeRecipesDelete), ShouldBeTrue)
+		})
+	})
+
+	tables := []struct {
+		givenTitle          string
+		token               func() string
+		expectedDescription string
+	}{
+		{
+			"Given an expired token",
+			func() string {
+				claims := validClaims()
+				claims["exp"] = time.Now().Add(-time.Minute).Unix()
+				return keys.sign(t, auth.AlgRS256, "", claims)
+			},
+			"token has expired",
+		},
+		{
+			"Given a token which is not valid yet",
+			func() string {
+				claims := validClaims()
+				claims["nbf"] = time.Now().Add(time.Minute).Unix()
+				return keys.sign(t, auth.AlgES256, "", claims)
+			},
+			"token is not valid yet",
+		},
+		{
+			"Given a token without an expiry",
+			func() string {
+				claims := validClaims()
+				delete(claims, "exp")
+				return keys.sign(t, auth.AlgHS256, "", claims)
+			},
+			"token is missing the exp claim",
+		},
+		{
+			"Given a token from an untrusted issuer",
+			func() string {
+				claims := validClaims()
+				claims["iss"] = "https://elsewhere"
+				return keys.sign(t, auth.AlgRS256, "", claims)
+			},
+			"token issuer is not trusted",
+		},
+		{
+			"Given a token for a different audience",
+			func() string {
+				claims := validClaims()
+				claims["aud"] = []string{"another-api"}
+				return keys.sign(t, auth.AlgRS256, "", claims)
+			},
+			"token audience does not include this api",
+		},
+		{
+			"Given a token whose claims have been altered",
+			func() string {
+				segments := strings.Split(keys.sign(t, auth.AlgRS256, "", validClaims()), ".")
+				claims := validClaims()
+				claims["scope"] = "admin"
+				segments[1] = encode(t, claims)
+				return strings.Join(segments, ".")
+			},
+			"token signature is invalid",
+		},
+		{
+			"Given a token identifying an unknown key",
+			func() string {
+				return keys.sign(t, auth.AlgRS256, "unknown-key", validClaims())
+			},
+			"no key found to verify token",
+		},
+		{
+			"Given an unsigned token",
+			func() string {
+				return encode(t, map[string]string{"alg": "none"}) + "." + encode(t, validClaims()) + "."
+			},
+			"unsupported signing algorithm",
+		},
+		{
+			"Given a token which is not a jwt",
+			func() string {
+				return "frk_not-a-jwt"
+			},
+			"token is not a jwt",
+		},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			Convey("Then the token is rejected as invalid credentials", func() {
+				identity, err := authenticator.Authenticate(ctx, table.token())
+
+				So(identity, ShouldBeNil)
+				So(errors.Is(err, errs.ErrInvalidCredentials), ShouldBeTrue)
+
+				var tokenErr *auth.TokenError
+				So(errors.As(err, &tokenErr), ShouldBeTrue)
+				So(tokenErr.Description, ShouldEqual, table.expectedDescription)
+			})
+		})
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	Convey("Given a jwks containing a key for an algorithm which does not match its type", t, func() {
+		jwks := `{"keys": [{"kty": "oct", "alg": "RS256", "k": "c2VjcmV0"}]}`
+
+		Convey("Then an error is returned", func() {
+			_, err := auth.ParseJWKS([]byte(jwks))
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a jwks containing a hmac key shorter than 32 bytes", t, func() {
+		jwks := `{"keys": [{"kty": "oct", "alg": "HS256", "k": "c2VjcmV0"}]}`
+
+		Convey("Then an error is returned", func() {
+			_, err := auth.ParseJWKS([]byte(jwks))
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given a jwks containing only encryption keys", t, func() {
+		jwks := `{"keys": [{"kty": "oct", "use": "enc", "k": "c2VjcmV0"}]}`
+
+		Convey("Then an error is returned", func() {
+			_, err := auth.ParseJWKS([]byte(jwks))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":   "alice",
+		"iss":   issuer,
+		"aud":   audience,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"scope": "recipes:read recipes:write openid",
+	}
+}
+
+func generateSigningKeys(t *testing.T) *signingKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate rsa key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate ec key: %v", err)
+	}
+
+	return &signingKeys{rsa: rsaKey, ec: ecKey}
+}
+
+func (k *signingKeys) jwks(t *testing.T) []byte {
+	b64 := base64.RawURLEncoding.EncodeToString
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa-key", "alg": "RS256", "use": "sig", "n": b64(k.rsa.N.Bytes()), "e": b64(big.NewInt(int64(k.rsa.E)).Bytes())},
+			{"kty": "EC", "kid": "ec-key", "crv": "P-256", "x": b64(k.ec.X.FillBytes(make([]byte, 32))), "y": b64(k.ec.Y.FillBytes(make([]byte, 32)))},
+			{"kty": "oct", "kid": "hmac-key", "alg": "HS256", "k": b64(hmacSecret)},
+			{"kty": "oct", "kid": "encryption-key", "use": "enc", "k": b64([]byte("ignored"))},
+		},
+	}
+
+	b, err := json.Marshal(jwks)
+	if err != nil {
+		t.Fatalf("unable to marshal jwks: %v", err)
+	}
+
+	return b
+}
+
+func (k *signingKeys) sign(t *testing.T, alg, kid string, claims interface{}) string {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	signingInput := encode(t, header) + "." + encode(t, claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var (
+		signature []byte
+		err       error
+	)
+
+	switch alg {
+	case auth.AlgRS256:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k.rsa, crypto.SHA256, digest[:])
+	case auth.AlgES256:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k.ec, digest[:])
+		if err == nil {
+			signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+		}
+	case auth.AlgHS256:
+		mac := hmac.New(sha256.New, hmacSecret)
+		mac.Write([]byte(signingInput))
+		signature = mac.Sum(nil)
+	}
+
+	if err != nil {
+		t.Fatalf("unable to sign token: %v", err)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encode(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("unable to marshal token segment: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
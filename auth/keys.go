@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+)
+
+const (
+	keySeparator   = ":"
+	scopeSeparator = "|"
+
+	// keyPrefix identifies keys issued by the API, making them easier to spot if leaked
+	keyPrefix = "frk_"
+	keyLength = 32
+
+	// lastUsedResolution limits how often the last used time of a persisted key is written
+	lastUsedResolution = time.Minute
+)
+
+//go:generate moq -out mock/persisted.go -pkg mock . PersistedKeys
+
+// PersistedKeys retrieves API keys issued through the API
+type PersistedKeys interface {
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, name string, usedAt time.Time) error
+}
+
+// APIKey is a key issued to a named caller granting a set of scopes, only the hash of the key is held
+type APIKey struct {
+	Name   string
+	Hash   []byte
+	Scopes []Scope
+}
+
+// HashKey returns the hex encoded SHA-256 hash of an API key, which is the form keys are configured in
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseAPIKeys parses a list of API keys from configuration, each in the format
+// <name>:<hex encoded sha256 hash of key>:<scope>|<scope>, e.g. ci:9f86d08...:recipes:write|recipes:delete
+func ParseAPIKeys(values []string) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(values))
+	names := map[string]bool{}
+
+	for i, value := range values {
+		parts := strings.SplitN(strings.TrimSpace(value), keySeparator, 3)
+		if len(parts) != 3 || parts[0] == "" {
+			return nil, fmt.Errorf("api key [%d] is not in the format <name>:<sha256 hash>:<scopes>", i)
+		}
+
+		name := parts[0]
+		if names[name] {
+			return nil, fmt.Errorf("api key [%d] has duplicate name %q", i, name)
+		}
+		names[name] = true
+
+		hash, err := hex.DecodeString(parts[1])
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %q must contain a hex encoded sha256 hash of the key", name)
+		}
+
+		key := APIKey{Name: name, Hash: hash}
+
+		for _, s := range strings.Split(parts[2], scopeSeparator) {
+			scope := Scope(strings.TrimSpace(s))
+			if !scope.IsValid() {
+				return nil, fmt.Errorf("api key %q contains invalid scope %q", name, s)
+			}
+			key.Scopes = append(key.Scopes, scope)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// GenerateKey returns a new random API key
+func GenerateKey() (string, error) {
+	b := make([]byte, keyLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// KeyStore authenticates callers presenting either one of the API keys from configuration or an API key issued
+// through the API
+type KeyStore struct {
+	keys      []APIKey
+	persisted PersistedKeys
+}
+
+// NewKeyStore creates a key store holding the given API keys from configuration, persisted may be nil if API keys
+// are not issued through the API
+func NewKeyStore(keys []APIKey, persisted PersistedKeys) *KeyStore {
+	return &KeyStore{keys: keys, persisted: persisted}
+}
+
+// Authenticate returns the identity of the caller the API key was issued to. The hash of the key is compared against
+// the hash of every configured key in constant time so the time taken does not reveal which, if any, of the keys
+// matched. Persisted keys are looked up by their hash, which does not reveal anything useful about the key itself.
+func (s *KeyStore) Authenticate(ctx context.Context, key string) (*Identity, error) {
+	if key == "" {
+		return nil, errs.ErrInvalidCredentials
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	var match *APIKey
+	for i := range s.keys {
+		if subtle.ConstantTimeCompare(sum[:], s.keys[i].Hash) == 1 {
+			match = &s.keys[i]
+		}
+	}
+
+	if match != nil {
+		return &Identity{Name: match.Name, Method: MethodAPIKey, Scopes: match.Scopes}, nil
+	}
+
+	if s.persisted == nil || !strings.HasPrefix(key, keyPrefix) {
+		return nil, errs.ErrInvalidCredentials
+	}
+
+	persisted, err := s.persisted.GetAPIKeyByHash(ctx, hex.EncodeToString(sum[:]))
+	if err != nil {
+		if err == errs.ErrAPIKeyNotFound {
+			return nil, errs.ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	logData := log.Data{"key_name": persisted.Name}
+
+	if !persisted.IsActive(now) {
+		log.Warn(ctx, "api key has expired or been revoked", logData)
+		return nil, errs.ErrInvalidCredentials
+	}
+
+	if persisted.LastUsedAt == nil || now.Sub(*persisted.LastUsedAt) >= lastUsedResolution {
+		if err = s.persisted.TouchAPIKey(ctx, persisted.Name, now); err != nil {
+			log.Warn(ctx, "failed to record last use of api key", log.FormatErrors([]error{err}), logData)
+		}
+	}
+
+	identity := &Identity{Name: persisted.Name, Method: MethodAPIKey}
+	for _, scope := range persisted.Scopes {
+		identity.Scopes = append(identity.Scopes, Scope(scope))
+	}
+
+	return identity, nil
+}
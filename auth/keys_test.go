@@ -0,0 +1,316 @@
+package auth_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	ciKey    = "ci-secret-key"
+	adminKey = "admin-secret-key"
+
+	issuedKey = "frk_issued-secret-key"
+)
+
+var errKeyStore = errors.New("key store unavailable")
+
+// persistedKeys holds issued api keys by their hash, recording lookups and the keys touched
+type persistedKeys struct {
+	keys     map[string]*models.APIKey
+	err      error
+	touchErr error
+	lookups  int
+	touched  []string
+}
+
+func (p *persistedKeys) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	p.lookups++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	key, ok := p.keys[hash]
+	if !ok {
+		return nil, errs.ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (p *persistedKeys) TouchAPIKey(ctx context.Context, name string, usedAt time.Time) error {
+	p.touched = append(p.touched, name)
+	return p.touchErr
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	Convey("Given a list of valid api keys", t, func() {
+		values := []string{
+			"ci:" + auth.HashKey(ciKey) + ":recipes:write|recipes:delete",
+			" admin:" + auth.HashKey(adminKey) + ":admin ",
+		}
+
+		Convey("Then the keys are parsed with their names and scopes", func() {
+			keys, err := auth.ParseAPIKeys(values)
+
+			So(err, ShouldBeNil)
+			So(keys, ShouldHaveLength, 2)
+			So(keys[0].Name, ShouldEqual, "ci")
+			So(keys[0].Scopes, ShouldResemble, []auth.Scope{auth.ScopeRecipesWrite, auth.ScopeRecipesDelete})
+			So(keys[1].Name, ShouldEqual, "admin")
+			So(keys[1].Scopes, ShouldResemble, []auth.Scope{auth.ScopeAdmin})
+		})
+	})
+
+	tables := []struct {
+		givenTitle string
+		value      string
+	}{
+		{"Given an api key missing its scopes", "ci:" + auth.HashKey(ciKey)},
+		{"Given an api key without a name", ":" + auth.HashKey(ciKey) + ":admin"},
+		{"Given an api key containing the key in place of its hash", "ci:" + ciKey + ":admin"},
+		{"Given an api key containing an unknown scope", "ci:" + auth.HashKey(ciKey) + ":recipes:everything"},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			Convey("Then an error is returned", func() {
+				keys, err := auth.ParseAPIKeys([]string{table.value})
+
+				So(err, ShouldNotBeNil)
+				So(keys, ShouldBeNil)
+			})
+		})
+	}
+
+	Convey("Given two api keys with the same name", t, func() {
+		values := []string{"ci:" + auth.HashKey(ciKey) + ":admin", "ci:" + auth.HashKey(adminKey) + ":admin"}
+
+		Convey("Then an error is returned", func() {
+			_, err := auth.ParseAPIKeys(values)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestKeyStoreAuthenticate(t *testing.T) {
+	keys, err := auth.ParseAPIKeys([]string{
+		"ci:" + auth.HashKey(ciKey) + ":recipes:write",
+		"admin:" + auth.HashKey(adminKey) + ":admin",
+	})
+	if err != nil {
+		t.Fatalf("unable to parse api keys: %v", err)
+	}
+
+	store := auth.NewKeyStore(keys, nil)
+	ctx := context.Background()
+
+	Convey("Given a known api key", t, func() {
+		Convey("Then the identity of the caller is returned", func() {
+			identity, err := store.Authenticate(ctx, ciKey)
+
+			So(err, ShouldBeNil)
+			So(identity.Name, ShouldEqual, "ci")
+			So(identity.HasScope(auth.ScopeRecipesWrite), ShouldBeTrue)
+			So(identity.HasScope(auth.ScopeRecipesDelete), ShouldBeFalse)
+		})
+	})
+
+	Convey("Given an api key with the admin scope", t, func() {
+		Convey("Then the caller has every scope", func() {
+			identity, err := store.Authenticate(ctx, adminKey)
+
+			So(err, ShouldBeNil)
+			So(identity.HasScope(auth.ScopeRecipesDelete), ShouldBeTrue)
+			So(identity.HasScope(auth.ScopeRecipesRead), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given an unknown api key", t, func() {
+		Convey("Then the caller is not authenticated", func() {
+			identity, err := store.Authenticate(ctx, "coffee-break")
+
+			So(err, ShouldEqual, errs.ErrInvalidCredentials)
+			So(identity, ShouldBeNil)
+		})
+	})
+
+	Convey("Given the hash of a known api key", t, func() {
+		Convey("Then the caller is not authenticated", func() {
+			_, err := store.Authenticate(ctx, auth.HashKey(ciKey))
+			So(err, ShouldEqual, errs.ErrInvalidCredentials)
+		})
+	})
+}
+
+func TestKeyStoreAuthenticatePersisted(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+	recent := now.Add(-10 * time.Second)
+
+	newPersistedKeys := func(key models.APIKey) *persistedKeys {
+		key.Name = "importer"
+		key.Hash = auth.HashKey(issuedKey)
+		key.Scopes = []string{"recipes:write"}
+		return &persistedKeys{keys: map[string]*models.APIKey{key.Hash: &key}}
+	}
+
+	Convey("Given an issued api key which has not been used", t, func() {
+		persisted := newPersistedKeys(models.APIKey{ExpiresAt: &future})
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When the key is authenticated", func() {
+			identity, err := store.Authenticate(ctx, issuedKey)
+
+			Convey("Then the identity of the caller is returned with the scopes of the key", func() {
+				So(err, ShouldBeNil)
+				So(identity.Name, ShouldEqual, "importer")
+				So(identity.Method, ShouldEqual, auth.MethodAPIKey)
+				So(identity.Scopes, ShouldResemble, []auth.Scope{auth.ScopeRecipesWrite})
+			})
+
+			Convey("Then the last use of the key is recorded", func() {
+				So(persisted.touched, ShouldResemble, []string{"importer"})
+			})
+		})
+	})
+
+	Convey("Given an issued api key which was used within the last minute", t, func() {
+		persisted := newPersistedKeys(models.APIKey{LastUsedAt: &recent})
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When the key is authenticated", func() {
+			_, err := store.Authenticate(ctx, issuedKey)
+
+			Convey("Then the last use of the key is not written again", func() {
+				So(err, ShouldBeNil)
+				So(persisted.touched, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given an issued api key which was last used over a minute ago", t, func() {
+		persisted := newPersistedKeys(models.APIKey{LastUsedAt: &past})
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When the key is authenticated", func() {
+			_, err := store.Authenticate(ctx, issuedKey)
+
+			Convey("Then the last use of the key is recorded", func() {
+				So(err, ShouldBeNil)
+				So(persisted.touched, ShouldResemble, []string{"importer"})
+			})
+		})
+	})
+
+	Convey("Given the last use of an issued api key cannot be recorded", t, func() {
+		persisted := newPersistedKeys(models.APIKey{})
+		persisted.touchErr = errKeyStore
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When the key is authenticated", func() {
+			identity, err := store.Authenticate(ctx, issuedKey)
+
+			Convey("Then the caller is still authenticated", func() {
+				So(err, ShouldBeNil)
+				So(identity.Name, ShouldEqual, "importer")
+			})
+		})
+	})
+
+	tables := []struct {
+		givenTitle string
+		key        models.APIKey
+	}{
+		{"Given an issued api key which has expired", models.APIKey{ExpiresAt: &past}},
+		{"Given an issued api key which expires now", models.APIKey{ExpiresAt: &now}},
+		{"Given an issued api key which has been revoked", models.APIKey{ExpiresAt: &future, RevokedAt: &past}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			persisted := newPersistedKeys(table.key)
+			store := auth.NewKeyStore(nil, persisted)
+
+			Convey("When the key is authenticated", func() {
+				identity, err := store.Authenticate(ctx, issuedKey)
+
+				Convey("Then the caller is not authenticated and the key is not touched", func() {
+					So(err, ShouldEqual, errs.ErrInvalidCredentials)
+					So(identity, ShouldBeNil)
+					So(persisted.touched, ShouldBeEmpty)
+				})
+			})
+		})
+	}
+
+	Convey("Given an unknown key with the prefix of issued api keys", t, func() {
+		persisted := newPersistedKeys(models.APIKey{})
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When the key is authenticated", func() {
+			identity, err := store.Authenticate(ctx, "frk_unknown")
+
+			Convey("Then the key is looked up and the caller is not authenticated", func() {
+				So(persisted.lookups, ShouldEqual, 1)
+				So(err, ShouldEqual, errs.ErrInvalidCredentials)
+				So(identity, ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a key without the prefix of issued api keys", t, func() {
+		persisted := newPersistedKeys(models.APIKey{})
+		persisted.keys[auth.HashKey("issued-secret-key")] = persisted.keys[auth.HashKey(issuedKey)]
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When the key is authenticated", func() {
+			_, err := store.Authenticate(ctx, "issued-secret-key")
+
+			Convey("Then the key is not looked up and the caller is not authenticated", func() {
+				So(persisted.lookups, ShouldEqual, 0)
+				So(err, ShouldEqual, errs.ErrInvalidCredentials)
+			})
+		})
+	})
+
+	Convey("Given a key matching an api key from configuration", t, func() {
+		keys, err := auth.ParseAPIKeys([]string{"ci:" + auth.HashKey(issuedKey) + ":admin"})
+		So(err, ShouldBeNil)
+		persisted := newPersistedKeys(models.APIKey{})
+		store := auth.NewKeyStore(keys, persisted)
+
+		Convey("When the key is authenticated", func() {
+			identity, authErr := store.Authenticate(ctx, issuedKey)
+
+			Convey("Then the key from configuration is used without looking up issued keys", func() {
+				So(authErr, ShouldBeNil)
+				So(identity.Name, ShouldEqual, "ci")
+				So(persisted.lookups, ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given the issued api keys cannot be retrieved", t, func() {
+		persisted := newPersistedKeys(models.APIKey{})
+		persisted.err = errKeyStore
+		store := auth.NewKeyStore(nil, persisted)
+
+		Convey("When an issued key is authenticated", func() {
+			identity, err := store.Authenticate(ctx, issuedKey)
+
+			Convey("Then the error is returned rather than treating the key as invalid", func() {
+				So(err, ShouldEqual, errKeyStore)
+				So(identity, ShouldBeNil)
+			})
+		})
+	})
+}
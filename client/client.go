@@ -0,0 +1,280 @@
+// Package client is a Go client for the food recipes API
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+)
+
+const (
+	defaultMaxRetries = 3
+	defaultBackoff    = 100 * time.Millisecond
+	maxBackoff        = 5 * time.Second
+)
+
+// TokenSource returns the bearer token to authenticate a request with, such as an API key or a JWT which is refreshed
+// before it expires
+type TokenSource func(ctx context.Context) (string, error)
+
+// Client makes requests to the food recipes API
+type Client struct {
+	baseURL     *url.URL
+	httpClient  *http.Client
+	tokenSource TokenSource
+	maxRetries  int
+	backoff     time.Duration
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the http client used to make requests, http.DefaultClient is used by default
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithBearerToken authenticates every request with the API key or JWT
+func WithBearerToken(token string) Option {
+	return WithTokenSource(func(ctx context.Context) (string, error) {
+		return token, nil
+	})
+}
+
+// WithTokenSource authenticates every request with the token returned by the token source
+func WithTokenSource(tokenSource TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = tokenSource
+	}
+}
+
+// WithRetries sets the number of times a request is retried after a server error or a failure to connect, waiting
+// for the backoff before the first retry and doubling it before each subsequent retry. Only requests which can be
+// safely repeated, those using GET, PUT or DELETE, are retried. Requests are retried 3 times with a backoff of 100ms
+// by default, a maximum of 0 disables retries.
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.backoff = backoff
+	}
+}
+
+// New creates a client for the API at the base URL, e.g. http://localhost:30000
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		backoff:    defaultBackoff,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// ListOptions pages through a list of recipes, zero values use the defaults of the API
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+// List returns a page of the recipes visible to the caller
+func (c *Client) List(ctx context.Context, opts ListOptions) (*models.Recipes, error) {
+	query := url.Values{}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	var recipes models.Recipes
+	if err := c.do(ctx, http.MethodGet, "/recipes", query, "", nil, &recipes); err != nil {
+		return nil, err
+	}
+
+	return &recipes, nil
+}
+
+// Get returns the recipe with the id, recipes which have been re-slugged are returned using their previous id
+func (c *Client) Get(ctx context.Context, id string) (*models.Recipe, error) {
+	var recipe models.Recipe
+	if err := c.do(ctx, http.MethodGet, recipePath(id), nil, "", nil, &recipe); err != nil {
+		return nil, err
+	}
+
+	return &recipe, nil
+}
+
+// Create creates a recipe owned by the caller and returns the stored recipe, the id is generated from the title
+func (c *Client) Create(ctx context.Context, recipe *models.Recipe) (*models.Recipe, error) {
+	var created models.Recipe
+	if err := c.do(ctx, http.MethodPost, "/recipes", nil, "application/json", recipe, &created); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// Replace creates or replaces the recipe with the id and returns the stored recipe
+func (c *Client) Replace(ctx context.Context, id string, recipe *models.UpdateRecipe) (*models.Recipe, error) {
+	var replaced models.Recipe
+	if err := c.do(ctx, http.MethodPut, recipePath(id), nil, "application/json", recipe, &replaced); err != nil {
+		return nil, err
+	}
+
+	return &replaced, nil
+}
+
+// Patch applies a list of JSON Patch operations to the recipe with the id and returns the patched recipe, see NewPatch
+// for building the list of operations
+func (c *Client) Patch(ctx context.Context, id string, patches ...patch.Patch) (*models.Recipe, error) {
+	var patched models.Recipe
+	if err := c.do(ctx, http.MethodPatch, recipePath(id), nil, patch.JSONPatchMediaType, patches, &patched); err != nil {
+		return nil, err
+	}
+
+	return &patched, nil
+}
+
+// Delete deletes the recipe with the id, deleting a recipe which does not exist is not an error
+func (c *Client) Delete(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, recipePath(id), nil, "", nil, nil)
+}
+
+func recipePath(id string) string {
+	return "/recipes/" + url.PathEscape(id)
+}
+
+// do sends a request with the json encoding of the body, if any, and decodes the json response into the result, if
+// any. Unsuccessful responses are returned as an *Error.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, contentType string, body, result interface{}) error {
+	var b []byte
+	if body != nil {
+		var err error
+		if b, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+	}
+
+	u := *c.baseURL
+	u.Path += path
+	u.RawQuery = query.Encode()
+
+	retries := 0
+	if method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete {
+		retries = c.maxRetries
+	}
+
+	backoff := c.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, u.String(), contentType, b)
+		if err == nil && resp.StatusCode < http.StatusInternalServerError {
+			return handleResponse(resp, result)
+		}
+
+		if attempt >= retries || ctx.Err() != nil {
+			if err != nil {
+				return err
+			}
+			return handleResponse(resp, result)
+		}
+
+		if resp != nil {
+			drain(resp)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// send makes a single attempt at a request, errors are only returned if no response was received
+func (c *Client) send(ctx context.Context, method, u, contentType string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.tokenSource != nil {
+		token, tokenErr := c.tokenSource(ctx)
+		if tokenErr != nil {
+			return nil, fmt.Errorf("failed to get bearer token: %w", tokenErr)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, u, err)
+	}
+
+	return resp, nil
+}
+
+// handleResponse decodes a successful response into the result or returns the error in an unsuccessful response
+func handleResponse(resp *http.Response, result interface{}) error {
+	defer drain(resp)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newError(resp)
+	}
+
+	if result == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to decode response body: %w", err)
+	}
+
+	return nil
+}
+
+// drain reads the rest of the response body so the connection can be reused
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck,gosec // the body is being discarded
+	resp.Body.Close()              //nolint:errcheck,gosec // the body has been read
+}
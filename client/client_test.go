@@ -0,0 +1,242 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/client"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var ctx = context.Background()
+
+func newClient(url string, opts ...client.Option) *client.Client {
+	c, err := client.New(url, append([]client.Option{client.WithRetries(2, time.Millisecond)}, opts...)...)
+	So(err, ShouldBeNil)
+	return c
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) //nolint:errcheck,gosec // test server
+}
+
+func TestNew(t *testing.T) {
+	Convey("Given a base url without a scheme", t, func() {
+		_, err := client.New("localhost:30000")
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestGet(t *testing.T) {
+	Convey("Given a client authenticated with a bearer token", t, func() {
+		var req *http.Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req = r
+			writeJSON(w, http.StatusOK, models.Recipe{ID: "lemon-drizzle", Title: "Lemon Drizzle"})
+		}))
+		defer server.Close()
+
+		c := newClient(server.URL+"/", client.WithBearerToken("secret"))
+
+		Convey("When a recipe is requested", func() {
+			recipe, err := c.Get(ctx, "lemon-drizzle")
+
+			Convey("Then the recipe is returned", func() {
+				So(err, ShouldBeNil)
+				So(recipe, ShouldResemble, &models.Recipe{ID: "lemon-drizzle", Title: "Lemon Drizzle"})
+			})
+
+			Convey("Then the request is authenticated", func() {
+				So(req.Method, ShouldEqual, http.MethodGet)
+				So(req.URL.Path, ShouldEqual, "/recipes/lemon-drizzle")
+				So(req.Header.Get("Authorization"), ShouldEqual, "Bearer secret")
+			})
+		})
+	})
+
+	Convey("Given the API responds with a list of errors", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, response := models.CreateErrorResponse(errs.ErrRecipeNotFound)
+			response.RequestID = "abc123"
+			writeJSON(w, http.StatusNotFound, response)
+		}))
+		defer server.Close()
+
+		c := newClient(server.URL)
+
+		Convey("When a recipe is requested", func() {
+			_, err := c.Get(ctx, "missing")
+
+			Convey("Then the errors are returned", func() {
+				var apiErr *client.Error
+				So(errors.As(err, &apiErr), ShouldBeTrue)
+				So(apiErr.StatusCode, ShouldEqual, http.StatusNotFound)
+				So(apiErr.RequestID, ShouldEqual, "abc123")
+				So(apiErr.Codes(), ShouldResemble, []string{"recipe_not_found"})
+				So(apiErr.Error(), ShouldEqual, "food recipes api responded with status 404: recipe not found")
+			})
+
+			Convey("Then the errors are matched by the API errors", func() {
+				So(errors.Is(err, errs.ErrRecipeNotFound), ShouldBeTrue)
+				So(errors.Is(err, errs.ErrHouseholdNotFound), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given the API responds with a server error before succeeding", t, func() {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&attempts, 1) < 3 {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+				return
+			}
+			writeJSON(w, http.StatusOK, models.Recipe{ID: "lemon-drizzle"})
+		}))
+		defer server.Close()
+
+		Convey("When a recipe is requested", func() {
+			recipe, err := newClient(server.URL).Get(ctx, "lemon-drizzle")
+
+			Convey("Then the request is retried until it succeeds", func() {
+				So(err, ShouldBeNil)
+				So(recipe.ID, ShouldEqual, "lemon-drizzle")
+				So(atomic.LoadInt32(&attempts), ShouldEqual, 3)
+			})
+		})
+
+		Convey("When a recipe is requested without retries", func() {
+			_, err := newClient(server.URL, client.WithRetries(0, 0)).Get(ctx, "lemon-drizzle")
+
+			Convey("Then the server error is returned", func() {
+				var apiErr *client.Error
+				So(errors.As(err, &apiErr), ShouldBeTrue)
+				So(apiErr.StatusCode, ShouldEqual, http.StatusBadGateway)
+				So(apiErr.Error(), ShouldEqual, "food recipes api responded with status 502: bad gateway")
+				So(atomic.LoadInt32(&attempts), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestCreate(t *testing.T) {
+	Convey("Given the API responds with a server error", t, func() {
+		var attempts int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&attempts, 1)
+			writeJSON(w, http.StatusInternalServerError, models.ErrorResponse{})
+		}))
+		defer server.Close()
+
+		Convey("When a recipe is created", func() {
+			_, err := newClient(server.URL).Create(ctx, &models.Recipe{Title: "Lemon Drizzle"})
+
+			Convey("Then the request is not retried", func() {
+				So(err, ShouldNotBeNil)
+				So(atomic.LoadInt32(&attempts), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestPatch(t *testing.T) {
+	Convey("Given a list of operations built with the patch builder", t, func() {
+		var contentType string
+		var patches []patch.Patch
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			contentType = r.Header.Get("Content-Type")
+			b, _ := io.ReadAll(r.Body)
+			json.Unmarshal(b, &patches) //nolint:errcheck,gosec // test server
+			writeJSON(w, http.StatusOK, models.Recipe{ID: "lemon-drizzle", Notes: "Bake for 45 minutes"})
+		}))
+		defer server.Close()
+
+		operations := client.NewPatch().
+			Test("/title", "Lemon Drizzle").
+			Replace("/notes", "Bake for 45 minutes").
+			Remove("/tags/0").
+			Patches()
+
+		Convey("When the recipe is patched", func() {
+			recipe, err := newClient(server.URL).Patch(ctx, "lemon-drizzle", operations...)
+
+			Convey("Then the operations are sent as a JSON Patch", func() {
+				So(err, ShouldBeNil)
+				So(recipe.Notes, ShouldEqual, "Bake for 45 minutes")
+				So(contentType, ShouldEqual, patch.JSONPatchMediaType)
+				So(patches, ShouldResemble, []patch.Patch{
+					{Op: "test", Path: "/title", Value: "Lemon Drizzle"},
+					{Op: "replace", Path: "/notes", Value: "Bake for 45 minutes"},
+					{Op: "remove", Path: "/tags/0"},
+				})
+			})
+		})
+	})
+}
+
+func TestDelete(t *testing.T) {
+	Convey("Given the API responds with no content", t, func() {
+		var method string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		Convey("When a recipe is deleted", func() {
+			err := newClient(server.URL).Delete(ctx, "lemon-drizzle")
+
+			Convey("Then no error is returned", func() {
+				So(err, ShouldBeNil)
+				So(method, ShouldEqual, http.MethodDelete)
+			})
+		})
+	})
+}
+
+func TestRecipes(t *testing.T) {
+	Convey("Given the API has more recipes than fit on a page", t, func() {
+		var offsets []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			offsets = append(offsets, r.URL.Query().Get("offset"))
+			offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+			items := []models.Recipe{}
+			for i := offset; i < offset+2 && i < 5; i++ {
+				items = append(items, models.Recipe{ID: strconv.Itoa(i)})
+			}
+			writeJSON(w, http.StatusOK, models.Recipes{Count: len(items), Items: items, Limit: 2, Offset: offset, TotalCount: 5})
+		}))
+		defer server.Close()
+
+		Convey("When the recipes are iterated over", func() {
+			it := newClient(server.URL).Recipes(client.ListOptions{Limit: 2})
+
+			var ids []string
+			for it.Next(ctx) {
+				ids = append(ids, it.Recipe().ID)
+			}
+
+			Convey("Then every recipe is returned, requesting each page once", func() {
+				So(it.Err(), ShouldBeNil)
+				So(ids, ShouldResemble, []string{"0", "1", "2", "3", "4"})
+				So(offsets, ShouldResemble, []string{"", "2", "4"})
+			})
+		})
+	})
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+)
+
+// maxErrorBodySize limits how much of an unsuccessful response is read when decoding the error
+const maxErrorBodySize = 1 << 20
+
+// Error is returned for unsuccessful responses from the API
+type Error struct {
+	StatusCode int
+	RequestID  string
+	Errors     []*models.ErrorObject
+}
+
+// newError decodes the list of errors in an unsuccessful response, responses which are not a list of errors, such as
+// those from a proxy, are reported using the body of the response as the error
+func newError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil {
+		return e
+	}
+
+	var response models.ErrorResponse
+	if err = json.Unmarshal(b, &response); err == nil && len(response.Errors) > 0 {
+		e.RequestID = response.RequestID
+		e.Errors = response.Errors
+		return e
+	}
+
+	if message := strings.TrimSpace(string(b)); message != "" {
+		e.Errors = []*models.ErrorObject{{Error: message}}
+	}
+
+	return e
+}
+
+// Error returns the messages of each error in the response
+func (e *Error) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, errorObject := range e.Errors {
+		messages = append(messages, errorObject.Error)
+	}
+
+	if len(messages) == 0 {
+		messages = append(messages, http.StatusText(e.StatusCode))
+	}
+
+	return fmt.Sprintf("food recipes api responded with status %d: %s", e.StatusCode, strings.Join(messages, "; "))
+}
+
+// Codes returns the code of each error in the response
+func (e *Error) Codes() []string {
+	codes := make([]string, 0, len(e.Errors))
+	for _, errorObject := range e.Errors {
+		if errorObject.Code != "" {
+			codes = append(codes, errorObject.Code)
+		}
+	}
+
+	return codes
+}
+
+// Is reports whether any error in the response has the code of the target API error, so that responses can be
+// matched using the errors in the apierrors package, e.g. errors.Is(err, apierrors.ErrRecipeNotFound)
+func (e *Error) Is(target error) bool {
+	var apiErr *errs.Error
+	if !errors.As(target, &apiErr) {
+		return false
+	}
+
+	for _, code := range e.Codes() {
+		if code == apiErr.Code {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+
+	"github.com/nshumoogum/food-recipes/models"
+)
+
+// RecipeIterator iterates over every recipe visible to the caller, requesting each page as it is needed
+type RecipeIterator struct {
+	client *Client
+	opts   ListOptions
+	page   []models.Recipe
+	index  int
+	recipe models.Recipe
+	done   bool
+	err    error
+}
+
+// Recipes returns an iterator over the recipes starting from the offset, fetching pages of the limit
+//
+//	it := c.Recipes(ListOptions{Limit: 100})
+//	for it.Next(ctx) {
+//		recipe := it.Recipe()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+func (c *Client) Recipes(opts ListOptions) *RecipeIterator {
+	return &RecipeIterator{client: c, opts: opts}
+}
+
+// Next advances to the next recipe, returning false once there are no more recipes or an error occurred
+func (it *RecipeIterator) Next(ctx context.Context) bool {
+	if it.err != nil {
+		return false
+	}
+
+	if it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+
+		recipes, err := it.client.List(ctx, it.opts)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.page = recipes.Items
+		it.index = 0
+		it.opts.Offset = recipes.Offset + len(recipes.Items)
+		it.done = len(recipes.Items) == 0 || int64(it.opts.Offset) >= recipes.TotalCount
+
+		if len(it.page) == 0 {
+			return false
+		}
+	}
+
+	it.recipe = it.page[it.index]
+	it.index++
+
+	return true
+}
+
+// Recipe returns the current recipe
+func (it *RecipeIterator) Recipe() models.Recipe {
+	return it.recipe
+}
+
+// Err returns the error which stopped the iteration, if any
+func (it *RecipeIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,55 @@
+package client
+
+import "github.com/nshumoogum/food-recipes/patch"
+
+// PatchBuilder builds a list of JSON Patch operations to send with Client.Patch, e.g.
+//
+//	client.NewPatch().Test("/title", "Lemon Drizzle").Replace("/notes", "Bake for 45 minutes").Patches()
+type PatchBuilder struct {
+	patches []patch.Patch
+}
+
+// NewPatch creates an empty list of operations
+func NewPatch() *PatchBuilder {
+	return &PatchBuilder{}
+}
+
+// Add adds the value at the path
+func (b *PatchBuilder) Add(path string, value interface{}) *PatchBuilder {
+	return b.append(patch.Patch{Op: patch.OpAdd.String(), Path: path, Value: value})
+}
+
+// Remove removes the value at the path
+func (b *PatchBuilder) Remove(path string) *PatchBuilder {
+	return b.append(patch.Patch{Op: patch.OpRemove.String(), Path: path})
+}
+
+// Replace replaces the value at the path
+func (b *PatchBuilder) Replace(path string, value interface{}) *PatchBuilder {
+	return b.append(patch.Patch{Op: patch.OpReplace.String(), Path: path, Value: value})
+}
+
+// Move moves the value at the from path to the path
+func (b *PatchBuilder) Move(from, path string) *PatchBuilder {
+	return b.append(patch.Patch{Op: patch.OpMove.String(), From: from, Path: path})
+}
+
+// Copy copies the value at the from path to the path
+func (b *PatchBuilder) Copy(from, path string) *PatchBuilder {
+	return b.append(patch.Patch{Op: patch.OpCopy.String(), From: from, Path: path})
+}
+
+// Test checks the value at the path is equal to the value, no operations are applied if it is not
+func (b *PatchBuilder) Test(path string, value interface{}) *PatchBuilder {
+	return b.append(patch.Patch{Op: patch.OpTest.String(), Path: path, Value: value})
+}
+
+// Patches returns the operations in the order they were added
+func (b *PatchBuilder) Patches() []patch.Patch {
+	return append([]patch.Patch(nil), b.patches...)
+}
+
+func (b *PatchBuilder) append(p patch.Patch) *PatchBuilder {
+	b.patches = append(b.patches, p)
+	return b
+}
@@ -0,0 +1,74 @@
+// import imports recipes from a CSV file or URL into the database and writes a report of the change made to each
+// recipe as JSON to stdout. The source and mode default to GOOGLE_SHEET_URL and IMPORT_MODE.
+//
+// Run with -dry-run to report the changes without writing recipes to the database, the summary of a dry run is still
+// stored alongside the summaries of other runs.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/nshumoogum/food-recipes/config"
+	"github.com/nshumoogum/food-recipes/importer"
+	"github.com/nshumoogum/food-recipes/store"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	log.Namespace = "food-recipes-import"
+	ctx := context.Background()
+
+	cfg, err := config.Get()
+	if err != nil {
+		log.Fatal(ctx, "failed to retrieve configuration", err)
+		os.Exit(1)
+	}
+
+	source := flag.String("source", cfg.GSURL, "CSV file or URL to import recipes from")
+	mode := flag.String("mode", cfg.ImportMode, "create-only, update-existing or replace-all")
+	dryRun := flag.Bool("dry-run", false, "report changes without storing recipes")
+	flag.Parse()
+
+	if err = run(ctx, cfg, *source, importer.Options{Mode: *mode, DryRun: *dryRun}); err != nil {
+		log.Fatal(ctx, "import failed", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Configuration, source string, opts importer.Options) error {
+	result, err := importer.Import(ctx, source, &http.Client{Timeout: cfg.DownloadTimeout})
+	if err != nil {
+		log.Error(ctx, "failed to read recipes", err, log.Data{"source": source})
+		return err
+	}
+
+	mongoCTX, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(mongoCTX, options.Client().ApplyURI(
+		cfg.MongoConfig.BindAddr+"/"+cfg.MongoConfig.Database+"?retryWrites=true&w=majority",
+	))
+	if err != nil {
+		log.Error(ctx, "failed to create mongo client", err)
+		return err
+	}
+	defer client.Disconnect(ctx) //nolint:errcheck // nothing to do if disconnect fails on exit
+
+	report, err := importer.Run(ctx, store.NewMongo(client, cfg.MongoConfig.Database, cfg.MongoConfig.Collection), result, opts)
+	if err != nil {
+		log.Error(ctx, "failed to store imported recipes", err)
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/client"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/slug"
+)
+
+// parseFlags parses the arguments of a command, leaving the command to print its usage if they are invalid
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	flags.Usage = func() {}
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	return nil
+}
+
+// idArg returns the id of the recipe a command was run against
+func idArg(name string, args []string) (string, error) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	if err := parseFlags(flags, args); err != nil {
+		return "", err
+	}
+
+	if flags.NArg() != 1 {
+		return "", errUsage
+	}
+
+	return flags.Arg(0), nil
+}
+
+func list(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	limit := flags.Int("limit", 0, "maximum number of recipes to list")
+	offset := flags.Int("offset", 0, "number of recipes to skip")
+	if err := parseFlags(flags, args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	recipes, err := c.List(ctx, client.ListOptions{Limit: *limit, Offset: *offset})
+	if err != nil {
+		return err
+	}
+
+	if err = writeTable(stdout, recipes.Items); err != nil {
+		return err
+	}
+
+	if recipes.Count > 0 {
+		_, err = fmt.Fprintf(stdout, "\n%d-%d of %d recipes\n", recipes.Offset+1, recipes.Offset+recipes.Count, recipes.TotalCount)
+	}
+
+	return err
+}
+
+func search(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("search", flag.ContinueOnError)
+	tag := flags.String("tag", "", "only include recipes with the tag")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	terms := make([]string, 0, flags.NArg())
+	for _, term := range flags.Args() {
+		terms = append(terms, strings.ToLower(term))
+	}
+
+	matches := []models.Recipe{}
+
+	it := c.Recipes(client.ListOptions{})
+	for it.Next(ctx) {
+		if recipe := it.Recipe(); matchesSearch(recipe, *tag, terms) {
+			matches = append(matches, recipe)
+		}
+	}
+
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	return writeTable(stdout, matches)
+}
+
+// matchesSearch reports whether the recipe has the tag, if any, and every term appears in its title, tags or the
+// items in its ingredients
+func matchesSearch(recipe models.Recipe, tag string, terms []string) bool {
+	text := []string{strings.ToLower(recipe.Title)}
+	hasTag := tag == ""
+
+	for _, t := range recipe.Tags {
+		text = append(text, strings.ToLower(t))
+		hasTag = hasTag || strings.EqualFold(t, tag)
+	}
+
+	for _, ingredient := range append(recipe.Ingredients, recipe.Extras...) {
+		text = append(text, strings.ToLower(ingredient.Item))
+	}
+
+	if !hasTag {
+		return false
+	}
+
+	searchable := strings.Join(text, "\n")
+	for _, term := range terms {
+		if !strings.Contains(searchable, term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func show(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	id, err := idArg("show", args)
+	if err != nil {
+		return err
+	}
+
+	recipe, err := c.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeRecipe(stdout, recipe)
+}
+
+func create(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	file := flags.String("f", "", "JSON or YAML file containing the recipe, - to read from stdin")
+	if err := parseFlags(flags, args); err != nil || *file == "" || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	var recipe models.Recipe
+	if err := decodeFile(*file, &recipe); err != nil {
+		return err
+	}
+
+	created, err := c.Create(ctx, &recipe)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "created recipe %s\n", created.ID)
+	return err
+}
+
+func remove(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	id, err := idArg("delete", args)
+	if err != nil {
+		return err
+	}
+
+	if err = c.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(stdout, "deleted recipe %s\n", id)
+	return err
+}
+
+func importRecipes(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	file := flags.String("f", "", "JSON or YAML file containing a list of recipes, - to read from stdin")
+	replace := flags.Bool("replace", false, "replace recipes which already exist instead of skipping them")
+	if err := parseFlags(flags, args); err != nil || *file == "" || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	var recipes []models.Recipe
+	if err := decodeFile(*file, &recipes); err != nil {
+		return err
+	}
+
+	var created, replaced, skipped, failed int
+
+	for i := range recipes {
+		recipe := &recipes[i]
+		id := slug.Make(recipe.Title)
+
+		_, err := c.Create(ctx, recipe)
+		switch {
+		case err == nil:
+			created++
+			fmt.Fprintf(stdout, "created recipe %s\n", id)
+		case errors.Is(err, errs.ErrRecipeAlreadyExists) && *replace:
+			if _, err = c.Replace(ctx, id, recipe.ToUpdateRecipe()); err != nil {
+				failed++
+				fmt.Fprintf(stdout, "failed to replace recipe %s: %v\n", id, err)
+				continue
+			}
+			replaced++
+			fmt.Fprintf(stdout, "replaced recipe %s\n", id)
+		case errors.Is(err, errs.ErrRecipeAlreadyExists):
+			skipped++
+			fmt.Fprintf(stdout, "skipped recipe %s, it already exists\n", id)
+		default:
+			failed++
+			fmt.Fprintf(stdout, "failed to create recipe %q: %v\n", recipe.Title, err)
+		}
+	}
+
+	fmt.Fprintf(stdout, "\n%d created, %d replaced, %d skipped, %d failed\n", created, replaced, skipped, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d recipes failed to import", failed, len(recipes))
+	}
+
+	return nil
+}
+
+func export(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "", "json or yaml, defaults to the extension of the output file or json")
+	output := flags.String("o", "", "file to write the recipes to, defaults to stdout")
+	if err := parseFlags(flags, args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	if *format == "" {
+		*format = formatOf(*output)
+	}
+
+	if *format != formatJSON && *format != formatYAML {
+		return errUsage
+	}
+
+	recipes := []models.Recipe{}
+
+	it := c.Recipes(client.ListOptions{})
+	for it.Next(ctx) {
+		recipes = append(recipes, it.Recipe())
+	}
+
+	if err := it.Err(); err != nil {
+		return err
+	}
+
+	return encodeFile(*output, *format, recipes, stdout)
+}
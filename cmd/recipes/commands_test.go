@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIDArg(t *testing.T) {
+	tables := []struct {
+		givenTitle  string
+		args        []string
+		expectedID  string
+		expectedErr error
+	}{
+		{"Given an id", []string{"pancakes"}, "pancakes", nil},
+		{"Given no id", []string{}, "", errUsage},
+		{"Given more than one id", []string{"pancakes", "toast"}, "", errUsage},
+		{"Given an unknown flag", []string{"-f", "pancakes"}, "", errUsage},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			id, err := idArg("show", table.args)
+
+			Convey("Then the id or a usage error is returned", func() {
+				So(id, ShouldEqual, table.expectedID)
+				So(err, ShouldEqual, table.expectedErr)
+			})
+		})
+	}
+}
+
+func TestCommandArguments(t *testing.T) {
+	tables := []struct {
+		givenTitle string
+		name       string
+		args       []string
+	}{
+		{"Given list with an argument", "list", []string{"pancakes"}},
+		{"Given list with a limit which is not a number", "list", []string{"-limit", "ten"}},
+		{"Given search with an unknown flag", "search", []string{"-title", "pancakes"}},
+		{"Given create without a file", "create", []string{}},
+		{"Given create with an argument", "create", []string{"-f", "recipe.json", "toast"}},
+		{"Given import without a file", "import", []string{"-replace"}},
+		{"Given export with an unknown format", "export", []string{"-format", "csv"}},
+		{"Given export to a file with an unknown extension and an argument", "export", []string{"-o", "recipes.csv", "all"}},
+		{"Given edit without an id", "edit", []string{}},
+		{"Given delete with two ids", "delete", []string{"pancakes", "toast"}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			var stdout bytes.Buffer
+			err := commands[table.name].run(context.Background(), nil, table.args, &stdout)
+
+			Convey("Then the usage of the command is printed without making a request", func() {
+				So(errors.Is(err, errUsage), ShouldBeTrue)
+				So(stdout.Len(), ShouldEqual, 0)
+			})
+		})
+	}
+}
+
+func TestMatchesSearch(t *testing.T) {
+	recipe := models.Recipe{
+		Title:       "Pancakes",
+		Tags:        []string{"Breakfast"},
+		Ingredients: []models.Ingredient{{Item: "flour"}},
+		Extras:      []models.Ingredient{{Item: "maple syrup"}},
+	}
+
+	tables := []struct {
+		givenTitle string
+		tag        string
+		terms      []string
+		expected   bool
+	}{
+		{"Given no tag or terms", "", nil, true},
+		{"Given a term in the title", "", []string{"pancake"}, true},
+		{"Given terms in an ingredient and an extra", "", []string{"flour", "syrup"}, true},
+		{"Given a term which is missing", "", []string{"flour", "eggs"}, false},
+		{"Given a tag in a different case", "breakfast", nil, true},
+		{"Given a tag which is missing", "dinner", []string{"flour"}, false},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			Convey("Then whether the recipe matches is returned", func() {
+				So(matchesSearch(recipe, table.tag, table.terms), ShouldEqual, table.expected)
+			})
+		})
+	}
+}
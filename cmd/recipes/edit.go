@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/nshumoogum/food-recipes/client"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+)
+
+const defaultEditor = "vi"
+
+// edit opens the members of a recipe which can be changed in the user's editor as JSON and applies the changes made
+// as a JSON Patch, so changes made to other members of the recipe in the meantime are kept. The title is tested as
+// part of the patch, so the patch is rejected if the recipe was replaced while it was being edited.
+func edit(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error {
+	id, err := idArg("edit", args)
+	if err != nil {
+		return err
+	}
+
+	recipe, err := c.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	original, err := json.MarshalIndent(recipe.ToUpdateRecipe(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp("", "recipe-"+recipe.ID+"-*.json")
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(original, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = openEditor(ctx, file.Name()); err != nil {
+		return err
+	}
+
+	var edited models.UpdateRecipe
+	if err = decodeFile(file.Name(), &edited); err != nil {
+		return fmt.Errorf("%w, changes kept in %s", err, file.Name())
+	}
+
+	patches, err := editPatches(recipe, original, &edited)
+	if err != nil {
+		return err
+	}
+
+	if len(patches) == 0 {
+		os.Remove(file.Name()) //nolint:errcheck,gosec // the file is no longer needed
+		_, err = fmt.Fprintln(stdout, "no changes made")
+		return err
+	}
+
+	if recipe, err = c.Patch(ctx, recipe.ID, patches...); err != nil {
+		return fmt.Errorf("%w, changes kept in %s", err, file.Name())
+	}
+
+	os.Remove(file.Name()) //nolint:errcheck,gosec // the file is no longer needed
+
+	return writeRecipe(stdout, recipe)
+}
+
+// editPatches returns the JSON Patch changing the original members of the recipe into the edited members, preceded by
+// a test of the title, or no patches if nothing was changed
+func editPatches(recipe *models.Recipe, original []byte, edited *models.UpdateRecipe) ([]patch.Patch, error) {
+	modified, err := json.Marshal(edited)
+	if err != nil {
+		return nil, err
+	}
+
+	patches, err := patch.Diff(original, modified)
+	if err != nil || len(patches) == 0 {
+		return nil, err
+	}
+
+	return append([]patch.Patch{{Op: patch.OpTest.String(), Path: "/title", Value: recipe.Title}}, patches...), nil
+}
+
+// openEditor opens the file in the editor set by $VISUAL or $EDITOR and waits for it to be closed
+func openEditor(ctx context.Context, file string) error {
+	editor := editorCommand()
+
+	cmd := exec.CommandContext(ctx, editor[0], append(editor[1:], file)...) //nolint:gosec // the editor is chosen by the user
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", strings.Join(editor, " "), err)
+	}
+
+	return nil
+}
+
+// editorCommand returns the editor set by $VISUAL or $EDITOR, which may include arguments, e.g. "code --wait", split
+// into its fields. The default editor is returned if neither is set to more than whitespace.
+func editorCommand() []string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(variable)); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	return []string{defaultEditor}
+}
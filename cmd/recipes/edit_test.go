@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/client"
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEditorCommand(t *testing.T) {
+	tables := []struct {
+		givenTitle string
+		visual     string
+		editor     string
+		expected   []string
+	}{
+		{"Given no editor", "", "", []string{defaultEditor}},
+		{"Given an editor with arguments", "", "code --wait", []string{"code", "--wait"}},
+		{"Given a visual editor and an editor", "emacs", "nano", []string{"emacs"}},
+		{"Given a visual editor of only whitespace", "  ", "nano", []string{"nano"}},
+		{"Given editors of only whitespace", " ", "\t", []string{defaultEditor}},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			t.Setenv("VISUAL", table.visual)
+			t.Setenv("EDITOR", table.editor)
+
+			Convey("Then the editor to run is returned", func() {
+				So(editorCommand(), ShouldResemble, table.expected)
+			})
+		})
+	}
+}
+
+func TestEditPatches(t *testing.T) {
+	original, err := json.MarshalIndent(pancakes.ToUpdateRecipe(), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Given a recipe which was edited", t, func() {
+		edited := pancakes.ToUpdateRecipe()
+		edited.CookTime = 25
+		edited.Tags = append(edited.Tags, "quick")
+
+		patches, err := editPatches(&pancakes, original, edited)
+
+		Convey("Then the changes are preceded by a test of the title", func() {
+			So(err, ShouldBeNil)
+			So(patches, ShouldResemble, []patch.Patch{
+				{Op: "test", Path: "/title", Value: "Pancakes"},
+				{Op: "replace", Path: "/cook_time", Value: float64(25)},
+				{Op: "replace", Path: "/tags", Value: []interface{}{"breakfast", "sweet", "quick"}},
+			})
+		})
+	})
+
+	Convey("Given a recipe which was not changed", t, func() {
+		patches, err := editPatches(&pancakes, original, pancakes.ToUpdateRecipe())
+
+		Convey("Then no patches are returned", func() {
+			So(err, ShouldBeNil)
+			So(patches, ShouldBeEmpty)
+		})
+	})
+}
+
+func TestEdit(t *testing.T) {
+	if _, err := exec.LookPath("sed"); err != nil {
+		t.Skip("sed is needed to edit the recipe")
+	}
+
+	Convey("Given a recipe edited by an editor", t, func() {
+		var patchBody []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodPatch {
+				patchBody, _ = io.ReadAll(req.Body)
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(pancakes) //nolint:errcheck,gosec // test server
+		}))
+		defer server.Close()
+
+		c, err := client.New(server.URL)
+		So(err, ShouldBeNil)
+
+		t.Setenv("VISUAL", "")
+		t.Setenv("EDITOR", "sed -i s/Baking/Brunch/")
+
+		var stdout bytes.Buffer
+		err = edit(context.Background(), c, []string{"pancakes"}, &stdout)
+
+		Convey("Then the change is sent as a JSON Patch testing the title", func() {
+			So(err, ShouldBeNil)
+
+			var patches []patch.Patch
+			So(json.Unmarshal(patchBody, &patches), ShouldBeNil)
+			So(patches, ShouldResemble, []patch.Patch{
+				{Op: "test", Path: "/title", Value: "Pancakes"},
+				{Op: "replace", Path: "/location/cook_book", Value: "Brunch"},
+			})
+			So(stdout.String(), ShouldStartWith, "Pancakes (pancakes)\n")
+		})
+	})
+}
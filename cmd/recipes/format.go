@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/nshumoogum/food-recipes/models"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	formatJSON = "json"
+	formatYAML = "yaml"
+)
+
+// formatOf returns the format of a file from its extension, files without a YAML extension are treated as JSON
+func formatOf(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatJSON
+	}
+}
+
+// decodeFile decodes a JSON or YAML file into v using the json names of its fields, - reads from stdin. Unknown fields
+// are rejected so mistyped fields are not silently dropped.
+func decodeFile(file string, v interface{}) error {
+	var b []byte
+	var err error
+	if file == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(file) //nolint:gosec // the file is chosen by the user running the command
+	}
+	if err != nil {
+		return err
+	}
+
+	if formatOf(file) == formatYAML || file == "-" {
+		var doc interface{}
+		if err = yaml.Unmarshal(b, &doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+
+		if b, err = json.Marshal(doc); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", file, err)
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err = decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	return nil
+}
+
+// encodeFile writes v to the file in the format, or to stdout if no file is given
+func encodeFile(file, format string, v interface{}, stdout io.Writer) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if format == formatYAML {
+		var doc interface{}
+		if err = yaml.Unmarshal(b, &doc); err != nil {
+			return err
+		}
+
+		if b, err = yaml.Marshal(doc); err != nil {
+			return err
+		}
+	} else {
+		b = append(b, '\n')
+	}
+
+	if file == "" {
+		_, err = stdout.Write(b)
+		return err
+	}
+
+	return os.WriteFile(file, b, 0o600)
+}
+
+// writeTable writes a summary of each recipe as a row of a table
+func writeTable(stdout io.Writer, recipes []models.Recipe) error {
+	if len(recipes) == 0 {
+		_, err := fmt.Fprintln(stdout, "no recipes found")
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTITLE\tDIFFICULTY\tCOOK TIME\tSERVES\tTAGS")
+
+	for i := range recipes {
+		recipe := &recipes[i]
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			recipe.ID, recipe.Title, recipe.Difficulty, minutes(recipe.CookTime), recipe.PortionSize, strings.Join(recipe.Tags, ", "))
+	}
+
+	return w.Flush()
+}
+
+// writeRecipe writes a recipe formatted for reading
+func writeRecipe(stdout io.Writer, recipe *models.Recipe) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s (%s)\n\n", recipe.Title, recipe.ID)
+
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Difficulty:\t%s\n", recipe.Difficulty)
+	fmt.Fprintf(w, "Cook time:\t%s\n", minutes(recipe.CookTime))
+	fmt.Fprintf(w, "Serves:\t%d\n", recipe.PortionSize)
+	if recipe.Favourite {
+		fmt.Fprintln(w, "Favourite:\tyes")
+	}
+	if len(recipe.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:\t%s\n", strings.Join(recipe.Tags, ", "))
+	}
+	if location := formatLocation(recipe.Location); location != "" {
+		fmt.Fprintf(w, "Location:\t%s\n", location)
+	}
+	fmt.Fprintf(w, "Visibility:\t%s\n", recipe.Visibility)
+	if recipe.Owner != "" {
+		fmt.Fprintf(w, "Owner:\t%s\n", recipe.Owner)
+	}
+	if recipe.Household != "" {
+		fmt.Fprintf(w, "Household:\t%s\n", recipe.Household)
+	}
+	w.Flush() //nolint:errcheck,gosec // writing to a strings.Builder does not fail
+
+	writeIngredients(&b, "Ingredients", recipe.Ingredients)
+	writeIngredients(&b, "Extra ingredients", recipe.Extras)
+
+	if recipe.Notes != "" {
+		fmt.Fprintf(&b, "\nNotes\n  %s\n", strings.ReplaceAll(recipe.Notes, "\n", "\n  "))
+	}
+
+	_, err := io.WriteString(stdout, b.String())
+	return err
+}
+
+func writeIngredients(b *strings.Builder, heading string, ingredients []models.Ingredient) {
+	if len(ingredients) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "\n%s\n", heading)
+	for _, ingredient := range ingredients {
+		amount := strconv.Itoa(ingredient.Quantity)
+		if ingredient.Unit != "" {
+			amount += " " + ingredient.Unit
+		}
+		fmt.Fprintf(b, "  - %s %s\n", amount, ingredient.Item)
+	}
+}
+
+func formatLocation(location models.Location) string {
+	parts := []string{}
+	if location.CookBook != "" {
+		parts = append(parts, location.CookBook)
+	}
+	if location.Page > 0 {
+		parts = append(parts, "page "+strconv.Itoa(location.Page))
+	}
+	if location.Link != "" {
+		parts = append(parts, location.Link)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func minutes(cookTime int) string {
+	if cookTime == 0 {
+		return "-"
+	}
+	return strconv.Itoa(cookTime) + " mins"
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var pancakes = models.Recipe{
+	ID:          "pancakes",
+	CookTime:    20,
+	Difficulty:  "easy",
+	Ingredients: []models.Ingredient{{Item: "flour", Quantity: 200, Unit: "g"}, {Item: "eggs", Quantity: 2}},
+	Location:    models.Location{CookBook: "Baking", Page: 12},
+	PortionSize: 4,
+	Tags:        []string{"breakfast", "sweet"},
+	Title:       "Pancakes",
+	Visibility:  models.VisibilityPublic,
+}
+
+func TestFormatOf(t *testing.T) {
+	tables := []struct {
+		file     string
+		expected string
+	}{
+		{"recipes.yaml", formatYAML},
+		{"recipes.YML", formatYAML},
+		{"recipes.json", formatJSON},
+		{"recipes", formatJSON},
+		{"", formatJSON},
+	}
+
+	for _, table := range tables {
+		Convey("Given the file "+table.file, t, func() {
+			Convey("Then the format is taken from its extension", func() {
+				So(formatOf(table.file), ShouldEqual, table.expected)
+			})
+		})
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	Convey("Given a list of recipes", t, func() {
+		quick := models.Recipe{ID: "toast", Title: "Toast", Difficulty: "easy", PortionSize: 1}
+		var b bytes.Buffer
+
+		So(writeTable(&b, []models.Recipe{pancakes, quick}), ShouldBeNil)
+
+		Convey("Then each recipe is written as an aligned row of the table", func() {
+			So(b.String(), ShouldEqual, ""+
+				"ID        TITLE     DIFFICULTY  COOK TIME  SERVES  TAGS\n"+
+				"pancakes  Pancakes  easy        20 mins    4       breakfast, sweet\n"+
+				"toast     Toast     easy        -          1       \n")
+		})
+	})
+
+	Convey("Given no recipes", t, func() {
+		var b bytes.Buffer
+
+		So(writeTable(&b, nil), ShouldBeNil)
+
+		Convey("Then no recipes are reported in place of a table", func() {
+			So(b.String(), ShouldEqual, "no recipes found\n")
+		})
+	})
+}
+
+func TestWriteRecipe(t *testing.T) {
+	Convey("Given a recipe", t, func() {
+		recipe := pancakes
+		recipe.Notes = "rest the batter\nfor an hour"
+		var b bytes.Buffer
+
+		So(writeRecipe(&b, &recipe), ShouldBeNil)
+
+		Convey("Then the recipe is written for reading", func() {
+			So(b.String(), ShouldEqual, ""+
+				"Pancakes (pancakes)\n\n"+
+				"Difficulty:  easy\n"+
+				"Cook time:   20 mins\n"+
+				"Serves:      4\n"+
+				"Tags:        breakfast, sweet\n"+
+				"Location:    Baking, page 12\n"+
+				"Visibility:  public\n"+
+				"\nIngredients\n"+
+				"  - 200 g flour\n"+
+				"  - 2 eggs\n"+
+				"\nNotes\n"+
+				"  rest the batter\n"+
+				"  for an hour\n")
+		})
+	})
+}
+
+func TestEncodeFile(t *testing.T) {
+	recipes := []models.Recipe{{ID: "toast", Title: "Toast", Difficulty: "easy", PortionSize: 1, Tags: []string{"quick"}}}
+
+	Convey("Given recipes encoded as YAML", t, func() {
+		var b bytes.Buffer
+
+		So(encodeFile("", formatYAML, recipes, &b), ShouldBeNil)
+
+		Convey("Then the recipes are written using the json names of their fields", func() {
+			So(b.String(), ShouldContainSubstring, "- cook_time: 0\n")
+			So(b.String(), ShouldContainSubstring, "  portion_size: 1\n")
+			So(b.String(), ShouldContainSubstring, "  tags:\n    - quick\n")
+		})
+
+		Convey("Then the recipes are decoded from the YAML unchanged", func() {
+			file := filepath.Join(t.TempDir(), "recipes.yaml")
+			So(os.WriteFile(file, b.Bytes(), 0o600), ShouldBeNil)
+
+			var decoded []models.Recipe
+			So(decodeFile(file, &decoded), ShouldBeNil)
+			So(decoded, ShouldResemble, recipes)
+		})
+	})
+
+	Convey("Given recipes encoded as JSON to a file", t, func() {
+		file := filepath.Join(t.TempDir(), "recipes.json")
+		var b bytes.Buffer
+
+		So(encodeFile(file, formatJSON, recipes, &b), ShouldBeNil)
+
+		Convey("Then the recipes are written to the file as indented JSON, not to stdout", func() {
+			written, err := os.ReadFile(file) //nolint:gosec // the file is created by the test
+			So(err, ShouldBeNil)
+			So(string(written), ShouldStartWith, "[\n  {\n    \"id\": \"toast\",\n")
+			So(b.Len(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestDecodeFile(t *testing.T) {
+	Convey("Given a YAML file with a field which is not part of a recipe", t, func() {
+		file := filepath.Join(t.TempDir(), "recipe.yml")
+		So(os.WriteFile(file, []byte("title: Toast\nserves: 2\n"), 0o600), ShouldBeNil)
+
+		Convey("Then the file is rejected", func() {
+			var recipe models.Recipe
+			err := decodeFile(file, &recipe)
+
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, `unknown field "serves"`)
+		})
+	})
+}
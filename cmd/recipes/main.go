@@ -0,0 +1,115 @@
+// recipes is a command line tool for managing recipes through the food recipes API. Requests are authenticated with
+// an API key, the same key accepted by the API in the Authorization header, read from the -api-key flag or the
+// RECIPES_API_KEY environment variable.
+//
+// Usage:
+//
+//	recipes [-url url] [-api-key key] <command> [arguments]
+//
+// Run recipes -h for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/nshumoogum/food-recipes/client"
+)
+
+const defaultURL = "http://localhost:30000"
+
+// command runs a subcommand with the arguments which follow its name, writing its output to stdout
+type command struct {
+	usage       string
+	description string
+	run         func(ctx context.Context, c *client.Client, args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"list":   {"list [-limit n] [-offset n]", "list a page of recipes", list},
+	"search": {"search [-tag tag] [terms...]", "search every recipe by title, tag and ingredient", search},
+	"show":   {"show <id>", "show a recipe", show},
+	"create": {"create -f file", "create a recipe from a JSON or YAML file", create},
+	"edit":   {"edit <id>", "edit a recipe in $EDITOR, applying the changes as a JSON Patch", edit},
+	"delete": {"delete <id>", "delete a recipe", remove},
+	"import": {"import [-replace] -f file", "create the recipes in a JSON or YAML file", importRecipes},
+	"export": {"export [-format json|yaml] [-o file]", "write every recipe to a JSON or YAML file", export},
+}
+
+// errUsage is returned by commands given invalid arguments, the usage of the command is printed in place of the error
+var errUsage = errors.New("invalid arguments")
+
+func main() {
+	ctx := context.Background()
+
+	flags := flag.NewFlagSet("recipes", flag.ExitOnError)
+	url := flags.String("url", envOrDefault("RECIPES_API_URL", defaultURL), "url of the food recipes API [RECIPES_API_URL]")
+	apiKey := flags.String("api-key", os.Getenv("RECIPES_API_KEY"), "API key to authenticate with [RECIPES_API_KEY]")
+	flags.Usage = func() { usage(flags) }
+	flags.Parse(os.Args[1:]) //nolint:errcheck,gosec // the flag set exits on error
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	name := flags.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "recipes: unknown command %q\n", name)
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	opts := []client.Option{}
+	if *apiKey != "" {
+		opts = append(opts, client.WithBearerToken(*apiKey))
+	}
+
+	c, err := client.New(*url, opts...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "recipes: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err = cmd.run(ctx, c, flags.Args()[1:], os.Stdout); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprintf(os.Stderr, "usage: recipes %s\n", cmd.usage)
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "recipes %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "usage: recipes [-url url] [-api-key key] <command> [arguments]")
+	fmt.Fprintln(out, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-40s %s\n", commands[name].usage, commands[name].description)
+	}
+
+	fmt.Fprintln(out, "\nflags:")
+	flags.PrintDefaults()
+}
+
+func envOrDefault(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
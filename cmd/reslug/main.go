@@ -0,0 +1,107 @@
+// reslug is a one-off migration which regenerates the identifier of every stored recipe from its title
+// using the slug package. Recipes whose identifier changes are re-inserted under the new identifier and
+// the previous identifier is kept as an alias, so existing links continue to resolve. Recipes and aliases are read
+// from and written to the database and collection configured for the API. A run which is interrupted can be
+// repeated to complete the renames it started.
+//
+// Run with -dry-run to report the changes without writing to the database.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/config"
+	"github.com/nshumoogum/food-recipes/slug"
+	"github.com/nshumoogum/food-recipes/store"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	log.Namespace = "food-recipes-reslug"
+	ctx := context.Background()
+
+	dryRun := flag.Bool("dry-run", false, "report changes without updating recipes")
+	flag.Parse()
+
+	if err := run(ctx, *dryRun); err != nil {
+		log.Fatal(ctx, "reslug migration failed", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, dryRun bool) error {
+	cfg, err := config.Get()
+	if err != nil {
+		log.Error(ctx, "failed to retrieve configuration", err)
+		return err
+	}
+
+	mongoCTX, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(mongoCTX, options.Client().ApplyURI(
+		cfg.MongoConfig.BindAddr+"/"+cfg.MongoConfig.Database+"?retryWrites=true&w=majority",
+	))
+	if err != nil {
+		log.Error(ctx, "failed to create mongo client", err)
+		return err
+	}
+	defer client.Disconnect(ctx) //nolint:errcheck // nothing to do if disconnect fails on exit
+
+	dataStore := store.NewMongo(client, cfg.MongoConfig.Database, cfg.MongoConfig.Collection)
+
+	recipes, err := dataStore.GetAllRecipes(ctx)
+	if err != nil {
+		log.Error(ctx, "failed to retrieve recipes", err)
+		return err
+	}
+
+	var renamed, skipped int
+
+	for i := range recipes {
+		recipe := &recipes[i]
+		newID := slug.Make(recipe.Title)
+		logData := log.Data{"id": recipe.ID, "new_id": newID, "title": recipe.Title}
+
+		if newID == recipe.ID {
+			continue
+		}
+
+		if newID == "" {
+			log.Warn(ctx, "title cannot be converted to an identifier, skipping recipe", logData)
+			skipped++
+			continue
+		}
+
+		if dryRun {
+			log.Info(ctx, "recipe would be renamed", logData)
+			renamed++
+			continue
+		}
+
+		if err = dataStore.RenameRecipe(ctx, recipe, newID); err != nil {
+			if errors.Is(err, errs.ErrRecipeAlreadyExists) {
+				log.Warn(ctx, "a different recipe already exists with the new identifier, skipping recipe", logData)
+				skipped++
+				continue
+			}
+
+			log.Error(ctx, "failed to rename recipe", err, logData)
+			return err
+		}
+
+		log.Info(ctx, "recipe renamed", logData)
+		renamed++
+	}
+
+	log.Info(ctx, "reslug migration complete", log.Data{"renamed": renamed, "skipped": skipped, "dry_run": dryRun})
+
+	return nil
+}
@@ -8,14 +8,71 @@ import (
 
 // Configuration structure which hold information for configuring the import API
 type Configuration struct {
-	BindAddr                string        `envconfig:"BIND_ADDR"`
-	ConnectionString        string        `envconfig:"CONNECTION_STRING"          json:"-"`
+	APIKeys                 []string `envconfig:"API_KEYS"                   json:"-"`
+	BindAddr                string   `envconfig:"BIND_ADDR"`
+	CORSConfig              CORSConfig
 	DefaultMaxResults       int           `envconfig:"DEFAULT_MAX_RESULTS"`
+	DrainPeriod             time.Duration `envconfig:"DRAIN_PERIOD"`
 	DownloadData            bool          `envconfig:"DOWNLOAD_DATA"`
 	DownloadTimeout         time.Duration `envconfig:"DOWNLOAD_TIMEOUT"`
 	GSURL                   string        `envconfig:"GOOGLE_SHEET_URL"           json:"-"`
 	GracefulShutdownTimeout time.Duration `envconfig:"GRACEFUL_SHUTDOWN_TIMEOUT"`
+	ImportMode              string        `envconfig:"IMPORT_MODE"`
+	JWTConfig               JWTConfig
+	MaxBodyBytes            int64 `envconfig:"MAX_BODY_BYTES"`
 	MongoConfig             MongoConfig
+	RateLimitConfig         RateLimitConfig
+	TLSConfig               TLSConfig
+	TracingConfig           TracingConfig
+}
+
+// CORSConfig contains the config for cross-origin requests from browsers, cross-origin requests are not allowed if no
+// origins are configured
+type CORSConfig struct {
+	AllowCredentials bool          `envconfig:"CORS_ALLOW_CREDENTIALS"`
+	AllowedHeaders   []string      `envconfig:"CORS_ALLOWED_HEADERS"`
+	AllowedMethods   []string      `envconfig:"CORS_ALLOWED_METHODS"`
+	AllowedOrigins   []string      `envconfig:"CORS_ALLOWED_ORIGINS"`
+	ExposedHeaders   []string      `envconfig:"CORS_EXPOSED_HEADERS"`
+	MaxAge           time.Duration `envconfig:"CORS_MAX_AGE"`
+}
+
+// JWTConfig contains the config required to authenticate callers presenting a JWT
+type JWTConfig struct {
+	Audience   string        `envconfig:"JWT_AUDIENCE"`
+	Issuer     string        `envconfig:"JWT_ISSUER"`
+	JWKSFile   string        `envconfig:"JWKS_FILE"`
+	Leeway     time.Duration `envconfig:"JWT_LEEWAY"`
+	ScopeClaim string        `envconfig:"JWT_SCOPE_CLAIM"`
+}
+
+// RateLimitConfig contains the rates, in requests per second, and burst sizes allowed for each caller identified by an
+// API key or JWT and for each client IP, a rate of zero disables the limit
+type RateLimitConfig struct {
+	KeyBurst          int     `envconfig:"RATE_LIMIT_KEY_BURST"`
+	KeyRate           float64 `envconfig:"RATE_LIMIT_KEY_RATE"`
+	IPBurst           int     `envconfig:"RATE_LIMIT_IP_BURST"`
+	IPRate            float64 `envconfig:"RATE_LIMIT_IP_RATE"`
+	TrustForwardedFor bool    `envconfig:"RATE_LIMIT_TRUST_FORWARDED_FOR"`
+}
+
+// TLSConfig contains the config required to serve the API over HTTPS, the API is served over HTTP if no certificate
+// is configured
+type TLSConfig struct {
+	CertFile       string        `envconfig:"TLS_CERT_FILE"`
+	ClientCAFile   string        `envconfig:"TLS_CLIENT_CA_FILE"`
+	KeyFile        string        `envconfig:"TLS_KEY_FILE"`
+	ReloadInterval time.Duration `envconfig:"TLS_RELOAD_INTERVAL"`
+}
+
+// TracingConfig contains the config for exporting OpenTelemetry traces, spans are not exported if no exporter is
+// configured
+type TracingConfig struct {
+	Exporter     string  `envconfig:"TRACING_EXPORTER"`
+	OTLPEndpoint string  `envconfig:"TRACING_OTLP_ENDPOINT"`
+	OTLPInsecure bool    `envconfig:"TRACING_OTLP_INSECURE"`
+	SampleRatio  float64 `envconfig:"TRACING_SAMPLE_RATIO"`
+	ServiceName  string  `envconfig:"TRACING_SERVICE_NAME"`
 }
 
 // MongoConfig contains the config required to connect to MongoDB.
@@ -34,18 +91,44 @@ func Get() (*Configuration, error) {
 	}
 
 	cfg = &Configuration{
-		BindAddr:                ":30000",
-		ConnectionString:        "coffee-break",
+		BindAddr: ":30000",
+		CORSConfig: CORSConfig{
+			AllowedHeaders: []string{"Authorization", "Content-Type", "Prefer"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			ExposedHeaders: []string{"Accept-Patch", "Location", "Preference-Applied", "Retry-After", "WWW-Authenticate", "X-Request-Id"},
+			MaxAge:         10 * time.Minute,
+		},
 		DefaultMaxResults:       50,
 		DownloadData:            false,
 		DownloadTimeout:         5 * time.Second,
 		GSURL:                   "",
 		GracefulShutdownTimeout: 5 * time.Second,
+		ImportMode:              "create-only",
+		JWTConfig: JWTConfig{
+			Leeway:     30 * time.Second,
+			ScopeClaim: "scope",
+		},
+		MaxBodyBytes: 1 << 20,
 		MongoConfig: MongoConfig{
 			BindAddr:   "mongodb://localhost:27017",
 			Collection: "recipes",
 			Database:   "food-recipes",
 		},
+		RateLimitConfig: RateLimitConfig{
+			KeyBurst: 20,
+			KeyRate:  10,
+			IPBurst:  40,
+			IPRate:   20,
+		},
+		TLSConfig: TLSConfig{
+			ReloadInterval: time.Minute,
+		},
+		TracingConfig: TracingConfig{
+			OTLPEndpoint: "localhost:4318",
+			OTLPInsecure: true,
+			SampleRatio:  1,
+			ServiceName:  "food-recipes",
+		},
 	}
 
 	return cfg, envconfig.Process("", cfg)
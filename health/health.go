@@ -0,0 +1,200 @@
+// Package health reports whether the API is alive and ready to serve requests, along with its build information and
+// the state of the services it depends on
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// checkTimeout is the longest a check can take before it is reported as failed
+const checkTimeout = 2 * time.Second
+
+// Status of the API or of one of its checks
+type Status string
+
+// Possible statuses, the API is ready unless a check is critical or the API is draining
+const (
+	StatusOK       Status = "OK"
+	StatusWarning  Status = "WARNING"
+	StatusCritical Status = "CRITICAL"
+)
+
+// BuildInfo identifies the build of the API, set at build time
+type BuildInfo struct {
+	BuildTime string `json:"build_time,omitempty"`
+	GitCommit string `json:"git_commit,omitempty"`
+	Version   string `json:"version,omitempty"`
+}
+
+// CheckFunc checks a dependency of the API, returning a message describing its state or an error if it is unhealthy
+type CheckFunc func(ctx context.Context) (string, error)
+
+// Check is a named check of a dependency. The API is not ready if a critical check fails, other checks failing are
+// reported as warnings.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      CheckFunc
+}
+
+// CheckResult is the result of running a check
+type CheckResult struct {
+	Name        string    `json:"name"`
+	Status      Status    `json:"status"`
+	Message     string    `json:"message,omitempty"`
+	LastChecked time.Time `json:"last_checked"`
+}
+
+// Report describes the health of the API
+type Report struct {
+	Status          Status        `json:"status"`
+	Build           BuildInfo     `json:"build"`
+	LanguageVersion string        `json:"language_version"`
+	StartTime       time.Time     `json:"start_time"`
+	Uptime          string        `json:"uptime"`
+	Draining        bool          `json:"draining,omitempty"`
+	Checks          []CheckResult `json:"checks,omitempty"`
+}
+
+// Health reports the health of the API
+type Health struct {
+	build     BuildInfo
+	checks    []Check
+	draining  int32
+	startTime time.Time
+	now       func() time.Time
+}
+
+// New creates a health reporter for the build running the given checks, the API is considered started from now
+func New(build BuildInfo, checks ...Check) *Health {
+	return &Health{
+		build:     build,
+		checks:    checks,
+		startTime: time.Now().UTC(),
+		now:       time.Now,
+	}
+}
+
+// StartDraining marks the API as shutting down, from which point it is no longer ready for new requests
+func (h *Health) StartDraining() {
+	atomic.StoreInt32(&h.draining, 1)
+}
+
+// IsDraining returns true if the API is shutting down
+func (h *Health) IsDraining() bool {
+	return atomic.LoadInt32(&h.draining) == 1
+}
+
+// Liveness responds with a 200 while the API is able to serve requests, it does not run any checks
+func (h *Health) Liveness(w http.ResponseWriter, req *http.Request) {
+	report := h.report()
+	report.Status = StatusOK
+
+	writeReport(req.Context(), w, http.StatusOK, report)
+}
+
+// Readiness runs the checks and responds with a 200 if the API is ready for requests, otherwise a 503
+func (h *Health) Readiness(w http.ResponseWriter, req *http.Request) {
+	report := h.report()
+	report.Checks = h.runChecks(req.Context())
+	report.Status = aggregate(report.Checks)
+
+	status := http.StatusOK
+	if report.Draining {
+		report.Status = StatusCritical
+	}
+
+	if report.Status == StatusCritical {
+		status = http.StatusServiceUnavailable
+		log.Warn(req.Context(), "api is not ready", log.Data{"draining": report.Draining, "checks": report.Checks})
+	}
+
+	writeReport(req.Context(), w, status, report)
+}
+
+func (h *Health) report() *Report {
+	return &Report{
+		Build:           h.build,
+		LanguageVersion: runtime.Version(),
+		StartTime:       h.startTime,
+		Uptime:          h.now().Sub(h.startTime).Round(time.Second).String(),
+		Draining:        h.IsDraining(),
+	}
+}
+
+// runChecks runs all checks concurrently, returning the results in the order the checks were given
+func (h *Health) runChecks(ctx context.Context) []CheckResult {
+	results := make([]CheckResult, len(h.checks))
+
+	var wg sync.WaitGroup
+	for i := range h.checks {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = h.runCheck(ctx, &h.checks[i])
+		}(i)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func (h *Health) runCheck(ctx context.Context, check *Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	result := CheckResult{Name: check.Name, Status: StatusOK}
+
+	message, err := check.Run(ctx)
+	result.LastChecked = h.now().UTC()
+	result.Message = message
+
+	if err != nil {
+		result.Status = StatusWarning
+		if check.Critical {
+			result.Status = StatusCritical
+		}
+		result.Message = err.Error()
+	}
+
+	return result
+}
+
+// aggregate returns the most severe status of the results
+func aggregate(results []CheckResult) Status {
+	status := StatusOK
+	for _, result := range results {
+		switch result.Status {
+		case StatusCritical:
+			return StatusCritical
+		case StatusWarning:
+			status = StatusWarning
+		}
+	}
+
+	return status
+}
+
+func writeReport(ctx context.Context, w http.ResponseWriter, status int, report *Report) {
+	b, err := json.Marshal(report)
+	if err != nil {
+		log.Error(ctx, "failed to marshal health report", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if _, err = w.Write(b); err != nil {
+		log.Warn(ctx, "failed to write health report", log.FormatErrors([]error{err}))
+	}
+}
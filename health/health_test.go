@@ -0,0 +1,134 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nshumoogum/food-recipes/health"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func passing(ctx context.Context) (string, error) {
+	return "fine", nil
+}
+
+func failing(ctx context.Context) (string, error) {
+	return "", errors.New("unreachable")
+}
+
+func get(handler http.HandlerFunc) (*httptest.ResponseRecorder, *health.Report) {
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	report := &health.Report{}
+	So(json.Unmarshal(w.Body.Bytes(), report), ShouldBeNil)
+
+	return w, report
+}
+
+func TestReadiness(t *testing.T) {
+	build := health.BuildInfo{GitCommit: "abc123", Version: "v1.0.0"}
+
+	Convey("Given all checks pass", t, func() {
+		h := health.New(build, health.Check{Name: "db", Critical: true, Run: passing}, health.Check{Name: "import", Run: passing})
+
+		Convey("Then the API is ready and the build and check results are reported", func() {
+			w, report := get(h.Readiness)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(report.Status, ShouldEqual, health.StatusOK)
+			So(report.Build, ShouldResemble, build)
+			So(report.Checks, ShouldHaveLength, 2)
+			So(report.Checks[0].Name, ShouldEqual, "db")
+			So(report.Checks[0].Message, ShouldEqual, "fine")
+			So(report.Checks[1].Status, ShouldEqual, health.StatusOK)
+		})
+	})
+
+	Convey("Given a non-critical check fails", t, func() {
+		h := health.New(build, health.Check{Name: "db", Critical: true, Run: passing}, health.Check{Name: "import", Run: failing})
+
+		Convey("Then the API is ready with a warning", func() {
+			w, report := get(h.Readiness)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(report.Status, ShouldEqual, health.StatusWarning)
+			So(report.Checks[1].Status, ShouldEqual, health.StatusWarning)
+			So(report.Checks[1].Message, ShouldEqual, "unreachable")
+		})
+	})
+
+	Convey("Given a critical check fails", t, func() {
+		h := health.New(build, health.Check{Name: "db", Critical: true, Run: failing}, health.Check{Name: "import", Run: failing})
+
+		Convey("Then the API is not ready", func() {
+			w, report := get(h.Readiness)
+			So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+			So(report.Status, ShouldEqual, health.StatusCritical)
+			So(report.Checks[0].Status, ShouldEqual, health.StatusCritical)
+		})
+
+		Convey("Then the API is still live", func() {
+			w, report := get(h.Liveness)
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(report.Status, ShouldEqual, health.StatusOK)
+			So(report.Checks, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given the API is draining", t, func() {
+		h := health.New(build, health.Check{Name: "db", Critical: true, Run: passing})
+		h.StartDraining()
+
+		Convey("Then the API is not ready", func() {
+			w, report := get(h.Readiness)
+			So(w.Code, ShouldEqual, http.StatusServiceUnavailable)
+			So(report.Status, ShouldEqual, health.StatusCritical)
+			So(report.Draining, ShouldBeTrue)
+		})
+
+		Convey("Then the API is still live", func() {
+			w, _ := get(h.Liveness)
+			So(w.Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+func TestImportStatus(t *testing.T) {
+	at := time.Date(2023, 4, 1, 9, 30, 0, 0, time.UTC)
+
+	Convey("Given no import has run", t, func() {
+		status := &health.ImportStatus{}
+
+		Convey("Then the check passes", func() {
+			message, err := status.Check(context.Background())
+			So(err, ShouldBeNil)
+			So(message, ShouldEqual, "no import has run")
+		})
+	})
+
+	Convey("Given the last import succeeded", t, func() {
+		status := &health.ImportStatus{}
+		status.Record(at, 12, nil)
+
+		Convey("Then the check reports the number of recipes loaded", func() {
+			message, err := status.Check(context.Background())
+			So(err, ShouldBeNil)
+			So(message, ShouldEqual, "import at 2023-04-01T09:30:00Z loaded 12 recipes")
+		})
+	})
+
+	Convey("Given the last import failed", t, func() {
+		status := &health.ImportStatus{}
+		status.Record(at, 0, errors.New("timeout"))
+
+		Convey("Then the check fails with the error from the import", func() {
+			_, err := status.Check(context.Background())
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "import at 2023-04-01T09:30:00Z failed: timeout")
+		})
+	})
+}
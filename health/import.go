@@ -0,0 +1,44 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ImportStatus records the result of the last import of recipes from the Google Sheet
+type ImportStatus struct {
+	mu      sync.Mutex
+	enabled bool
+	time    time.Time
+	count   int
+	err     error
+}
+
+// Record the result of an import which loaded count recipes
+func (s *ImportStatus) Record(at time.Time, count int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.enabled = true
+	s.time = at
+	s.count = count
+	s.err = err
+}
+
+// Check reports the result of the last import, returning the error from the import if it failed
+func (s *ImportStatus) Check(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.enabled {
+		return "no import has run", nil
+	}
+
+	if s.err != nil {
+		return "", fmt.Errorf("import at %s failed: %w", s.time.UTC().Format(time.RFC3339), s.err)
+	}
+
+	return fmt.Sprintf("import at %s loaded %d recipes", s.time.UTC().Format(time.RFC3339), s.count), nil
+}
@@ -3,7 +3,6 @@ package helpers
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"strconv"
 
 	"github.com/ONSdigital/log.go/v2/log"
@@ -22,19 +21,19 @@ func CalculateLimit(ctx context.Context, defaultLimit, maximumLimit int, request
 	requestedLimitNumber, err := strconv.Atoi(requestedLimit)
 	if err != nil {
 		log.Error(ctx, "invalid limit value", errors.WithMessage(err, errs.ErrLimitWrongType.Error()), log.Data{"requested_limit": requestedLimitNumber})
-		return 0, errs.New(errs.ErrLimitWrongType, http.StatusBadRequest, errorValues)
+		return 0, errs.ErrLimitWrongType.WithValues(errorValues)
 	}
 
 	if requestedLimitNumber < 0 {
 		log.Error(ctx, "invalid limit value", errs.ErrNegativeLimit, log.Data{"requested_limit": requestedLimitNumber})
-		return 0, errs.New(errs.ErrNegativeLimit, http.StatusBadRequest, errorValues)
+		return 0, errs.ErrNegativeLimit.WithValues(errorValues)
 	}
 
 	if requestedLimitNumber > maximumLimit {
-		err := fmt.Errorf("limit exceeded maximum value, limit cannot be greater than [%d]", maximumLimit)
+		err := errs.ErrLimitExceeded.WithMessage(fmt.Sprintf("limit exceeded maximum value, limit cannot be greater than [%d]", maximumLimit))
 
 		log.Error(ctx, "invalid limit value", err, log.Data{"requested_limit": requestedLimitNumber})
-		return 0, errs.New(err, http.StatusBadRequest, errorValues)
+		return 0, err.WithValues(errorValues)
 	}
 
 	return requestedLimitNumber, nil
@@ -2,7 +2,6 @@ package helpers
 
 import (
 	"context"
-	"net/http"
 	"strconv"
 
 	"github.com/ONSdigital/log.go/v2/log"
@@ -18,12 +17,12 @@ func CalculateOffset(ctx context.Context, requestedOffset string) (offset int, e
 		offset, err = strconv.Atoi(requestedOffset)
 		if err != nil {
 			log.Error(ctx, "invalid offset parameter", errors.WithMessage(err, errs.ErrOffsetWrongType.Error()), log.Data{"requested_offset": requestedOffset})
-			return 0, errs.New(errs.ErrOffsetWrongType, http.StatusBadRequest, errorValues)
+			return 0, errs.ErrOffsetWrongType.WithValues(errorValues)
 		}
 
 		if offset < 0 {
-			log.Error(ctx, "invalid offset parameter", errors.WithMessage(err, errs.ErrNegativeLimit.Error()), log.Data{"requested_offset": requestedOffset})
-			return 0, errs.New(errs.ErrNegativeOffset, http.StatusBadRequest, errorValues)
+			log.Error(ctx, "invalid offset parameter", errors.WithMessage(err, errs.ErrNegativeOffset.Error()), log.Data{"requested_offset": requestedOffset})
+			return 0, errs.ErrNegativeOffset.WithValues(errorValues)
 		}
 	}
 
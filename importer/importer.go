@@ -0,0 +1,326 @@
+// Package importer reads recipes from a CSV file, such as one exported from a Google Sheet. Columns are mapped to the
+// members of a recipe by the names in the header row, so columns can be in any order, and problems with a row are
+// reported against its line number without stopping the rest of the file from being read.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/slug"
+)
+
+// Columns of the CSV file, named after the json names of the members of a recipe
+const (
+	ColumnTitle            = "title"
+	ColumnPortionSize      = "portion_size"
+	ColumnLink             = "link"
+	ColumnCookBook         = "cook_book"
+	ColumnPage             = "page"
+	ColumnTags             = "tags"
+	ColumnFavourite        = "favourite"
+	ColumnCookTime         = "cook_time"
+	ColumnDifficulty       = "difficulty"
+	ColumnNotes            = "notes"
+	ColumnIngredients      = "ingredients"
+	ColumnExtraIngredients = "extra_ingredients"
+)
+
+// aliases maps alternative names for a column, after normalising them, to the name of the column
+var aliases = map[string]string{
+	"name":         ColumnTitle,
+	"recipe":       ColumnTitle,
+	"portions":     ColumnPortionSize,
+	"serves":       ColumnPortionSize,
+	"url":          ColumnLink,
+	"cookbook":     ColumnCookBook,
+	"book":         ColumnCookBook,
+	"favorite":     ColumnFavourite,
+	"cooking_time": ColumnCookTime,
+	"extras":       ColumnExtraIngredients,
+}
+
+var columns = map[string]bool{
+	ColumnTitle: true, ColumnPortionSize: true, ColumnLink: true, ColumnCookBook: true, ColumnPage: true, ColumnTags: true,
+	ColumnFavourite: true, ColumnCookTime: true, ColumnDifficulty: true, ColumnNotes: true, ColumnIngredients: true,
+	ColumnExtraIngredients: true,
+}
+
+// Errors returned when the file cannot be read at all, rather than a row of it
+var (
+	ErrMissingHeader      = errors.New("missing header row")
+	ErrMissingTitleColumn = errors.New("missing title column")
+)
+
+// RowError describes a problem with a row of the file, the row is not imported
+type RowError struct {
+	Line   int
+	Column string
+	Value  string
+	Err    error
+}
+
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return fmt.Sprintf("line %d: column %s: %v: %q", e.Line, e.Column, e.Err, e.Value)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// Problems with the values in a row
+var (
+	ErrInvalidNumber     = errors.New("value is not a whole number")
+	ErrInvalidBoolean    = errors.New("value is not TRUE or FALSE")
+	ErrInvalidIngredient = errors.New("ingredient is not in the form (item:quantity:unit)")
+	ErrMissingTitle      = errors.New("missing title")
+	ErrUnusableTitle     = errors.New("title cannot be converted to an identifier")
+	ErrDuplicateRecipe   = errors.New("recipe has the same identifier as an earlier row")
+)
+
+// Row is a recipe read from a line of the file
+type Row struct {
+	Line   int
+	Recipe models.Recipe
+}
+
+// Result of reading a file, rows which could not be read are reported in Errors and are not included in Rows
+type Result struct {
+	Rows           []Row
+	Errors         []*RowError
+	IgnoredColumns []string
+}
+
+// Recipes returns the recipes read from the file
+func (r *Result) Recipes() []models.Recipe {
+	recipes := make([]models.Recipe, 0, len(r.Rows))
+	for _, row := range r.Rows {
+		recipes = append(recipes, row.Recipe)
+	}
+	return recipes
+}
+
+// Read reads recipes from CSV. An error is only returned if the file cannot be read, problems with rows are reported
+// in the result.
+func Read(r io.Reader) (*Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrMissingHeader
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	result := &Result{Rows: []Row{}, Errors: []*RowError{}}
+
+	indexes := map[string]int{}
+	for i, name := range header {
+		column := normalise(name)
+		if alias, ok := aliases[column]; ok {
+			column = alias
+		}
+
+		if _, duplicate := indexes[column]; !columns[column] || duplicate {
+			result.IgnoredColumns = append(result.IgnoredColumns, name)
+			continue
+		}
+
+		indexes[column] = i
+	}
+
+	if _, ok := indexes[ColumnTitle]; !ok {
+		return nil, ErrMissingTitleColumn
+	}
+
+	ids := map[string]int{}
+
+	for {
+		record, readErr := reader.Read()
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+
+		if readErr != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(readErr, &parseErr) {
+				return nil, fmt.Errorf("failed to read file: %w", readErr)
+			}
+
+			// the rest of the file cannot be read reliably after a malformed quoted field
+			result.Errors = append(result.Errors, &RowError{Line: parseErr.StartLine, Err: parseErr.Err})
+			break
+		}
+
+		if isBlank(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		recipe, rowErrs := newRow(line, record, indexes).recipe()
+
+		if firstLine, duplicate := ids[recipe.ID]; duplicate && len(rowErrs) == 0 {
+			duplicateErr := fmt.Errorf("%w on line %d", ErrDuplicateRecipe, firstLine)
+			rowErrs = append(rowErrs, &RowError{Line: line, Column: ColumnTitle, Value: recipe.Title, Err: duplicateErr})
+		}
+
+		if len(rowErrs) > 0 {
+			result.Errors = append(result.Errors, rowErrs...)
+			continue
+		}
+
+		ids[recipe.ID] = line
+		result.Rows = append(result.Rows, Row{Line: line, Recipe: *recipe})
+	}
+
+	return result, nil
+}
+
+// normalise converts a column name to lower case with words separated by underscores, e.g. "Cook Time" to "cook_time"
+func normalise(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == '_' || r == '-'
+	}), "_")
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// row reads the cells of a record by column name, collecting the problems with its values
+type row struct {
+	line    int
+	record  []string
+	indexes map[string]int
+	errs    []*RowError
+}
+
+func newRow(line int, record []string, indexes map[string]int) *row {
+	return &row{line: line, record: record, indexes: indexes}
+}
+
+// value returns the trimmed value of the column, columns missing from the file or the row are empty
+func (r *row) value(column string) string {
+	i, ok := r.indexes[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r *row) error(column, value string, err error) {
+	r.errs = append(r.errs, &RowError{Line: r.line, Column: column, Value: value, Err: err})
+}
+
+func (r *row) int(column string) int {
+	value := r.value(column)
+	if value == "" {
+		return 0
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		r.error(column, value, ErrInvalidNumber)
+	}
+	return i
+}
+
+func (r *row) bool(column string) bool {
+	value := r.value(column)
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		return true
+	case "", "false", "no", "n", "0":
+		return false
+	default:
+		r.error(column, value, ErrInvalidBoolean)
+		return false
+	}
+}
+
+// tags reads a list of tags separated by slashes, e.g. "baking/cake"
+func (r *row) tags(column string) []string {
+	var tags []string
+	for _, tag := range strings.Split(r.value(column), "/") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// ingredients reads a list of ingredients in brackets, e.g. "(flour:200:g)(eggs:2:)", the unit is optional
+func (r *row) ingredients(column string) []models.Ingredient {
+	var ingredients []models.Ingredient
+
+	for _, value := range strings.Split(strings.ReplaceAll(r.value(column), ")", ""), "(") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+
+		parts := strings.Split(value, ":")
+		if len(parts) < 2 || len(parts) > 3 || strings.TrimSpace(parts[0]) == "" {
+			r.error(column, value, ErrInvalidIngredient)
+			continue
+		}
+
+		quantity, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil {
+			r.error(column, value, ErrInvalidIngredient)
+			continue
+		}
+
+		ingredient := models.Ingredient{Item: strings.TrimSpace(parts[0]), Quantity: quantity}
+		if len(parts) == 3 {
+			ingredient.Unit = strings.TrimSpace(parts[2])
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	return ingredients
+}
+
+// recipe returns the recipe in the row, or the problems with its values
+func (r *row) recipe() (*models.Recipe, []*RowError) {
+	recipe := &models.Recipe{
+		CookTime:    r.int(ColumnCookTime),
+		Difficulty:  r.value(ColumnDifficulty),
+		Extras:      r.ingredients(ColumnExtraIngredients),
+		Favourite:   r.bool(ColumnFavourite),
+		Ingredients: r.ingredients(ColumnIngredients),
+		Location: models.Location{
+			CookBook: r.value(ColumnCookBook),
+			Link:     r.value(ColumnLink),
+			Page:     r.int(ColumnPage),
+		},
+		Notes:       r.value(ColumnNotes),
+		PortionSize: r.int(ColumnPortionSize),
+		Tags:        r.tags(ColumnTags),
+		Title:       r.value(ColumnTitle),
+	}
+
+	switch recipe.ID = slug.Make(recipe.Title); {
+	case recipe.Title == "":
+		r.errs = append(r.errs, &RowError{Line: r.line, Err: ErrMissingTitle})
+	case recipe.ID == "":
+		r.error(ColumnTitle, recipe.Title, ErrUnusableTitle)
+	}
+
+	return recipe, r.errs
+}
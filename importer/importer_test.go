@@ -0,0 +1,118 @@
+package importer_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/importer"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const sheet = `Title,Serves,Link,Cook Book,Page,Tags,Favourite,Cook Time,Difficulty,Notes,Ingredients,Extra Ingredients
+Lemon Drizzle,8,,Baking,12,baking/cake,TRUE,45,easy,Cool first,(flour:200:g)(eggs:2:),(icing sugar:100:g)
+Pad Thai,2,https://example.com/pad-thai,,,noodles,FALSE,20,moderate,,(noodles:200:g),
+`
+
+func TestRead(t *testing.T) {
+	Convey("Given a file with a header row", t, func() {
+		result, err := importer.Read(strings.NewReader(sheet))
+
+		Convey("Then each row is read into a recipe using the column names", func() {
+			So(err, ShouldBeNil)
+			So(result.Errors, ShouldBeEmpty)
+			So(result.IgnoredColumns, ShouldBeEmpty)
+			So(result.Rows, ShouldResemble, []importer.Row{
+				{Line: 2, Recipe: models.Recipe{
+					ID:          "lemon-drizzle",
+					CookTime:    45,
+					Difficulty:  "easy",
+					Extras:      []models.Ingredient{{Item: "icing sugar", Quantity: 100, Unit: "g"}},
+					Favourite:   true,
+					Ingredients: []models.Ingredient{{Item: "flour", Quantity: 200, Unit: "g"}, {Item: "eggs", Quantity: 2}},
+					Location:    models.Location{CookBook: "Baking", Page: 12},
+					Notes:       "Cool first",
+					PortionSize: 8,
+					Tags:        []string{"baking", "cake"},
+					Title:       "Lemon Drizzle",
+				}},
+				{Line: 3, Recipe: models.Recipe{
+					ID:          "pad-thai",
+					CookTime:    20,
+					Difficulty:  "moderate",
+					Ingredients: []models.Ingredient{{Item: "noodles", Quantity: 200, Unit: "g"}},
+					Location:    models.Location{Link: "https://example.com/pad-thai"},
+					PortionSize: 2,
+					Tags:        []string{"noodles"},
+					Title:       "Pad Thai",
+				}},
+			})
+		})
+	})
+
+	Convey("Given a file with columns in a different order, missing and unrecognised columns", t, func() {
+		result, err := importer.Read(strings.NewReader("rating,cook_time,title\n5,30,Pad Thai\n"))
+
+		Convey("Then the columns are mapped by name and unrecognised columns are reported", func() {
+			So(err, ShouldBeNil)
+			So(result.IgnoredColumns, ShouldResemble, []string{"rating"})
+			So(result.Recipes(), ShouldResemble, []models.Recipe{{ID: "pad-thai", CookTime: 30, Title: "Pad Thai"}})
+		})
+	})
+
+	Convey("Given rows which are short, blank or have invalid values", t, func() {
+		file := "title,cook_time,favourite,ingredients\n" +
+			"Pad Thai\n" +
+			",,,\n" +
+			"Lemon Drizzle,soon,maybe,(flour)(eggs:two:)\n" +
+			",10\n" +
+			"pad thai!,15\n"
+
+		result, err := importer.Read(strings.NewReader(file))
+
+		Convey("Then the problems are reported with their line numbers and the rows are skipped", func() {
+			So(err, ShouldBeNil)
+			So(result.Recipes(), ShouldResemble, []models.Recipe{{ID: "pad-thai", Title: "Pad Thai"}})
+			So(result.Errors, ShouldHaveLength, 6)
+
+			So(*result.Errors[0], ShouldResemble, importer.RowError{Line: 4, Column: "cook_time", Value: "soon", Err: importer.ErrInvalidNumber})
+			So(*result.Errors[1], ShouldResemble, importer.RowError{Line: 4, Column: "favourite", Value: "maybe", Err: importer.ErrInvalidBoolean})
+			So(*result.Errors[2], ShouldResemble, importer.RowError{Line: 4, Column: "ingredients", Value: "flour", Err: importer.ErrInvalidIngredient})
+			So(*result.Errors[3], ShouldResemble, importer.RowError{Line: 4, Column: "ingredients", Value: "eggs:two:", Err: importer.ErrInvalidIngredient})
+			So(*result.Errors[4], ShouldResemble, importer.RowError{Line: 5, Err: importer.ErrMissingTitle})
+
+			So(result.Errors[5].Line, ShouldEqual, 6)
+			So(errors.Is(result.Errors[5], importer.ErrDuplicateRecipe), ShouldBeTrue)
+			So(result.Errors[5].Error(), ShouldEqual,
+				`line 6: column title: recipe has the same identifier as an earlier row on line 2: "pad thai!"`)
+		})
+	})
+
+	Convey("Given a file with a malformed quoted field", t, func() {
+		result, err := importer.Read(strings.NewReader("title,notes\nPad Thai,\"serve \"hot\"\nLemon Drizzle,\n"))
+
+		Convey("Then the problem is reported and the rows before it are read", func() {
+			So(err, ShouldBeNil)
+			So(result.Recipes(), ShouldBeEmpty)
+			So(result.Errors, ShouldHaveLength, 1)
+			So(result.Errors[0].Line, ShouldEqual, 2)
+		})
+	})
+
+	Convey("Given a file without a title column", t, func() {
+		_, err := importer.Read(strings.NewReader("name_of_dish,cook_time\nPad Thai,20\n"))
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldEqual, importer.ErrMissingTitleColumn)
+		})
+	})
+
+	Convey("Given an empty file", t, func() {
+		_, err := importer.Read(strings.NewReader(""))
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldEqual, importer.ErrMissingHeader)
+		})
+	})
+}
@@ -0,0 +1,297 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+)
+
+// Actions taken, or which would be taken in a dry run, for each recipe in an import
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionSkip   = "skip"
+	ActionDelete = "delete"
+	ActionError  = "error"
+)
+
+// The caller and auth method changes made by an import are recorded against in the audit log
+const (
+	AuditCaller     = "importer"
+	AuditAuthMethod = "import"
+)
+
+// ErrInvalidMode is returned when an import is run with a mode other than one of models.ImportModes
+var ErrInvalidMode = errors.New("invalid import mode")
+
+// Store is the storage recipes are imported into
+type Store interface {
+	GetAllRecipes(ctx context.Context) ([]models.Recipe, error)
+	CreateRecipe(ctx context.Context, recipe *models.Recipe) error
+	ReplaceRecipe(ctx context.Context, previous, recipe *models.Recipe) error
+	DeleteRecipe(ctx context.Context, recipe *models.Recipe) error
+	CreateImportRun(ctx context.Context, run *models.ImportRun) error
+	CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error
+}
+
+// Options of an import run
+type Options struct {
+	Mode   string
+	DryRun bool
+}
+
+// Change describes what happened, or would happen in a dry run, to a recipe. Updates include the JSON Patch
+// operations which change the stored recipe into the imported recipe.
+type Change struct {
+	Action  string        `json:"action"`
+	Line    int           `json:"line,omitempty"`
+	ID      string        `json:"id,omitempty"`
+	Reason  string        `json:"reason,omitempty"`
+	Patches []patch.Patch `json:"patches,omitempty"`
+}
+
+// Report of an import run, listing the change made to each recipe as well as the summary of the run
+type Report struct {
+	Run     *models.ImportRun `json:"run"`
+	Changes []Change          `json:"changes"`
+}
+
+// plannedChange is a change along with the recipe to store to make it, and the stored recipe it replaces
+type plannedChange struct {
+	Change
+	recipe   *models.Recipe
+	previous *models.Recipe
+}
+
+// Run stores the recipes read from a file according to the mode, or in a dry run reports the changes which would be
+// made without storing anything. Recipes created through the API, which have an owner, are never changed or deleted
+// by an import, and in replace-all mode recipes are only deleted if every row of the file was read, so a recipe is
+// not deleted because its row, or the rows after a malformed row, could not be read. A summary of the run is stored and returned in the report, along with the change to each recipe. An
+// error is returned if the run could not be completed, failures to store individual recipes are reported as changes.
+func Run(ctx context.Context, store Store, result *Result, opts Options) (*Report, error) {
+	if !isValidMode(opts.Mode) {
+		return nil, fmt.Errorf("%w %q, expected one of %v", ErrInvalidMode, opts.Mode, models.ImportModes)
+	}
+
+	run := &models.ImportRun{Mode: opts.Mode, DryRun: opts.DryRun, StartedAt: time.Now().UTC()}
+	run.Partial = opts.Mode == models.ImportModeReplaceAll && len(result.Errors) > 0
+
+	changes, err := plan(ctx, store, result, opts.Mode)
+	if err == nil && !opts.DryRun {
+		apply(ctx, store, changes)
+	}
+
+	report := &Report{Run: run, Changes: make([]Change, 0, len(changes))}
+	for i := range changes {
+		report.Changes = append(report.Changes, changes[i].Change)
+		count(run, changes[i].Action)
+	}
+
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.FinishedAt = time.Now().UTC()
+
+	if storeErr := store.CreateImportRun(ctx, run); storeErr != nil && err == nil {
+		err = fmt.Errorf("failed to store import run: %w", storeErr)
+	}
+
+	return report, err
+}
+
+// plan decides the change to make to each recipe, the rows which could not be read are reported as errors
+func plan(ctx context.Context, store Store, result *Result, mode string) ([]plannedChange, error) {
+	changes := make([]plannedChange, 0, len(result.Rows)+len(result.Errors))
+	for _, rowErr := range result.Errors {
+		changes = append(changes, plannedChange{Change: Change{Action: ActionError, Line: rowErr.Line, Reason: rowErr.Error()}})
+	}
+
+	stored, err := store.GetAllRecipes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stored recipes: %w", err)
+	}
+
+	existing := make(map[string]*models.Recipe, len(stored))
+	for i := range stored {
+		existing[stored[i].ID] = &stored[i]
+	}
+
+	imported := make(map[string]bool, len(result.Rows))
+
+	for i := range result.Rows {
+		row := result.Rows[i]
+		recipe := row.Recipe
+		imported[recipe.ID] = true
+
+		change, planErr := planRecipe(&recipe, existing[recipe.ID], mode)
+		if planErr != nil {
+			return nil, planErr
+		}
+
+		change.Line = row.Line
+		changes = append(changes, change)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Line < changes[j].Line
+	})
+
+	// recipes are only deleted if they were imported, so recipes created through the API are kept, and only if every
+	// row was read, as a recipe missing from the rows may be in a row which could not be read
+	if mode == models.ImportModeReplaceAll && len(result.Errors) == 0 {
+		for i := range stored {
+			if recipe := &stored[i]; recipe.Owner == "" && !imported[recipe.ID] {
+				changes = append(changes, plannedChange{Change: Change{Action: ActionDelete, ID: recipe.ID}, recipe: recipe})
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// planRecipe decides the change to make for an imported recipe given the stored recipe with the same id, if any
+func planRecipe(recipe, previous *models.Recipe, mode string) (plannedChange, error) {
+	change := plannedChange{Change: Change{ID: recipe.ID}, recipe: recipe, previous: previous}
+
+	if err := recipe.Validate(); err != nil {
+		change.Action = ActionError
+		change.Reason = err.Error()
+		return change, nil
+	}
+
+	switch {
+	case previous == nil:
+		change.Action = ActionCreate
+		recipe.Visibility = models.VisibilityPublic
+		return change, nil
+	case mode == models.ImportModeCreateOnly:
+		change.Action = ActionSkip
+		change.Reason = "recipe already exists"
+		return change, nil
+	case previous.Owner != "":
+		change.Action = ActionSkip
+		change.Reason = "recipe was created by " + previous.Owner
+		return change, nil
+	}
+
+	// who the recipe is shared with is not part of the file, so is kept when updating it
+	recipe.Visibility = previous.Visibility
+	recipe.Household = previous.Household
+
+	patches, err := diff(previous, recipe)
+	if err != nil {
+		return change, err
+	}
+
+	if len(patches) == 0 {
+		change.Action = ActionSkip
+		change.Reason = "recipe is unchanged"
+		return change, nil
+	}
+
+	change.Action = ActionUpdate
+	change.Patches = patches
+
+	return change, nil
+}
+
+func diff(previous, recipe *models.Recipe) ([]patch.Patch, error) {
+	original, err := json.Marshal(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	modified, err := json.Marshal(recipe)
+	if err != nil {
+		return nil, err
+	}
+
+	return patch.Diff(original, modified)
+}
+
+// apply stores the planned changes and records each change stored in the audit log, changes which cannot be stored are
+// reported as errors
+func apply(ctx context.Context, store Store, changes []plannedChange) {
+	for i := range changes {
+		change := &changes[i]
+
+		var err error
+		entry := models.AuditEntry{RecipeID: change.ID}
+		before, after := change.previous, change.recipe
+
+		switch change.Action {
+		case ActionCreate:
+			entry.Action = models.AuditActionCreate
+			err = store.CreateRecipe(ctx, change.recipe)
+		case ActionUpdate:
+			entry.Action = models.AuditActionReplace
+			err = store.ReplaceRecipe(ctx, change.previous, change.recipe)
+		case ActionDelete:
+			entry.Action = models.AuditActionDelete
+			before, after = change.recipe, nil
+			err = store.DeleteRecipe(ctx, change.recipe)
+		default:
+			continue
+		}
+
+		if err != nil {
+			change.Reason = fmt.Sprintf("failed to %s recipe: %v", change.Action, err)
+			change.Action = ActionError
+			continue
+		}
+
+		recordAudit(ctx, store, entry, before, after)
+	}
+}
+
+// recordAudit records a change made to a recipe by an import in the audit log, with the state of the recipe before and
+// after the change. The change has already been made, so failing to record it is logged rather than returned.
+func recordAudit(ctx context.Context, store Store, entry models.AuditEntry, before, after *models.Recipe) {
+	entry.Caller = AuditCaller
+	entry.AuthMethod = AuditAuthMethod
+	entry.Timestamp = time.Now().UTC()
+
+	var err error
+	if entry.BeforeDigest, err = before.Digest(); err == nil {
+		entry.AfterDigest, err = after.Digest()
+	}
+
+	if err == nil {
+		err = store.CreateAuditEntry(ctx, &entry)
+	}
+
+	if err != nil {
+		log.Error(ctx, "failed to record change to recipe in audit log", err, log.Data{"id": entry.RecipeID, "action": entry.Action})
+	}
+}
+
+func count(run *models.ImportRun, action string) {
+	switch action {
+	case ActionCreate:
+		run.Created++
+	case ActionUpdate:
+		run.Updated++
+	case ActionSkip:
+		run.Skipped++
+	case ActionDelete:
+		run.Deleted++
+	case ActionError:
+		run.Failed++
+	}
+}
+
+func isValidMode(mode string) bool {
+	for _, valid := range models.ImportModes {
+		if mode == valid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+package importer_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/importer"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errStore = errors.New("store unavailable")
+
+// memoryStore is an in-memory importer.Store
+type memoryStore struct {
+	recipes   map[string]models.Recipe
+	runs      []models.ImportRun
+	audit     []models.AuditEntry
+	createErr error
+}
+
+func newMemoryStore(recipes ...models.Recipe) *memoryStore {
+	s := &memoryStore{recipes: map[string]models.Recipe{}}
+	for i := range recipes {
+		s.recipes[recipes[i].ID] = recipes[i]
+	}
+	return s
+}
+
+func (s *memoryStore) GetAllRecipes(ctx context.Context) ([]models.Recipe, error) {
+	recipes := []models.Recipe{}
+	for id := range s.recipes {
+		recipes = append(recipes, s.recipes[id])
+	}
+	sort.Slice(recipes, func(i, j int) bool { return recipes[i].ID < recipes[j].ID })
+	return recipes, nil
+}
+
+func (s *memoryStore) CreateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if s.createErr != nil {
+		return s.createErr
+	}
+	s.recipes[recipe.ID] = *recipe
+	return nil
+}
+
+func (s *memoryStore) ReplaceRecipe(ctx context.Context, previous, recipe *models.Recipe) error {
+	if stored, ok := s.recipes[previous.ID]; !ok || stored.Owner != previous.Owner {
+		return errs.ErrRecipeChanged
+	}
+	s.recipes[recipe.ID] = *recipe
+	return nil
+}
+
+func (s *memoryStore) DeleteRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if stored, ok := s.recipes[recipe.ID]; !ok || stored.Owner != recipe.Owner {
+		return errs.ErrRecipeChanged
+	}
+	delete(s.recipes, recipe.ID)
+	return nil
+}
+
+func (s *memoryStore) CreateImportRun(ctx context.Context, run *models.ImportRun) error {
+	s.runs = append(s.runs, *run)
+	return nil
+}
+
+func (s *memoryStore) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	s.audit = append(s.audit, *entry)
+	return nil
+}
+
+func recipe(title string, cookTime int) models.Recipe {
+	return models.Recipe{
+		ID:          title,
+		CookTime:    cookTime,
+		Difficulty:  "easy",
+		Ingredients: []models.Ingredient{{Item: "flour", Quantity: 200, Unit: "g"}},
+		Location:    models.Location{CookBook: "Baking", Page: 12},
+		PortionSize: 4,
+		Title:       title,
+	}
+}
+
+func stored(r models.Recipe, owner, visibility string) models.Recipe {
+	r.Owner = owner
+	r.Visibility = visibility
+	return r
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	invalid := recipe("invalid", 10)
+	invalid.Difficulty = "impossible"
+
+	result := &importer.Result{
+		Rows: []importer.Row{
+			{Line: 2, Recipe: recipe("new", 10)},
+			{Line: 3, Recipe: recipe("changed", 20)},
+			{Line: 4, Recipe: recipe("unchanged", 10)},
+			{Line: 5, Recipe: recipe("owned", 20)},
+			{Line: 7, Recipe: invalid},
+		},
+		Errors: []*importer.RowError{{Line: 6, Column: importer.ColumnCookTime, Value: "soon", Err: importer.ErrInvalidNumber}},
+	}
+
+	newStore := func() *memoryStore {
+		return newMemoryStore(
+			stored(recipe("changed", 10), "", models.VisibilityPrivate),
+			stored(recipe("unchanged", 10), "", models.VisibilityPublic),
+			stored(recipe("owned", 10), "alice", models.VisibilityPublic),
+			stored(recipe("removed", 10), "", models.VisibilityPublic),
+			stored(recipe("created-by-api", 10), "bob", models.VisibilityPublic),
+		)
+	}
+
+	Convey("Given recipes imported in create-only mode", t, func() {
+		store := newStore()
+		report, err := importer.Run(ctx, store, result, importer.Options{Mode: models.ImportModeCreateOnly})
+
+		Convey("Then only recipes which are not stored are created", func() {
+			So(err, ShouldBeNil)
+			So(actions(report), ShouldResemble, []string{"2 new create", "3 changed skip", "4 unchanged skip", "5 owned skip", "6  error", "7 invalid error"})
+			So(store.recipes["new"].Visibility, ShouldEqual, models.VisibilityPublic)
+			So(store.recipes["changed"].CookTime, ShouldEqual, 10)
+			So(store.recipes, ShouldContainKey, "removed")
+		})
+
+		Convey("Then a summary of the run is stored", func() {
+			So(store.runs, ShouldHaveLength, 1)
+			So(store.runs[0].Mode, ShouldEqual, models.ImportModeCreateOnly)
+			So(store.runs[0].DryRun, ShouldBeFalse)
+			So([]int{store.runs[0].Created, store.runs[0].Updated, store.runs[0].Skipped, store.runs[0].Deleted, store.runs[0].Failed},
+				ShouldResemble, []int{1, 0, 3, 0, 2})
+		})
+	})
+
+	Convey("Given recipes imported in update-existing mode", t, func() {
+		store := newStore()
+		report, err := importer.Run(ctx, store, result, importer.Options{Mode: models.ImportModeUpdateExisting})
+
+		Convey("Then changed recipes are updated, keeping who they are shared with", func() {
+			So(err, ShouldBeNil)
+			So(actions(report), ShouldResemble, []string{"2 new create", "3 changed update", "4 unchanged skip", "5 owned skip", "6  error", "7 invalid error"})
+			So(report.Changes[1].Patches, ShouldResemble, []patch.Patch{{Op: "replace", Path: "/cook_time", Value: float64(20)}})
+			So(store.recipes["changed"].CookTime, ShouldEqual, 20)
+			So(store.recipes["changed"].Visibility, ShouldEqual, models.VisibilityPrivate)
+		})
+
+		Convey("Then recipes created through the API are not changed", func() {
+			So(report.Changes[3].Reason, ShouldEqual, "recipe was created by alice")
+			So(store.recipes["owned"].CookTime, ShouldEqual, 10)
+		})
+	})
+
+	// every row of the file was read, although a recipe read is invalid
+	readable := &importer.Result{Rows: result.Rows}
+
+	Convey("Given recipes imported in replace-all mode", t, func() {
+		store := newStore()
+		report, err := importer.Run(ctx, store, readable, importer.Options{Mode: models.ImportModeReplaceAll})
+
+		Convey("Then imported recipes missing from the file are deleted, but not those created through the API", func() {
+			So(err, ShouldBeNil)
+			So(actions(report)[5:], ShouldResemble, []string{"0 removed delete"})
+			So(store.recipes, ShouldNotContainKey, "removed")
+			So(store.recipes, ShouldContainKey, "created-by-api")
+			So(store.runs[0].Deleted, ShouldEqual, 1)
+			So(store.runs[0].Partial, ShouldBeFalse)
+		})
+
+		Convey("Then every change stored is recorded in the audit log against the importer", func() {
+			So(audited(store), ShouldResemble, []string{"new create", "changed replace", "removed delete"})
+
+			for _, entry := range store.audit {
+				So(entry.Caller, ShouldEqual, importer.AuditCaller)
+				So(entry.AuthMethod, ShouldEqual, importer.AuditAuthMethod)
+				So(entry.Timestamp, ShouldNotBeZeroValue)
+			}
+
+			So(store.audit[0].BeforeDigest, ShouldBeEmpty)
+			So(store.audit[0].AfterDigest, ShouldNotBeEmpty)
+			So(store.audit[1].BeforeDigest, ShouldNotEqual, store.audit[1].AfterDigest)
+			So(store.audit[2].BeforeDigest, ShouldNotBeEmpty)
+			So(store.audit[2].AfterDigest, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a file with an unreadable row and a malformed row, imported in replace-all mode", t, func() {
+		file := strings.Join([]string{
+			"Title,Serves,Cook Time,Difficulty,Ingredients,Cook Book,Page",
+			"Lemon Drizzle,8,45,easy,(flour:200:g),Baking,12",
+			"Pad Thai,2,soon,moderate,(noodles:200:g),Noodles,40",
+			`"Ramen,2,30,easy,(noodles:100:g),Noodles,52`,
+			"Stew,4,120,easy,(beef:500:g),Slow Cooker,8",
+		}, "\n")
+
+		partial, err := importer.Read(strings.NewReader(file))
+		So(err, ShouldBeNil)
+		So(partial.Errors, ShouldHaveLength, 2)
+
+		store := newMemoryStore(
+			stored(recipe("pad-thai", 10), "", models.VisibilityPublic),
+			stored(recipe("ramen", 10), "", models.VisibilityPublic),
+			stored(recipe("stew", 10), "", models.VisibilityPublic),
+		)
+		report, err := importer.Run(ctx, store, partial, importer.Options{Mode: models.ImportModeReplaceAll})
+
+		Convey("Then the rows which were read are imported but no recipes are deleted", func() {
+			So(err, ShouldBeNil)
+			So(store.recipes, ShouldContainKey, "lemon-drizzle")
+			So(store.recipes, ShouldContainKey, "pad-thai")
+			So(store.recipes, ShouldContainKey, "ramen")
+			So(store.recipes, ShouldContainKey, "stew")
+			So(report.Run.Deleted, ShouldEqual, 0)
+		})
+
+		Convey("Then the run is recorded as partial", func() {
+			So(report.Run.Partial, ShouldBeTrue)
+			So(store.runs[0].Partial, ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a dry run", t, func() {
+		store := newStore()
+		before, _ := store.GetAllRecipes(ctx)
+		report, err := importer.Run(ctx, store, readable, importer.Options{Mode: models.ImportModeReplaceAll, DryRun: true})
+
+		Convey("Then the changes are reported without storing any recipes", func() {
+			So(err, ShouldBeNil)
+			So(actions(report), ShouldResemble, []string{
+				"2 new create", "3 changed update", "4 unchanged skip", "5 owned skip", "7 invalid error", "0 removed delete",
+			})
+
+			after, _ := store.GetAllRecipes(ctx)
+			So(after, ShouldResemble, before)
+			So(store.audit, ShouldBeEmpty)
+		})
+
+		Convey("Then a summary of the dry run is stored", func() {
+			So(store.runs, ShouldHaveLength, 1)
+			So(store.runs[0].DryRun, ShouldBeTrue)
+			So(store.runs[0].Created, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given a recipe which cannot be stored", t, func() {
+		store := newMemoryStore()
+		store.createErr = errStore
+		report, err := importer.Run(ctx, store, &importer.Result{Rows: []importer.Row{{Line: 2, Recipe: recipe("new", 10)}}},
+			importer.Options{Mode: models.ImportModeCreateOnly})
+
+		Convey("Then the failure is reported against the recipe", func() {
+			So(err, ShouldBeNil)
+			So(report.Changes[0].Action, ShouldEqual, importer.ActionError)
+			So(report.Changes[0].Reason, ShouldEqual, "failed to create recipe: store unavailable")
+			So(report.Run.Failed, ShouldEqual, 1)
+			So(store.audit, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given an invalid mode", t, func() {
+		store := newStore()
+		_, err := importer.Run(ctx, store, result, importer.Options{Mode: "merge"})
+
+		Convey("Then an error is returned without running the import", func() {
+			So(errors.Is(err, importer.ErrInvalidMode), ShouldBeTrue)
+			So(store.runs, ShouldBeEmpty)
+		})
+	})
+}
+
+// audited summarises the audit log of a store as "<id> <action>"
+func audited(store *memoryStore) []string {
+	summary := []string{}
+	for _, entry := range store.audit {
+		summary = append(summary, entry.RecipeID+" "+entry.Action)
+	}
+	return summary
+}
+
+// actions summarises the changes in a report as "<line> <id> <action>"
+func actions(report *importer.Report) []string {
+	summary := []string{}
+	for _, change := range report.Changes {
+		summary = append(summary, fmt.Sprintf("%d %s %s", change.Line, change.ID, change.Action))
+	}
+	return summary
+}
@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// csvMediaTypes are the content types accepted when downloading a file, files served without a content type are
+// also accepted
+var csvMediaTypes = map[string]bool{
+	"text/csv":                 true,
+	"application/csv":          true,
+	"text/plain":               true,
+	"application/octet-stream": true,
+}
+
+// ErrMissingSource is returned when no file or URL is given to import from
+var ErrMissingSource = errors.New("missing import source")
+
+// Open opens a CSV file from a local path, a file:// URL or an http(s):// URL, such as the CSV export of a Google
+// Sheet. The caller must close the returned file.
+func Open(ctx context.Context, source string, httpClient *http.Client) (io.ReadCloser, error) {
+	if source == "" {
+		return nil, ErrMissingSource
+	}
+
+	u, err := url.Parse(source)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// a path, including a windows path with a drive letter
+		return os.Open(source) //nolint:gosec // the source is set by configuration
+	}
+
+	switch u.Scheme {
+	case "file":
+		return os.Open(u.Path)
+	case "http", "https":
+		return download(ctx, u.String(), httpClient)
+	default:
+		return nil, fmt.Errorf("unsupported import source scheme %q", u.Scheme)
+	}
+}
+
+func download(ctx context.Context, u string, httpClient *http.Client) (io.ReadCloser, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close() //nolint:errcheck,gosec // the body is not needed
+		return nil, fmt.Errorf("unexpected response status %d, expected %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, parseErr := mime.ParseMediaType(contentType)
+		if parseErr != nil || !csvMediaTypes[mediaType] {
+			resp.Body.Close() //nolint:errcheck,gosec // the body is not needed
+			return nil, fmt.Errorf("unexpected content type %q, expected text/csv", contentType)
+		}
+	}
+
+	return resp.Body, nil
+}
+
+// Import reads recipes from the CSV file at the source, see Open and Read
+func Import(ctx context.Context, source string, httpClient *http.Client) (*Result, error) {
+	file, err := Open(ctx, source, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
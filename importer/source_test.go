@@ -0,0 +1,86 @@
+package importer_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/importer"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestImport(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a local file", t, func() {
+		path := filepath.Join(t.TempDir(), "recipes.csv")
+		So(os.WriteFile(path, []byte(sheet), 0o600), ShouldBeNil)
+
+		Convey("Then the recipes are read from a path or a file url", func() {
+			for _, source := range []string{path, "file://" + path} {
+				result, err := importer.Import(ctx, source, nil)
+				So(err, ShouldBeNil)
+				So(result.Rows, ShouldHaveLength, 2)
+			}
+		})
+	})
+
+	Convey("Given a file served over http", t, func() {
+		contentType := "text/csv; charset=utf-8"
+		status := http.StatusOK
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header()["Content-Type"] = []string{contentType}
+			w.WriteHeader(status)
+			w.Write([]byte(sheet)) //nolint:errcheck,gosec // test server
+		}))
+		defer server.Close()
+
+		Convey("When the file is served as CSV", func() {
+			result, err := importer.Import(ctx, server.URL, server.Client())
+
+			Convey("Then the recipes are read", func() {
+				So(err, ShouldBeNil)
+				So(result.Rows, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the file is served without a content type", func() {
+			contentType = ""
+			result, err := importer.Import(ctx, server.URL, server.Client())
+
+			Convey("Then the recipes are read", func() {
+				So(err, ShouldBeNil)
+				So(result.Rows, ShouldHaveLength, 2)
+			})
+		})
+
+		Convey("When the file is served as html", func() {
+			contentType = "text/html"
+			_, err := importer.Import(ctx, server.URL, server.Client())
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the server responds with an error", func() {
+			status = http.StatusNotFound
+			_, err := importer.Import(ctx, server.URL, server.Client())
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given no source", t, func() {
+		_, err := importer.Import(ctx, "", nil)
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldEqual, importer.ErrMissingSource)
+		})
+	})
+}
@@ -2,29 +2,38 @@ package main
 
 import (
 	"context"
-	"encoding/csv"
-	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/nshumoogum/food-recipes/config"
-	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/health"
+	"github.com/nshumoogum/food-recipes/importer"
+	"github.com/nshumoogum/food-recipes/metrics"
 	"github.com/nshumoogum/food-recipes/service"
+	"github.com/nshumoogum/food-recipes/store"
+	"github.com/nshumoogum/food-recipes/tracing"
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 const serviceName = "food-recipes"
 
-var recipeData = make(map[string]models.Recipe)
+var (
+	// BuildTime represents the time in which the service was built
+	BuildTime string
+	// GitCommit represents the commit (SHA-1) hash of the service that is running
+	GitCommit string
+	// Version represents the version of the service that is running
+	Version string
+)
 
 func main() {
 	log.Namespace = serviceName
@@ -48,23 +57,61 @@ func run(ctx context.Context) error {
 	}
 	log.Info(ctx, "config on startup", log.Data{"config": cfg})
 
-	if cfg.DownloadData {
-		if downloadErr := Download(ctx, cfg.GSURL, cfg.DownloadTimeout); err != nil {
-			log.Error(ctx, "failed to download data and store in database, continuing to load API", downloadErr)
-		}
+	tracingConfig := cfg.TracingConfig
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Exporter:       tracingConfig.Exporter,
+		OTLPEndpoint:   tracingConfig.OTLPEndpoint,
+		OTLPInsecure:   tracingConfig.OTLPInsecure,
+		SampleRatio:    tracingConfig.SampleRatio,
+		ServiceName:    tracingConfig.ServiceName,
+		ServiceVersion: Version,
+	})
+	if err != nil {
+		log.Error(ctx, "failed to initialise tracing", err)
+		return err
 	}
 
-	mongoClient, err := getMongoClient(ctx, cfg)
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.GracefulShutdownTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			log.Error(ctx, "failed to export remaining spans", err)
+		}
+	}()
+
+	appMetrics := metrics.New()
+
+	mongoClient, err := getMongoClient(ctx, cfg, store.CombineMonitors(appMetrics.MongoMonitor(), tracing.MongoMonitor()))
 	if err != nil {
 		return err
 	}
 
+	importStatus := &health.ImportStatus{}
+	if cfg.DownloadData {
+		importCtx, span := tracing.Tracer().Start(ctx, "import recipes")
+		dataStore := store.NewMongo(mongoClient, cfg.MongoConfig.Database, cfg.MongoConfig.Collection)
+		count, downloadErr := Download(importCtx, dataStore, cfg.GSURL, cfg.ImportMode, cfg.DownloadTimeout)
+		span.SetAttributes(attribute.Int("recipes.count", count))
+		if downloadErr != nil {
+			log.Error(ctx, "failed to download data and store in database, continuing to load API", downloadErr)
+			span.RecordError(downloadErr)
+			span.SetStatus(codes.Error, downloadErr.Error())
+		}
+		span.End()
+
+		importedAt := time.Now()
+		importStatus.Record(importedAt, count, downloadErr)
+		appMetrics.ObserveImport(importedAt, count, downloadErr)
+	}
+
 	// Create the service, providing an error channel for fatal errors
 	svcErrors := make(chan error, 1)
 
 	// Run the service
-	svc := service.New(cfg, mongoClient)
-	if err := svc.Run(ctx, recipeData, svcErrors); err != nil {
+	build := health.BuildInfo{BuildTime: BuildTime, GitCommit: GitCommit, Version: Version}
+	svc := service.New(cfg, mongoClient, build, importStatus, appMetrics)
+	if err := svc.Run(ctx, svcErrors); err != nil {
 		return errors.Wrap(err, "running service failed")
 	}
 
@@ -79,163 +126,56 @@ func run(ctx context.Context) error {
 	return svc.Close(ctx)
 }
 
-// Download data on initialisation - TODO needs updating, consider using the API POST request logic
-func Download(ctx context.Context, url string, timeout time.Duration) error {
-	logData := log.Data{"url": url}
+// Download recipes from the CSV file or URL on initialisation and store them according to the import mode, returning
+// the number of recipes created or updated. Rows which cannot be read or stored are logged and skipped.
+func Download(ctx context.Context, dataStore importer.Store, source, mode string, timeout time.Duration) (int, error) {
+	logData := log.Data{"source": source, "mode": mode}
 	log.Info(ctx, "downloading data", logData)
 
-	if url == "" {
-		log.Warn(ctx, "missing google sheets url, no data loaded", logData)
-		return nil
-	}
-
-	client := http.Client{
-		Timeout: timeout,
+	if source == "" {
+		log.Warn(ctx, "missing import source, no data loaded", logData)
+		return 0, nil
 	}
 
-	resp, err := client.Get(url)
+	result, err := importer.Import(ctx, source, &http.Client{Timeout: timeout})
 	if err != nil {
-		log.Error(ctx, "cannot download file from the given url", err, logData)
-		return err
-	}
-
-	if resp.StatusCode != 200 {
-		err = errors.New("response from the URL was" + strconv.Itoa(resp.StatusCode) + "but expecting 200")
-		log.Error(ctx, "unexpected response code", err, logData)
-		return err
+		log.Error(ctx, "failed to import recipes", err, logData)
+		return 0, err
 	}
 
-	if resp.Header["Content-Type"][0] != "text/csv" {
-		err = fmt.Errorf("the file downloaded has content type '%s', expected 'text/csv'", resp.Header["Content-Type"])
-		log.Error(ctx, "unexpected response header", err, logData)
-		return err
+	if len(result.IgnoredColumns) > 0 {
+		log.Warn(ctx, "unrecognised columns ignored", log.Data{"source": source, "columns": result.IgnoredColumns})
 	}
 
-	b, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Error(ctx, "unable to read response body", err, logData)
-		return err
-	}
-
-	// Store data in-memory
-	csvReader := csv.NewReader(strings.NewReader(string(b)))
-
-	// Scan header row
-	_, err = csvReader.Read()
+	report, err := importer.Run(ctx, dataStore, result, importer.Options{Mode: mode})
 	if err != nil {
-		log.Error(ctx, "encountered error when processing header row of csv", err, logData)
-		return err
+		log.Error(ctx, "failed to store imported recipes", err, logData)
+		return 0, err
 	}
 
-	count := 0
-	for {
-		line, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		recipeLogData := log.Data{"line_count": count, "csv_line": line}
-		if err != nil {
-			log.Error(ctx, "encountered error reading csv", err, recipeLogData)
-			break
-		}
-
-		extras := getIngredients(ctx, line[11], recipeLogData)
-		ingredients := getIngredients(ctx, line[10], recipeLogData)
-
-		location := models.Location{
-			CookBook: line[3],
-			Link:     line[2],
-		}
-
-		location.Page, err = strconv.Atoi(line[4])
-		if err != nil {
-			recipeLogData["page"] = line[4]
-			log.Event(ctx, "page value unreadable", log.WARN, recipeLogData)
-		}
-
-		var favourite bool
-		if line[6] == "TRUE" {
-			favourite = true
-		}
-
-		tags := strings.Split(line[5], "/")
-
-		title := strings.ReplaceAll(line[0], " ", "-")
-		lcTitle := strings.ToLower(title)
-
-		recipe := models.Recipe{
-			ID:          lcTitle,
-			Difficulty:  line[8],
-			Extras:      extras,
-			Favourite:   favourite,
-			Ingredients: ingredients,
-			Location:    location,
-			Notes:       line[9],
-			Tags:        tags,
-			Title:       line[0],
-		}
-
-		recipe.CookTime, err = strconv.Atoi(line[7])
-		if err != nil {
-			recipeLogData["cook_time"] = line[3]
-			log.Warn(ctx, "cook_time value unreadable", recipeLogData)
-		}
-
-		recipe.PortionSize, err = strconv.Atoi(line[1])
-		if err != nil {
-			recipeLogData["portion_size"] = line[1]
-			log.Warn(ctx, "portion_size value unreadable", recipeLogData)
+	for _, change := range report.Changes {
+		if change.Action == importer.ActionError {
+			log.Warn(ctx, "skipping recipe which cannot be imported", log.Data{"line": change.Line, "id": change.ID, "reason": change.Reason})
 		}
-
-		recipeData[lcTitle] = recipe
-
-		count++
-	}
-
-	logData["count"] = count
-	log.Info(ctx, "successfuly loaded recipe data", logData)
-
-	return nil
-}
-
-func getIngredients(ctx context.Context, cell string, logData log.Data) (ingredientList []models.Ingredient) {
-	if cell == "" {
-		return
 	}
 
-	ingredients := strings.Split(strings.ReplaceAll(cell, ")", ""), "(")
-
-	for _, ingredient := range ingredients {
-		if ingredient == "" {
-			continue
-		}
-
-		logData["ingredient"] = ingredient
-		ingredientParts := strings.Split(ingredient, ":")
-
-		quantity, err := strconv.Atoi(ingredientParts[1])
-		if err != nil {
-			logData["quantity"] = ingredientParts[1]
-			log.Warn(ctx, "quantity value unreadable", logData)
-		}
-
-		ingredientList = append(ingredientList, models.Ingredient{
-			Item:     ingredientParts[0],
-			Quantity: quantity,
-			Unit:     ingredientParts[2],
-		})
+	run := report.Run
+	logData["run"] = run
+	if run.Partial {
+		log.Warn(ctx, "rows of the file could not be read, recipes missing from the file have not been deleted", logData)
 	}
+	log.Info(ctx, "successfully imported recipe data", logData)
 
-	return
+	return run.Created + run.Updated, nil
 }
 
-func getMongoClient(ctx context.Context, cfg *config.Configuration) (*mongo.Client, error) {
+func getMongoClient(ctx context.Context, cfg *config.Configuration, monitor *event.CommandMonitor) (*mongo.Client, error) {
 	mongoCTX, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
 	client, err := mongo.Connect(mongoCTX, options.Client().ApplyURI(
 		cfg.MongoConfig.BindAddr+"/"+cfg.MongoConfig.Database+"?retryWrites=true&w=majority",
-	))
+	).SetMonitor(monitor))
 	if err != nil {
 		log.Error(ctx, "failed to create mongo client", err)
 		return nil, err
@@ -0,0 +1,194 @@
+// Package metrics exposes metrics about the API in the Prometheus exposition format
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+	"github.com/nshumoogum/food-recipes/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+const (
+	namespace = "food_recipes"
+
+	// noDifficulty labels recipes which have no difficulty
+	noDifficulty = "none"
+
+	// recipeCountTimeout is the longest counting recipes can take when metrics are scraped
+	recipeCountTimeout = 5 * time.Second
+)
+
+// Results of storage operations and imports
+const (
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+// RecipeCounter returns the number of recipes stored for each difficulty
+type RecipeCounter func(ctx context.Context) (map[string]int, error)
+
+// Metrics holds the metrics recorded by the API
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	storeDuration   *prometheus.HistogramVec
+	imports         *prometheus.CounterVec
+	importedRecipes prometheus.Counter
+	lastImport      *prometheus.GaugeVec
+}
+
+// New creates the metrics recorded by the API, along with metrics about the Go runtime and process
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method, route template and response status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to respond to HTTP requests by method, route template and response status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		storeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "store_operation_duration_seconds",
+			Help:      "Time taken by MongoDB commands by command name and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "result"}),
+		imports: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "imports_total",
+			Help:      "Number of imports of recipes from the Google Sheet by result.",
+		}, []string{"result"}),
+		importedRecipes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "imported_recipes_total",
+			Help:      "Number of recipes loaded by imports from the Google Sheet.",
+		}),
+		lastImport: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_import_timestamp_seconds",
+			Help:      "Unix time of the last import of recipes from the Google Sheet by result.",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.storeDuration,
+		m.imports,
+		m.importedRecipes,
+		m.lastImport,
+	)
+
+	return m
+}
+
+// Handler responds with the metrics in the Prometheus exposition format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// Middleware records the number of requests and the time taken to respond, labelled by the template of the route
+// matched in the router, such as /recipes/{id}, rather than the path requested. It wraps the router so requests which
+// do not match a route, including preflight requests, are also recorded, labelled as unmatched.
+func (m *Metrics) Middleware(router *mux.Router) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			sw := middleware.NewStatusRecorder(w)
+
+			h.ServeHTTP(sw, req)
+
+			route := middleware.RouteTemplate(router, req)
+			status := strconv.Itoa(sw.Status)
+			m.httpRequests.WithLabelValues(req.Method, route, status).Inc()
+			m.httpDuration.WithLabelValues(req.Method, route, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// MongoMonitor returns a monitor recording the time taken by each command sent to MongoDB, set on the client used by
+// the store
+func (m *Metrics) MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			m.observeStoreOperation(&evt.CommandFinishedEvent, resultSuccess)
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			m.observeStoreOperation(&evt.CommandFinishedEvent, resultFailure)
+		},
+	}
+}
+
+func (m *Metrics) observeStoreOperation(evt *event.CommandFinishedEvent, result string) {
+	m.storeDuration.WithLabelValues(evt.CommandName, result).Observe(time.Duration(evt.DurationNanos).Seconds())
+}
+
+// ObserveImport records an import of recipes from the Google Sheet at the given time, which loaded count recipes
+func (m *Metrics) ObserveImport(at time.Time, count int, err error) {
+	result := resultSuccess
+	if err != nil {
+		result = resultFailure
+	}
+
+	m.imports.WithLabelValues(result).Inc()
+	m.importedRecipes.Add(float64(count))
+	m.lastImport.WithLabelValues(result).Set(float64(at.Unix()))
+}
+
+// RegisterRecipeCounter reports the number of recipes stored for each difficulty, counted each time metrics are
+// scraped
+func (m *Metrics) RegisterRecipeCounter(counter RecipeCounter) {
+	m.registry.MustRegister(&recipeCollector{count: counter})
+}
+
+var recipesDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "recipes"),
+	"Number of recipes stored by difficulty.",
+	[]string{"difficulty"}, nil,
+)
+
+type recipeCollector struct {
+	count RecipeCounter
+}
+
+// Describe implements prometheus.Collector
+func (c *recipeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- recipesDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *recipeCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), recipeCountTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		log.Error(ctx, "failed to count recipes for metrics", err)
+		ch <- prometheus.NewInvalidMetric(recipesDesc, err)
+		return
+	}
+
+	for difficulty, count := range counts {
+		if difficulty == "" {
+			difficulty = noDifficulty
+		}
+		ch <- prometheus.MustNewConstMetric(recipesDesc, prometheus.GaugeValue, float64(count), difficulty)
+	}
+}
@@ -0,0 +1,129 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/nshumoogum/food-recipes/metrics"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func scrape(m *metrics.Metrics) string {
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	So(w.Code, ShouldEqual, http.StatusOK)
+
+	return w.Body.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given a router wrapped by the metrics middleware", t, func() {
+		m := metrics.New()
+		router := mux.NewRouter()
+		router.HandleFunc("/recipes/{id}", func(w http.ResponseWriter, req *http.Request) {
+			if mux.Vars(req)["id"] == "missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = w.Write([]byte("{}"))
+		}).Methods(http.MethodGet)
+		handler := m.Middleware(router)(router)
+
+		Convey("When requests are made for recipes", func() {
+			for _, path := range []string{"/recipes/pie", "/recipes/soup", "/recipes/missing"} {
+				handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+			}
+
+			Convey("Then requests are counted by route template and status", func() {
+				body := scrape(m)
+				So(body, ShouldContainSubstring, `food_recipes_http_requests_total{method="GET",route="/recipes/{id}",status="200"} 2`)
+				So(body, ShouldContainSubstring, `food_recipes_http_requests_total{method="GET",route="/recipes/{id}",status="404"} 1`)
+				So(body, ShouldContainSubstring, `food_recipes_http_request_duration_seconds_count{method="GET",route="/recipes/{id}",status="200"} 2`)
+				So(body, ShouldNotContainSubstring, "pie")
+			})
+		})
+
+		Convey("When requests are made which do not match a route", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cookbooks/pie", nil))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/recipes/pie", nil))
+
+			Convey("Then requests are counted as unmatched without the path requested", func() {
+				body := scrape(m)
+				So(body, ShouldContainSubstring, `food_recipes_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+				So(body, ShouldContainSubstring, `food_recipes_http_requests_total{method="DELETE",route="unmatched",status="405"} 1`)
+				So(body, ShouldNotContainSubstring, "cookbooks")
+			})
+		})
+	})
+}
+
+func TestMongoMonitor(t *testing.T) {
+	Convey("Given a mongo monitor", t, func() {
+		m := metrics.New()
+		monitor := m.MongoMonitor()
+
+		Convey("When commands succeed and fail", func() {
+			finished := event.CommandFinishedEvent{CommandName: "find", DurationNanos: int64(20 * time.Millisecond)}
+			monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{CommandFinishedEvent: finished})
+			monitor.Failed(context.Background(), &event.CommandFailedEvent{CommandFinishedEvent: finished})
+
+			Convey("Then the time taken is recorded by command and result", func() {
+				body := scrape(m)
+				So(body, ShouldContainSubstring, `food_recipes_store_operation_duration_seconds_count{operation="find",result="success"} 1`)
+				So(body, ShouldContainSubstring, `food_recipes_store_operation_duration_seconds_count{operation="find",result="failure"} 1`)
+			})
+		})
+	})
+}
+
+func TestObserveImport(t *testing.T) {
+	Convey("Given imports which succeed and fail", t, func() {
+		m := metrics.New()
+		m.ObserveImport(time.Unix(1680000000, 0), 12, nil)
+		m.ObserveImport(time.Unix(1680000100, 0), 0, errors.New("timeout"))
+
+		Convey("Then imports and recipes loaded are counted", func() {
+			body := scrape(m)
+			So(body, ShouldContainSubstring, `food_recipes_imports_total{result="success"} 1`)
+			So(body, ShouldContainSubstring, `food_recipes_imports_total{result="failure"} 1`)
+			So(body, ShouldContainSubstring, `food_recipes_imported_recipes_total 12`)
+			So(body, ShouldContainSubstring, `food_recipes_last_import_timestamp_seconds{result="failure"} 1.6800001e+09`)
+		})
+	})
+}
+
+func TestRecipeCounter(t *testing.T) {
+	Convey("Given a recipe counter is registered", t, func() {
+		m := metrics.New()
+		m.RegisterRecipeCounter(func(ctx context.Context) (map[string]int, error) {
+			return map[string]int{"easy": 3, "hard": 1, "": 2}, nil
+		})
+
+		Convey("Then the number of recipes by difficulty is reported when scraped", func() {
+			body := scrape(m)
+			So(body, ShouldContainSubstring, `food_recipes_recipes{difficulty="easy"} 3`)
+			So(body, ShouldContainSubstring, `food_recipes_recipes{difficulty="hard"} 1`)
+			So(body, ShouldContainSubstring, `food_recipes_recipes{difficulty="none"} 2`)
+		})
+	})
+
+	Convey("Given the recipes cannot be counted", t, func() {
+		m := metrics.New()
+		m.RegisterRecipeCounter(func(ctx context.Context) (map[string]int, error) {
+			return nil, errors.New("unreachable")
+		})
+
+		Convey("Then the other metrics are still reported", func() {
+			body := scrape(m)
+			So(strings.Contains(body, "food_recipes_recipes{"), ShouldBeFalse)
+			So(body, ShouldContainSubstring, "go_goroutines")
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+)
+
+// UnmatchedRoute is the route of requests which did not match a route, used in place of the path requested so paths
+// are not used as labels or span names
+const UnmatchedRoute = "unmatched"
+
+// RouteTemplate returns the template of the route the request matches in the router, such as /recipes/{id}, or
+// UnmatchedRoute if it does not match a route. It is used by middleware wrapping the router, which cannot use
+// mux.CurrentRoute as the route is only added to the request passed to the route's handler.
+func RouteTemplate(router *mux.Router, req *http.Request) string {
+	var match mux.RouteMatch
+	if router.Match(req, &match) && match.Route != nil {
+		if template, err := match.Route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+
+	return UnmatchedRoute
+}
+
+// AccessLog logs one line for each request once it has been responded to, with the method, the template of the route
+// matched, such as /recipes/{id}, the status, the number of bytes written and the time taken. It wraps the router so
+// requests which do not match a route, including preflight requests, are also logged.
+func AccessLog(router *mux.Router) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			start := time.Now().UTC()
+			rw := NewStatusRecorder(w)
+
+			h.ServeHTTP(rw, req)
+
+			end := time.Now().UTC()
+
+			log.Info(req.Context(), "http request", log.HTTP(req, rw.Status, rw.Bytes, &start, &end), log.Data{
+				"route":       RouteTemplate(router, req),
+				"remote_addr": req.RemoteAddr,
+			})
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+)
+
+// BodyLimit rejects requests with a body larger than maxBytes with a 413. Bodies are read in full before the handler
+// is called, so handlers reading the whole body cannot be made to read more than maxBytes, including bodies sent
+// without a Content-Length. A maxBytes of zero or less disables the limit.
+func BodyLimit(maxBytes int64) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		if maxBytes <= 0 {
+			return h
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Body == nil || req.Body == http.NoBody {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			ctx := req.Context()
+			logData := log.Data{"max_bytes": maxBytes, "content_length": req.ContentLength}
+
+			if req.ContentLength > maxBytes {
+				log.Warn(ctx, "request body too large", logData)
+				tooLarge(w, req, maxBytes)
+				return
+			}
+
+			b, err := io.ReadAll(io.LimitReader(req.Body, maxBytes+1))
+			req.Body.Close() //nolint:errcheck,gosec // the body has been read
+			if err != nil {
+				log.Warn(ctx, "failed to read request body", log.FormatErrors([]error{err}), logData)
+				writeError(w, req, errs.ErrUnableToReadMessage)
+				return
+			}
+
+			if int64(len(b)) > maxBytes {
+				log.Warn(ctx, "request body too large", logData)
+				tooLarge(w, req, maxBytes)
+				return
+			}
+
+			req.Body = io.NopCloser(bytes.NewReader(b))
+			h.ServeHTTP(w, req)
+		})
+	}
+}
+
+func tooLarge(w http.ResponseWriter, req *http.Request, maxBytes int64) {
+	// close the connection rather than read the rest of an oversized body
+	w.Header().Set("Connection", "close")
+	writeError(w, req, errs.ErrRequestBodyTooLarge.WithValues(map[string]string{"max_bytes": strconv.FormatInt(maxBytes, 10)}))
+}
@@ -0,0 +1,73 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/middleware"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBodyLimit(t *testing.T) {
+	var received string
+	handler := middleware.BodyLimit(10)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		received = string(b)
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	Convey("Given a request with a body within the limit", t, func() {
+		received = ""
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/recipes", strings.NewReader("0123456789")))
+
+		Convey("Then the handler receives the whole body", func() {
+			So(w.Code, ShouldEqual, http.StatusOK)
+			So(received, ShouldEqual, "0123456789")
+		})
+	})
+
+	Convey("Given a request with a Content-Length over the limit", t, func() {
+		received = ""
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/recipes", strings.NewReader("0123456789a")))
+
+		Convey("Then the request is rejected before the handler is called", func() {
+			So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+			So(w.Body.String(), ShouldContainSubstring, `"max_bytes":"10"`)
+			So(received, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a request with a body over the limit from a caller accepting problem details", t, func() {
+		received = ""
+		req := httptest.NewRequest(http.MethodPost, "/recipes", strings.NewReader("0123456789a"))
+		req.Header.Set("Accept", "application/problem+json")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		Convey("Then the request is rejected with problem details", func() {
+			So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+			So(w.Header().Get("Content-Type"), ShouldEqual, "application/problem+json")
+			So(w.Body.String(), ShouldContainSubstring, `"type":"urn:food-recipes:problem:request_body_too_large"`)
+			So(w.Body.String(), ShouldContainSubstring, `"max_bytes":"10"`)
+			So(received, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a request without a Content-Length and a body over the limit", t, func() {
+		received = ""
+		req := httptest.NewRequest(http.MethodPost, "/recipes", io.NopCloser(strings.NewReader(strings.Repeat("a", 100))))
+		req.ContentLength = -1
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		Convey("Then the request is rejected before the handler is called", func() {
+			So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+			So(received, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+)
+
+// RequireClientCertificate rejects requests which modify data, any method other than GET, HEAD and OPTIONS, unless
+// the client presented a certificate verified during the TLS handshake
+func RequireClientCertificate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		logData := log.Data{"method": req.Method, "requested_uri": req.URL.RequestURI()}
+
+		if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+			log.Warn(req.Context(), "request rejected, missing verified client certificate", logData)
+			writeError(w, req, errs.ErrClientCertificateRequired)
+			return
+		}
+
+		logData["client_certificate"] = req.TLS.VerifiedChains[0][0].Subject.String()
+		log.Info(req.Context(), "client certificate verified", logData)
+
+		h.ServeHTTP(w, req)
+	})
+}
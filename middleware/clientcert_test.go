@@ -0,0 +1,47 @@
+package middleware_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/middleware"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequireClientCertificate(t *testing.T) {
+	handler := middleware.RequireClientCertificate(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "ci"}}}}}
+
+	tables := []struct {
+		givenTitle     string
+		method         string
+		tls            *tls.ConnectionState
+		expectedStatus int
+	}{
+		{"Given a read request without a client certificate", http.MethodGet, &tls.ConnectionState{}, http.StatusOK},
+		{"Given a write request without a client certificate", http.MethodPatch, &tls.ConnectionState{}, http.StatusForbidden},
+		{"Given a write request over plain http", http.MethodDelete, nil, http.StatusForbidden},
+		{"Given a write request with a verified client certificate", http.MethodPost, verified, http.StatusOK},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			req := httptest.NewRequest(table.method, "/recipes", http.NoBody)
+			req.TLS = table.tls
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			Convey("Then the expected status is returned", func() {
+				So(w.Code, ShouldEqual, table.expectedStatus)
+			})
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// CORSOptions configures which cross-origin requests browsers are allowed to make
+type CORSOptions struct {
+	// AllowedOrigins are the origins allowed to make requests, "*" allows any origin
+	AllowedOrigins []string
+	// AllowedMethods are the methods allowed in a cross-origin request
+	AllowedMethods []string
+	// AllowedHeaders are the request headers allowed in a cross-origin request
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers made available to scripts
+	ExposedHeaders []string
+	// AllowCredentials allows requests to include cookies and authorization headers
+	AllowCredentials bool
+	// MaxAge is how long browsers can cache the result of a preflight request
+	MaxAge time.Duration
+}
+
+// ErrAnyOriginWithCredentials is returned when any origin is allowed to make requests with credentials, which would
+// let every website make requests as a signed in user
+var ErrAnyOriginWithCredentials = errors.New("cors: credentials cannot be allowed when any origin is allowed")
+
+// CORS handles cross-origin requests from browsers (https://fetch.spec.whatwg.org/#http-cors-protocol), responding to
+// preflight requests and adding the CORS headers to responses for allowed origins. It wraps the router, rather than
+// being added as router middleware, as preflight requests use the OPTIONS method which no route matches.
+// Requests without an Origin header are passed to the handler unchanged. An error is returned if the options allow
+// credentials from any origin.
+func CORS(options CORSOptions) (func(http.Handler) http.Handler, error) {
+	anyOrigin := contains(options.AllowedOrigins, "*")
+	if anyOrigin && options.AllowCredentials {
+		return nil, ErrAnyOriginWithCredentials
+	}
+
+	allowedMethods := toSet(options.AllowedMethods, strings.ToUpper)
+	allowedHeaders := toSet(options.AllowedHeaders, http.CanonicalHeaderKey)
+
+	return func(h http.Handler) http.Handler {
+		if len(options.AllowedOrigins) == 0 {
+			return h
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if origin == "" {
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			headers := w.Header()
+			headers.Add("Vary", "Origin")
+
+			isAllowedOrigin := anyOrigin || contains(options.AllowedOrigins, origin)
+			isPreflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+
+			if !isPreflight {
+				if isAllowedOrigin {
+					setAllowOrigin(headers, origin, anyOrigin, options.AllowCredentials)
+					if len(options.ExposedHeaders) > 0 {
+						headers.Set("Access-Control-Expose-Headers", strings.Join(options.ExposedHeaders, ", "))
+					}
+				}
+
+				h.ServeHTTP(w, req)
+				return
+			}
+
+			headers.Add("Vary", "Access-Control-Request-Method")
+			headers.Add("Vary", "Access-Control-Request-Headers")
+
+			method := strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+			requestedHeaders := splitHeaderList(req.Header.Get("Access-Control-Request-Headers"))
+
+			if !isAllowedOrigin || !allowedMethods[method] || !allAllowed(requestedHeaders, allowedHeaders) {
+				log.Warn(req.Context(), "cross-origin preflight request rejected", log.Data{
+					"origin": origin, "method": method, "headers": requestedHeaders,
+				})
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			setAllowOrigin(headers, origin, anyOrigin, options.AllowCredentials)
+			headers.Set("Access-Control-Allow-Methods", strings.Join(options.AllowedMethods, ", "))
+			if len(requestedHeaders) > 0 {
+				headers.Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+			}
+			if options.MaxAge > 0 {
+				headers.Set("Access-Control-Max-Age", strconv.Itoa(int(options.MaxAge.Seconds())))
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}, nil
+}
+
+// setAllowOrigin allows the origin to read the response
+func setAllowOrigin(headers http.Header, origin string, anyOrigin, allowCredentials bool) {
+	if anyOrigin {
+		headers.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		headers.Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if allowCredentials {
+		headers.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func splitHeaderList(value string) []string {
+	var headers []string
+	for _, header := range strings.Split(value, ",") {
+		if header = strings.TrimSpace(header); header != "" {
+			headers = append(headers, http.CanonicalHeaderKey(header))
+		}
+	}
+
+	return headers
+}
+
+func allAllowed(values []string, allowed map[string]bool) bool {
+	for _, value := range values {
+		if !allowed[value] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func toSet(values []string, normalise func(string) string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[normalise(strings.TrimSpace(value))] = true
+	}
+
+	return set
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,122 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nshumoogum/food-recipes/middleware"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var corsOptions = middleware.CORSOptions{
+	AllowedOrigins: []string{"https://recipes.example.com"},
+	AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+	AllowedHeaders: []string{"Authorization", "Content-Type"},
+	ExposedHeaders: []string{"Location"},
+	MaxAge:         10 * time.Minute,
+}
+
+func TestCORS(t *testing.T) {
+	var called bool
+	cors, err := middleware.CORS(corsOptions)
+	if err != nil {
+		t.Fatalf("unable to create cors middleware: %v", err)
+	}
+
+	handler := cors(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		called = false
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	preflight := func(origin, method, headers string) *http.Request {
+		req := httptest.NewRequest(http.MethodOptions, "/recipes/pancakes", http.NoBody)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		return req
+	}
+
+	Convey("Given a preflight request for a PATCH with an Authorization header from an allowed origin", t, func() {
+		w := serve(preflight("https://recipes.example.com", "PATCH", "authorization, content-type"))
+
+		Convey("Then the request is allowed without calling the handler", func() {
+			So(w.Code, ShouldEqual, http.StatusNoContent)
+			So(called, ShouldBeFalse)
+			So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "https://recipes.example.com")
+			So(w.Header().Get("Access-Control-Allow-Methods"), ShouldEqual, "GET, POST, PUT, PATCH, DELETE")
+			So(w.Header().Get("Access-Control-Allow-Headers"), ShouldEqual, "Authorization, Content-Type")
+			So(w.Header().Get("Access-Control-Max-Age"), ShouldEqual, "600")
+			So(w.Header().Values("Vary"), ShouldContain, "Origin")
+		})
+	})
+
+	tables := []struct {
+		givenTitle string
+		req        *http.Request
+	}{
+		{"Given a preflight request from an origin which is not allowed", preflight("https://elsewhere.example.com", "DELETE", "")},
+		{"Given a preflight request for a method which is not allowed", preflight("https://recipes.example.com", "TRACE", "")},
+		{"Given a preflight request for a header which is not allowed", preflight("https://recipes.example.com", "DELETE", "X-Custom")},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			w := serve(table.req)
+
+			Convey("Then the request is rejected without CORS headers", func() {
+				So(w.Code, ShouldEqual, http.StatusForbidden)
+				So(called, ShouldBeFalse)
+				So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+			})
+		})
+	}
+
+	Convey("Given a cross-origin request from an allowed origin", t, func() {
+		req := httptest.NewRequest(http.MethodDelete, "/recipes/pancakes", http.NoBody)
+		req.Header.Set("Origin", "https://recipes.example.com")
+		w := serve(req)
+
+		Convey("Then the response can be read by the origin", func() {
+			So(called, ShouldBeTrue)
+			So(w.Header().Get("Access-Control-Allow-Origin"), ShouldEqual, "https://recipes.example.com")
+			So(w.Header().Get("Access-Control-Expose-Headers"), ShouldEqual, "Location")
+		})
+	})
+
+	Convey("Given a cross-origin request from an origin which is not allowed", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/recipes", http.NoBody)
+		req.Header.Set("Origin", "https://elsewhere.example.com")
+		w := serve(req)
+
+		Convey("Then the request is handled without CORS headers", func() {
+			So(called, ShouldBeTrue)
+			So(w.Header().Get("Access-Control-Allow-Origin"), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given any origin is allowed with credentials", t, func() {
+		options := corsOptions
+		options.AllowedOrigins = []string{"*"}
+		options.AllowCredentials = true
+
+		Convey("When the CORS middleware is created", func() {
+			credentialsHandler, err := middleware.CORS(options)
+
+			Convey("Then an error is returned rather than echoing every origin with credentials", func() {
+				So(credentialsHandler, ShouldBeNil)
+				So(err, ShouldEqual, middleware.ErrAnyOriginWithCredentials)
+			})
+		})
+	})
+}
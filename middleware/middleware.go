@@ -0,0 +1,14 @@
+// Package middleware contains the http middleware applied to the routes of the API
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/nshumoogum/food-recipes/models"
+)
+
+// writeError responds with the error in the same format as the API handlers, as problem details if the caller accepts
+// them
+func writeError(w http.ResponseWriter, req *http.Request, apiErr error) {
+	models.WriteErrorResponse(w, req, apiErr)
+}
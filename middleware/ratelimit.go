@@ -0,0 +1,244 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+)
+
+const (
+	bearerPrefix = "Bearer "
+
+	// sweepInterval is how often buckets which have refilled are removed, so the number of buckets held is bounded by
+	// the number of callers active in the interval
+	sweepInterval = time.Minute
+)
+
+// Limit is the rate requests are allowed at, with Rate requests per second sustained and bursts of up to Burst requests.
+// A rate of zero or less disables the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+func (l Limit) enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Authenticator identifies the caller presenting a bearer token
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*auth.Identity, error)
+}
+
+// RateLimiter limits the rate of requests from each client IP and of requests from each authenticated caller, using a
+// token bucket for each. Requests are checked against the client IP limit and, if a bearer token identifying a caller
+// is presented, the limit for the caller, and are only charged against either limit if both allow the request. Tokens
+// which do not identify a caller are only limited by client IP, so presenting a different token on each request does
+// not evade the limit.
+type RateLimiter struct {
+	// TrustForwardedFor uses the last address in the X-Forwarded-For header, added by a trusted proxy, as the client
+	// IP in place of the remote address of the connection
+	TrustForwardedFor bool
+
+	authenticator Authenticator
+	perKey        *buckets
+	perIP         *buckets
+	now           func() time.Time
+}
+
+// NewRateLimiter creates a rate limiter with a limit for each caller identified by the authenticator and a limit for
+// each client IP
+func NewRateLimiter(perKey, perIP Limit, trustForwardedFor bool, authenticator Authenticator) *RateLimiter {
+	return &RateLimiter{
+		TrustForwardedFor: trustForwardedFor,
+		authenticator:     authenticator,
+		perKey:            newBuckets(perKey),
+		perIP:             newBuckets(perIP),
+		now:               time.Now,
+	}
+}
+
+// Middleware rejects requests exceeding a rate limit with a 429 and a Retry-After header giving the number of seconds
+// until the request would be allowed
+func (l *RateLimiter) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		limits := []clientLimit{{name: "ip", buckets: l.perIP, client: l.clientIP(req)}}
+		if caller := l.caller(req); caller != "" {
+			limits = append(limits, clientLimit{name: "key", buckets: l.perKey, client: caller})
+		}
+
+		if limit, wait := take(l.now(), limits...); wait > 0 {
+			retryAfter := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+			log.Warn(req.Context(), "request rate limited", log.Data{"limit": limit, "retry_after": retryAfter, "requested_uri": req.URL.RequestURI()})
+
+			w.Header().Set("Retry-After", retryAfter)
+			writeError(w, req, errs.ErrTooManyRequests.WithValues(map[string]string{"retry_after": retryAfter}))
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+// caller returns the caller identified by the bearer token in the request, or an empty string if there is no token or
+// it does not identify a caller
+func (l *RateLimiter) caller(req *http.Request) string {
+	token := bearerToken(req)
+	if token == "" || l.authenticator == nil || !l.perKey.limit.enabled() {
+		return ""
+	}
+
+	identity, err := l.authenticator.Authenticate(req.Context(), token)
+	if err != nil || identity == nil {
+		return ""
+	}
+
+	return identity.Method + ":" + identity.Name
+}
+
+func (l *RateLimiter) clientIP(req *http.Request) string {
+	if l.TrustForwardedFor {
+		if forwarded := req.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			addresses := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := strings.TrimSpace(addresses[len(addresses)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+
+	return host
+}
+
+func bearerToken(req *http.Request) string {
+	authValue := req.Header.Get("Authorization")
+	if len(authValue) < len(bearerPrefix) || !strings.EqualFold(authValue[:len(bearerPrefix)], bearerPrefix) {
+		return ""
+	}
+
+	return strings.TrimSpace(authValue[len(bearerPrefix):])
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// buckets holds a token bucket for each client sharing a limit
+type buckets struct {
+	limit Limit
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func newBuckets(limit Limit) *buckets {
+	return &buckets{
+		limit:   limit,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// clientLimit is the bucket of a client under a named limit
+type clientLimit struct {
+	name    string
+	buckets *buckets
+	client  string
+}
+
+// take takes a token from the bucket of each client only if every bucket has a token available, returning zero if
+// the tokens were taken or the name of the limit with the longest wait and how long to wait until a token is available
+func take(now time.Time, limits ...clientLimit) (string, time.Duration) {
+	var (
+		reserved []*bucket
+		limit    string
+		wait     time.Duration
+	)
+
+	for _, l := range limits {
+		if !l.buckets.limit.enabled() {
+			continue
+		}
+
+		// buckets are always locked in the same order, so requests checked against the same limits cannot deadlock
+		l.buckets.mu.Lock()
+		defer l.buckets.mu.Unlock()
+
+		bkt := l.buckets.get(l.client, now)
+		if bktWait := l.buckets.wait(bkt); bktWait > wait {
+			limit, wait = l.name, bktWait
+		}
+
+		reserved = append(reserved, bkt)
+	}
+
+	if wait > 0 {
+		return limit, wait
+	}
+
+	for _, bkt := range reserved {
+		bkt.tokens--
+	}
+
+	return "", 0
+}
+
+// get returns the client's bucket refilled to the time given, the caller must hold the lock
+func (b *buckets) get(client string, now time.Time) *bucket {
+	b.sweep(now)
+
+	bkt, ok := b.buckets[client]
+	if !ok {
+		bkt = &bucket{tokens: float64(b.limit.Burst), last: now}
+		b.buckets[client] = bkt
+	}
+
+	b.refill(bkt, now)
+
+	return bkt
+}
+
+// wait returns zero if the bucket has a token available or how long to wait until a token is available
+func (b *buckets) wait(bkt *bucket) time.Duration {
+	if bkt.tokens >= 1 {
+		return 0
+	}
+
+	return time.Duration((1 - bkt.tokens) / b.limit.Rate * float64(time.Second))
+}
+
+func (b *buckets) refill(bkt *bucket, now time.Time) {
+	if elapsed := now.Sub(bkt.last).Seconds(); elapsed > 0 {
+		bkt.tokens = math.Min(float64(b.limit.Burst), bkt.tokens+elapsed*b.limit.Rate)
+		bkt.last = now
+	}
+}
+
+// sweep removes buckets which have refilled, as they are the same as a new bucket
+func (b *buckets) sweep(now time.Time) {
+	if now.Sub(b.lastSweep) < sweepInterval {
+		return
+	}
+	b.lastSweep = now
+
+	for client, bkt := range b.buckets {
+		b.refill(bkt, now)
+		if bkt.tokens >= float64(b.limit.Burst) {
+			delete(b.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/auth"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+// tokens identifies callers by a fixed set of tokens, key-a2 being a second key for the same caller as key-a
+type tokens map[string]*auth.Identity
+
+func (t tokens) Authenticate(ctx context.Context, token string) (*auth.Identity, error) {
+	if identity, ok := t[token]; ok {
+		return identity, nil
+	}
+
+	return nil, errs.ErrInvalidCredentials
+}
+
+var callers = tokens{
+	"key-a":  {Name: "a", Method: auth.MethodAPIKey},
+	"key-a2": {Name: "a", Method: auth.MethodAPIKey},
+	"key-b":  {Name: "b", Method: auth.MethodAPIKey},
+	"key-c":  {Name: "c", Method: auth.MethodAPIKey},
+}
+
+func TestRateLimiter(t *testing.T) {
+	Convey("Given a rate limiter allowing a burst of 2 requests per key and 3 requests per client IP", t, func() {
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		limiter := NewRateLimiter(Limit{Rate: 1, Burst: 2}, Limit{Rate: 0.5, Burst: 3}, false, callers)
+		limiter.now = func() time.Time { return now }
+		handler := limiter.Middleware(okHandler)
+
+		request := func(remoteAddr, token string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/recipes", http.NoBody)
+			req.RemoteAddr = remoteAddr
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			return w
+		}
+
+		Convey("When a key exceeds its burst", func() {
+			So(request("10.0.0.1:1234", "key-a").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.2:1234", "key-a").Code, ShouldEqual, http.StatusOK)
+			w := request("10.0.0.3:1234", "key-a")
+
+			Convey("Then the request is rejected with a retry after the next token is available", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(w.Header().Get("Retry-After"), ShouldEqual, "1")
+			})
+
+			Convey("Then requests with other keys are allowed", func() {
+				So(request("10.0.0.3:1234", "key-b").Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Then requests with the key are allowed once the bucket has refilled", func() {
+				now = now.Add(time.Second)
+				So(request("10.0.0.3:1234", "key-a").Code, ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Then requests with another key for the same caller are rejected", func() {
+				So(request("10.0.0.3:1234", "key-a2").Code, ShouldEqual, http.StatusTooManyRequests)
+			})
+		})
+
+		Convey("When a key is rejected from a client IP", func() {
+			So(request("10.0.0.1:1234", "key-a").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.1:1234", "key-a").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.1:1234", "key-a").Code, ShouldEqual, http.StatusTooManyRequests)
+
+			Convey("Then the rejected request is not charged to the client IP", func() {
+				So(request("10.0.0.1:1234", "").Code, ShouldEqual, http.StatusOK)
+				So(request("10.0.0.1:1234", "").Code, ShouldEqual, http.StatusTooManyRequests)
+			})
+		})
+
+		Convey("When a client presents a different unknown token on each request", func() {
+			So(request("10.0.0.1:1234", "unknown-1").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.1:1234", "unknown-2").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.1:1234", "unknown-3").Code, ShouldEqual, http.StatusOK)
+			w := request("10.0.0.1:1234", "unknown-4")
+
+			Convey("Then the requests are limited by client IP", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(limiter.perKey.buckets, ShouldBeEmpty)
+			})
+		})
+
+		Convey("When a client IP exceeds its burst using different keys", func() {
+			So(request("10.0.0.1:1234", "key-a").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.1:1235", "key-b").Code, ShouldEqual, http.StatusOK)
+			So(request("10.0.0.1:1236", "").Code, ShouldEqual, http.StatusOK)
+			w := request("10.0.0.1:1237", "key-c")
+
+			Convey("Then the request is rejected", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(w.Header().Get("Retry-After"), ShouldEqual, "2")
+			})
+		})
+	})
+
+	Convey("Given a rate limiter with its limits disabled", t, func() {
+		handler := NewRateLimiter(Limit{}, Limit{}, false, callers).Middleware(okHandler)
+
+		Convey("Then requests are never rejected", func() {
+			for i := 0; i < 100; i++ {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recipes", http.NoBody))
+				So(w.Code, ShouldEqual, http.StatusOK)
+			}
+		})
+	})
+}
+
+func TestClientIP(t *testing.T) {
+	Convey("Given a request forwarded by a proxy", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/recipes", http.NoBody)
+		req.RemoteAddr = "192.168.0.1:4567"
+		req.Header.Add("X-Forwarded-For", "1.1.1.1, 2.2.2.2")
+		req.Header.Add("X-Forwarded-For", "3.3.3.3")
+
+		Convey("Then the remote address is used if the proxy is not trusted", func() {
+			So((&RateLimiter{}).clientIP(req), ShouldEqual, "192.168.0.1")
+		})
+
+		Convey("Then the address added by the proxy is used if the proxy is trusted", func() {
+			So((&RateLimiter{TrustForwardedFor: true}).clientIP(req), ShouldEqual, "3.3.3.3")
+		})
+	})
+}
+
+func TestBuckets(t *testing.T) {
+	Convey("Given buckets which have refilled since they were last used", t, func() {
+		now := time.Now()
+		b := newBuckets(Limit{Rate: 1, Burst: 1})
+		take(now, clientLimit{buckets: b, client: "client-a"})
+		take(now.Add(sweepInterval), clientLimit{buckets: b, client: "client-b"})
+
+		Convey("When the buckets are swept", func() {
+			take(now.Add(2*sweepInterval), clientLimit{buckets: b, client: "client-c"})
+
+			Convey("Then only buckets which have refilled are removed", func() {
+				So(b.buckets, ShouldNotContainKey, "client-a")
+				So(b.buckets, ShouldNotContainKey, "client-b")
+				So(b.buckets, ShouldContainKey, "client-c")
+			})
+		})
+	})
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import "net/http"
+
+// StatusRecorder wraps a response writer, recording the status and number of bytes of the response written. Flush is
+// passed through so streamed responses are still flushed, and Unwrap returns the wrapped writer so
+// http.ResponseController can reach it.
+type StatusRecorder struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int64
+	wroteHeader bool
+}
+
+// NewStatusRecorder returns a recorder wrapping the response writer, the status is 200 until another is written
+func NewStatusRecorder(w http.ResponseWriter) *StatusRecorder {
+	return &StatusRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+// WriteHeader records the status, only the first status written is sent to the caller
+func (w *StatusRecorder) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.Status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write records the number of bytes of the response body written
+func (w *StatusRecorder) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.Bytes += int64(n)
+	return n, err
+}
+
+// Flush sends any buffered data to the caller, if the wrapped writer supports flushing
+func (w *StatusRecorder) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped response writer
+func (w *StatusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/middleware"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatusRecorder(t *testing.T) {
+	Convey("Given a response written without a status", t, func() {
+		w := httptest.NewRecorder()
+		rw := middleware.NewStatusRecorder(w)
+
+		_, err := rw.Write([]byte("pancakes"))
+
+		Convey("Then the status is recorded as 200 along with the bytes written", func() {
+			So(err, ShouldBeNil)
+			So(rw.Status, ShouldEqual, http.StatusOK)
+			So(rw.Bytes, ShouldEqual, 8)
+		})
+	})
+
+	Convey("Given a response written with more than one status", t, func() {
+		w := httptest.NewRecorder()
+		rw := middleware.NewStatusRecorder(w)
+
+		rw.WriteHeader(http.StatusNotFound)
+		rw.WriteHeader(http.StatusInternalServerError)
+
+		Convey("Then the first status is recorded", func() {
+			So(rw.Status, ShouldEqual, http.StatusNotFound)
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+		})
+	})
+
+	Convey("Given a response which is flushed", t, func() {
+		w := httptest.NewRecorder()
+		rw := middleware.NewStatusRecorder(w)
+
+		flusher, ok := interface{}(rw).(http.Flusher)
+		So(ok, ShouldBeTrue)
+		flusher.Flush()
+
+		Convey("Then the wrapped writer is flushed", func() {
+			So(w.Flushed, ShouldBeTrue)
+		})
+
+		Convey("Then the wrapped writer can be unwrapped", func() {
+			So(rw.Unwrap(), ShouldEqual, w)
+		})
+	})
+}
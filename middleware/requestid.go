@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/go-ns/common"
+)
+
+const (
+	// RequestIDHeader is the header a request ID is accepted from and returned in
+	RequestIDHeader = "X-Request-Id"
+
+	requestIDLength    = 16
+	maxRequestIDLength = 128
+)
+
+// RequestID identifies each request with the ID given in the X-Request-Id header, or a generated ID if none or an
+// invalid ID is given. The ID is added to the context, so it is included in every log line for the request and in
+// error responses, and returned in the X-Request-Id header of the response.
+func RequestID(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		id := req.Header.Get(RequestIDHeader)
+		if !isValidRequestID(id) {
+			id = common.NewRequestID(requestIDLength)
+			req.Header.Set(RequestIDHeader, id)
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+
+		h.ServeHTTP(w, req.WithContext(WithRequestID(req.Context(), id)))
+	})
+}
+
+// WithRequestID returns a copy of the context with the request ID, under the keys used by both log.go and go-ns
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return common.WithRequestId(request.WithRequestId(ctx, id), id)
+}
+
+// GetRequestID returns the ID of the request from the context
+func GetRequestID(ctx context.Context) string {
+	return request.GetRequestId(ctx)
+}
+
+// isValidRequestID returns true if the ID is safe to log and return, limiting it to a reasonable length of letters,
+// digits and punctuation commonly used in IDs
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,107 @@
+package middleware_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ONSdigital/log.go/v2/log"
+	"github.com/gorilla/mux"
+	"github.com/nshumoogum/food-recipes/middleware"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRequestID(t *testing.T) {
+	var received string
+	handler := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received = middleware.GetRequestID(req.Context())
+	}))
+
+	Convey("Given a request with a valid X-Request-Id header", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/recipes", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		Convey("Then the request ID is added to the context and returned", func() {
+			So(received, ShouldEqual, "abc-123")
+			So(w.Header().Get(middleware.RequestIDHeader), ShouldEqual, "abc-123")
+		})
+	})
+
+	Convey("Given a request without an X-Request-Id header", t, func() {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/recipes", nil))
+
+		Convey("Then a request ID is generated", func() {
+			So(received, ShouldHaveLength, 16)
+			So(w.Header().Get(middleware.RequestIDHeader), ShouldEqual, received)
+		})
+	})
+
+	Convey("Given a request with an invalid X-Request-Id header", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/recipes", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc\" injected")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		Convey("Then a request ID is generated in its place", func() {
+			So(received, ShouldHaveLength, 16)
+			So(w.Header().Get(middleware.RequestIDHeader), ShouldEqual, received)
+		})
+	})
+
+	Convey("Given a request rejected by middleware with an error", t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/recipes", strings.NewReader("0123456789a"))
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+		w := httptest.NewRecorder()
+		middleware.RequestID(middleware.BodyLimit(10)(http.NotFoundHandler())).ServeHTTP(w, req)
+
+		Convey("Then the request ID is included in the error response", func() {
+			So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+			So(w.Body.String(), ShouldContainSubstring, `"request_id":"abc-123"`)
+		})
+	})
+}
+
+func TestAccessLog(t *testing.T) {
+	var output bytes.Buffer
+	log.SetDestination(&output, nil)
+	defer log.SetDestination(os.Stdout, nil)
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	})
+	handler := middleware.RequestID(middleware.AccessLog(router)(router))
+
+	Convey("Given a request matching a route", t, func() {
+		output.Reset()
+		req := httptest.NewRequest(http.MethodPut, "/recipes/pie", nil)
+		req.Header.Set(middleware.RequestIDHeader, "abc-123")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		Convey("Then one access log line is written with the route template, status, bytes and request ID", func() {
+			So(strings.Count(output.String(), "\n"), ShouldEqual, 1)
+			So(output.String(), ShouldContainSubstring, `"route":"/recipes/{id}"`)
+			So(output.String(), ShouldContainSubstring, `"status_code":201`)
+			So(output.String(), ShouldContainSubstring, `"response_content_length":7`)
+			So(output.String(), ShouldContainSubstring, `"method":"PUT"`)
+			So(output.String(), ShouldContainSubstring, `"trace_id":"abc-123"`)
+		})
+	})
+
+	Convey("Given a request not matching a route", t, func() {
+		output.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+
+		Convey("Then the access log line records the request as unmatched", func() {
+			So(output.String(), ShouldContainSubstring, `"route":"unmatched"`)
+			So(output.String(), ShouldContainSubstring, `"status_code":404`)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditActionCreate  = "create"
+	AuditActionReplace = "replace"
+	AuditActionPatch   = "patch"
+	AuditActionDelete  = "delete"
+)
+
+// AuditEntries contains a page of audit entries
+type AuditEntries struct {
+	Count      int          `json:"count"`
+	Items      []AuditEntry `json:"items"`
+	Limit      int          `json:"limit"`
+	Offset     int          `json:"offset"`
+	TotalCount int64        `json:"total_count"`
+}
+
+// AuditEntry records a change made to a recipe and who made it. The state of the recipe before and after the change
+// is recorded as a digest, so changes can be matched to a version of a recipe without keeping every version.
+type AuditEntry struct {
+	ID             string    `bson:"_id"                        json:"id"`
+	RecipeID       string    `bson:"recipe_id"                  json:"recipe_id"`
+	Action         string    `bson:"action"                     json:"action"`
+	Caller         string    `bson:"caller"                     json:"caller"`
+	AuthMethod     string    `bson:"auth_method"                json:"auth_method"`
+	RequestID      string    `bson:"request_id,omitempty"       json:"request_id,omitempty"`
+	Timestamp      time.Time `bson:"timestamp"                  json:"timestamp"`
+	BeforeDigest   string    `bson:"before_digest,omitempty"    json:"before_digest,omitempty"`
+	AfterDigest    string    `bson:"after_digest,omitempty"     json:"after_digest,omitempty"`
+	Patch          string    `bson:"patch,omitempty"            json:"patch,omitempty"`
+	PatchMediaType string    `bson:"patch_media_type,omitempty" json:"patch_media_type,omitempty"`
+}
+
+// AuditFilter selects the audit entries to return, empty fields match all entries
+type AuditFilter struct {
+	RecipeID string
+	Since    *time.Time
+}
+
+// Digest returns the hex encoded SHA-256 hash of the json representation of the recipe, or an empty string if there
+// is no recipe
+func (recipe *Recipe) Digest() (string, error) {
+	if recipe == nil {
+		return "", nil
+	}
+
+	b, err := json.Marshal(recipe)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(b)
+
+	return hex.EncodeToString(hash[:]), nil
+}
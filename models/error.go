@@ -1,58 +1,202 @@
 package models
 
 import (
+	"errors"
+	"strconv"
 	"strings"
 
 	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/helpers"
 )
 
 // ErrorResponse builds a list of errors for an unsuccessful request
 type ErrorResponse struct {
-	Errors []*ErrorObject `json:"errors"`
+	Errors    []*ErrorObject `json:"errors"`
+	RequestID string         `json:"request_id,omitempty"`
 }
 
-// ErrorObject contains an error message and error values
+// ErrorObject contains an error message and error values, identified by a code which does not change
 type ErrorObject struct {
+	Code        string            `json:"code"`
 	Error       string            `json:"error"`
+	Field       string            `json:"field,omitempty"`
 	ErrorValues map[string]string `json:"error_values,omitempty"`
 }
 
-// CreateErrorObject formulates an error object from an error
+// CreateErrorObject formulates an error object from an error, errors which are not API errors are reported as an
+// internal server error so their details are not exposed
 func CreateErrorObject(err error) *ErrorObject {
-	return &ErrorObject{Error: err.Error(), ErrorValues: err.(*errs.ErrorObject).Values()}
+	apiErr := apiError(err)
+	return &ErrorObject{Code: apiErr.Code, Error: apiErr.Message, Field: apiErr.Field, ErrorValues: apiErr.Values}
+}
+
+// CreateErrorResponse formulates the response to an error and the status to respond with. Each error in a list of
+// errors is reported and the status is that of the first error.
+func CreateErrorResponse(err error) (int, *ErrorResponse) {
+	list := errorList(err)
+
+	response := &ErrorResponse{Errors: make([]*ErrorObject, 0, len(list))}
+	for _, e := range list {
+		response.Errors = append(response.Errors, CreateErrorObject(e))
+	}
+
+	return Status(list[0]), response
+}
+
+// errorList returns the errors in a list of errors, or a list containing the error if it is not a list
+func errorList(err error) errs.Errors {
+	var list errs.Errors
+	if !errors.As(err, &list) || len(list) == 0 {
+		list = errs.Errors{err}
+	}
+
+	return list
+}
+
+// Status returns the status to respond with for an error, errors which are not API errors are internal server errors
+func Status(err error) int {
+	return apiError(err).Status
+}
+
+// apiError returns the API error to report for an error, errors which are not API errors are internal server errors
+func apiError(err error) *errs.Error {
+	var apiErr *errs.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	return errs.ErrInternalServer
 }
 
 // HandleValidationErrors works out a human friendly error for all Validation Errors
 // Extend function for additional validation tags
-func HandleValidationErrors(patchIndex, tag, field, value, param string) *ErrorObject {
-	var err error
+func HandleValidationErrors(patchIndex, tag, field, value, param string) error {
+	field = strings.ToLower(field)
+	pointer := "/" + patchIndex + "/" + field
 
 	switch tag {
 	case "required":
-		values := map[string]string{"[" + patchIndex + "]." + strings.ToLower(field): ""}
-		err = errs.New(errs.ErrMissingFields, 0, values)
+		values := map[string]string{"[" + patchIndex + "]." + field: ""}
+		return errs.ErrMissingFields.WithField(pointer).WithValues(values)
 	case "requirevalueifopis":
 		values := map[string]string{"[" + patchIndex + "]." + "value": ""}
-		err = errs.New(errs.ErrMissingFields, 0, values)
+		return errs.ErrMissingFields.WithField("/" + patchIndex + "/value").WithValues(values)
 	case "requirefromifopis":
 		values := map[string]string{"[" + patchIndex + "]." + "from": ""}
-		err = errs.New(errs.ErrMissingFields, 0, values)
+		return errs.ErrMissingFields.WithField("/" + patchIndex + "/from").WithValues(values)
 	case "supportedops":
-		values := map[string]string{"[" + patchIndex + "]." + strings.ToLower(field): value}
-		err = errs.New(errs.ErrUnsupportedOperation, 0, values)
+		values := map[string]string{"[" + patchIndex + "]." + field: value}
+		return errs.ErrUnsupportedOperation.WithField(pointer).WithValues(values)
 	case "oneof":
-		values := map[string]string{"[" + patchIndex + "]." + strings.ToLower(field): value}
-		err = errs.New(errs.ErrInvalidOperation, 0, values)
+		values := map[string]string{"[" + patchIndex + "]." + field: value}
+		return errs.ErrInvalidOperation.WithField(pointer).WithValues(values)
 	case "nefield":
-		values := map[string]string{"[" + patchIndex + "]." + strings.ToLower(field): value}
+		values := map[string]string{"[" + patchIndex + "]." + field: value}
 
 		params := strings.SplitAfter(param, " ")
 		for i := range params {
 			values["["+patchIndex+"]."+strings.ToLower(params[i])] = value
 		}
 
-		err = errs.New(errs.ErrPathAndFromFieldsCannotMatch, 0, values)
+		return errs.ErrPathAndFromFieldsCannotMatch.WithField(pointer).WithValues(values)
+	}
+
+	return errs.ErrInternalServer
+}
+
+// HandlePatchPathError works out a human friendly error for a patch which modifies a path it is not permitted to,
+// in the same format as HandleValidationErrors. The patch index is the index of a JSON Patch operation or the member
+// of a JSON Merge Patch, and the member is the member of the patch holding the path, either "path" or "from".
+func HandlePatchPathError(patchIndex, member, path string, err *errs.Error) error {
+	pointer := "/" + patchIndex
+	if _, convErr := strconv.Atoi(patchIndex); convErr == nil {
+		pointer += "/" + member
+	}
+
+	values := map[string]string{"[" + patchIndex + "]." + member: path}
+	return err.WithField(pointer).WithValues(values)
+}
+
+// PatchedPath identifies a path modified by a patch and the index used to refer to the patch in errors
+type PatchedPath struct {
+	Index string
+	Path  string
+}
+
+// AttributeToPatches prefixes the fields of validation errors with the index of the last patch which modified them, in
+// the same format as HandleValidationErrors, so problems introduced by a patch can be traced back to the operation.
+// Fields left untouched by all patches are reported as they are, as the problem already existed in the document.
+func AttributeToPatches(err error, patchedPaths []PatchedPath) error {
+	fields := make([]PatchedPath, len(patchedPaths))
+	for i := range patchedPaths {
+		fields[i] = PatchedPath{Index: patchedPaths[i].Index, Path: pointerToField(patchedPaths[i].Path)}
+	}
+
+	var list errs.Errors
+	if !errors.As(err, &list) {
+		list = errs.Errors{err}
+	}
+
+	attributed := make(errs.Errors, 0, len(list))
+	for _, e := range list {
+		var apiErr *errs.Error
+		if !errors.As(e, &apiErr) {
+			attributed = append(attributed, e)
+			continue
+		}
+
+		values := make(map[string]string, len(apiErr.Values))
+		var unattributed []string
+
+		for key, value := range apiErr.Values {
+			if key != "fields" {
+				if index, ok := lastPatchedIndex(fields, key); ok {
+					key = "[" + index + "]." + key
+				}
+				values[key] = value
+				continue
+			}
+
+			// missing fields are reported as a list, split out those which were removed by a patch
+			for _, field := range strings.Split(value, helpers.WordSeparator) {
+				if index, ok := lastPatchedIndex(fields, field); ok {
+					values["["+index+"]."+field] = ""
+					continue
+				}
+				unattributed = append(unattributed, field)
+			}
+		}
+
+		if len(unattributed) > 0 {
+			values["fields"] = helpers.StringifyWords(unattributed)
+		}
+
+		attributed = append(attributed, apiErr.WithValues(values))
+	}
+
+	return attributed
+}
+
+// pointerToField converts a JSON pointer into the field notation used by validation errors,
+// e.g. "/ingredients/0/unit" becomes "ingredients.[0].unit"
+func pointerToField(pointer string) string {
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, segment := range segments {
+		if _, err := strconv.Atoi(segment); err == nil {
+			segments[i] = "[" + segment + "]"
+		}
+	}
+
+	return strings.Join(segments, ".")
+}
+
+func lastPatchedIndex(patchedFields []PatchedPath, field string) (string, bool) {
+	for i := len(patchedFields) - 1; i >= 0; i-- {
+		patched := patchedFields[i].Path
+		if field == patched || strings.HasPrefix(field, patched+".") || strings.HasPrefix(patched, field+".") {
+			return patchedFields[i].Index, true
+		}
 	}
 
-	return CreateErrorObject(err)
+	return "", false
 }
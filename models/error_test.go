@@ -0,0 +1,152 @@
+package models_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateErrorResponse(t *testing.T) {
+	Convey("Given a list of API errors", t, func() {
+		err := errs.Append(nil,
+			errs.ErrInvalidVisibility.WithField("/visibility").WithValues(map[string]string{"visibility": "secret"}),
+			errs.ErrRecipeNotFound,
+		)
+
+		Convey("Then each error is reported with the status of the first error", func() {
+			status, response := models.CreateErrorResponse(err)
+
+			So(status, ShouldEqual, http.StatusBadRequest)
+			So(response.Errors, ShouldResemble, []*models.ErrorObject{
+				{
+					Code:        "invalid_visibility",
+					Error:       errs.ErrInvalidVisibility.Error(),
+					Field:       "/visibility",
+					ErrorValues: map[string]string{"visibility": "secret"},
+				},
+				{Code: "recipe_not_found", Error: "recipe not found"},
+			})
+		})
+	})
+
+	Convey("Given a wrapped API error", t, func() {
+		err := fmt.Errorf("get household: %w", errs.ErrHouseholdNotFound)
+
+		Convey("Then the API error is reported", func() {
+			status, response := models.CreateErrorResponse(err)
+
+			So(status, ShouldEqual, http.StatusNotFound)
+			So(response.Errors, ShouldResemble, []*models.ErrorObject{{Code: "household_not_found", Error: "household not found"}})
+		})
+	})
+
+	Convey("Given an error which is not an API error", t, func() {
+		err := errors.New("connection refused")
+
+		Convey("Then an internal server error is reported without the details of the error", func() {
+			status, response := models.CreateErrorResponse(err)
+
+			So(status, ShouldEqual, http.StatusInternalServerError)
+			So(response.Errors, ShouldResemble, []*models.ErrorObject{{Code: "internal_server_error", Error: "internal server error"}})
+		})
+	})
+}
+
+func TestHandlePatchPathError(t *testing.T) {
+	tables := []struct {
+		givenTitle    string
+		patchIndex    string
+		member        string
+		path          string
+		expectedField string
+		expectedKey   string
+	}{
+		{"Given a JSON Patch operation modifying a path it is not permitted to", "2", "path", "/id", "/2/path", "[2].path"},
+		{"Given a JSON Patch operation moving from a path it is not permitted to", "2", "from", "/id", "/2/from", "[2].from"},
+		{"Given a JSON Merge Patch member modifying a path it is not permitted to", "id", "path", "/id", "/id", "[id].path"},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			err := models.HandlePatchPathError(table.patchIndex, table.member, table.path, errs.ErrPathNotPatchable)
+
+			Convey("Then the error refers to the patch in the same format as validation errors", func() {
+				var apiErr *errs.Error
+				So(errors.As(err, &apiErr), ShouldBeTrue)
+				So(apiErr.Code, ShouldEqual, "path_not_patchable")
+				So(apiErr.Field, ShouldEqual, table.expectedField)
+				So(apiErr.Values, ShouldResemble, map[string]string{table.expectedKey: table.path})
+			})
+		})
+	}
+}
+
+func TestAttributeToPatches(t *testing.T) {
+	patchedPaths := []models.PatchedPath{
+		{Index: "0", Path: "/difficulty"},
+		{Index: "1", Path: "/ingredients/0"},
+		{Index: "2", Path: "/difficulty"},
+		{Index: "3", Path: "/location"},
+	}
+
+	Convey("Given validation errors for fields modified by patches", t, func() {
+		err := errs.Append(nil,
+			errs.ErrInvalidDifficulty.WithField("/difficulty").WithValues(map[string]string{"difficulty": "impossible"}),
+			errs.ErrMissingFields.WithValues(map[string]string{"fields": "ingredients.[0].quantity,portion_size"}),
+			errs.ErrInvalidLocation.WithValues(map[string]string{"location.cook_book": "", "location.page": "0"}),
+		)
+
+		attributed := models.AttributeToPatches(err, patchedPaths)
+
+		Convey("Then each field is prefixed with the index of the last patch which modified it or its parent", func() {
+			values := errorValues(attributed)
+			So(values, ShouldHaveLength, 3)
+			So(values[0], ShouldResemble, map[string]string{"[2].difficulty": "impossible"})
+			So(values[1], ShouldResemble, map[string]string{"[1].ingredients.[0].quantity": "", "fields": "portion_size"})
+			So(values[2], ShouldResemble, map[string]string{"[3].location.cook_book": "", "[3].location.page": "0"})
+		})
+	})
+
+	Convey("Given a validation error for a field which is a parent of a patched path", t, func() {
+		err := errs.ErrMissingFields.WithValues(map[string]string{"fields": "ingredients"})
+
+		attributed := models.AttributeToPatches(err, []models.PatchedPath{{Index: "0", Path: "/ingredients/1/item"}})
+
+		Convey("Then the error is attributed to the patch", func() {
+			So(errorValues(attributed), ShouldResemble, []map[string]string{{"[0].ingredients": ""}})
+		})
+	})
+
+	Convey("Given a validation error for a field which no patch modified", t, func() {
+		err := errs.ErrInvalidPortionSize.WithField("/portion_size").WithValues(map[string]string{"portion_size": "-1"})
+
+		attributed := models.AttributeToPatches(err, patchedPaths)
+
+		Convey("Then the error is reported as it is", func() {
+			_, response := models.CreateErrorResponse(attributed)
+			So(response.Errors, ShouldResemble, []*models.ErrorObject{{
+				Code:        "invalid_portion_size",
+				Error:       errs.ErrInvalidPortionSize.Error(),
+				Field:       "/portion_size",
+				ErrorValues: map[string]string{"portion_size": "-1"},
+			}})
+		})
+	})
+}
+
+// errorValues returns the values of each error in a list of errors
+func errorValues(err error) []map[string]string {
+	_, response := models.CreateErrorResponse(err)
+
+	values := make([]map[string]string, 0, len(response.Errors))
+	for _, e := range response.Errors {
+		values = append(values, e.ErrorValues)
+	}
+
+	return values
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+)
+
+// Roles of the members of a household
+const (
+	// RoleOwner members can manage the members of the household and edit or delete its recipes
+	RoleOwner = "owner"
+	// RoleEditor members can share their recipes with the household and edit its recipes
+	RoleEditor = "editor"
+	// RoleViewer members can see the recipes shared with the household
+	RoleViewer = "viewer"
+)
+
+var roles = map[string]bool{
+	RoleOwner:  true,
+	RoleEditor: true,
+	RoleViewer: true,
+}
+
+// Households contains a list of households
+type Households struct {
+	Count int         `json:"count"`
+	Items []Household `json:"items"`
+}
+
+// Household is a group of callers sharing recipes with each other
+type Household struct {
+	ID          string                `bson:"_id"                   json:"id"`
+	Name        string                `bson:"name"                  json:"name"`
+	Members     []HouseholdMember     `bson:"members"               json:"members"`
+	Invitations []HouseholdInvitation `bson:"invitations,omitempty" json:"invitations,omitempty"`
+	CreatedAt   time.Time             `bson:"created_at"            json:"created_at"`
+}
+
+// HouseholdMember is a caller belonging to a household, identified by the name of their API key or the subject of their JWT
+type HouseholdMember struct {
+	Name string `bson:"name" json:"name"`
+	Role string `bson:"role" json:"role"`
+}
+
+// HouseholdInvitation invites a caller to join a household with a role, they become a member once they accept it
+type HouseholdInvitation struct {
+	Name      string    `bson:"name"       json:"name"`
+	Role      string    `bson:"role"       json:"role"`
+	InvitedBy string    `bson:"invited_by" json:"invited_by"`
+	InvitedAt time.Time `bson:"invited_at" json:"invited_at"`
+}
+
+// NewHousehold contains the details of a household to create
+type NewHousehold struct {
+	Name string `json:"name"`
+}
+
+// Role returns the role of the member in the household, or an empty string if they are not a member
+func (household *Household) Role(name string) string {
+	for _, member := range household.Members {
+		if member.Name == name {
+			return member.Role
+		}
+	}
+
+	return ""
+}
+
+// Invitation returns the invitation for the caller with the given name, or nil if they have not been invited
+func (household *Household) Invitation(name string) *HouseholdInvitation {
+	for i := range household.Invitations {
+		if household.Invitations[i].Name == name {
+			return &household.Invitations[i]
+		}
+	}
+
+	return nil
+}
+
+// Owners returns the number of members with the owner role
+func (household *Household) Owners() int {
+	var owners int
+	for _, member := range household.Members {
+		if member.Role == RoleOwner {
+			owners++
+		}
+	}
+
+	return owners
+}
+
+// Validate the member being invited to a household
+func (member *HouseholdMember) Validate() error {
+	var err error
+
+	if member.Name == "" {
+		err = errs.Append(err, errs.ErrMissingFields.WithField("/name").WithValues(map[string]string{"fields": "name"}))
+	}
+
+	if !roles[member.Role] {
+		err = errs.Append(err, errs.ErrInvalidRole.WithField("/role").WithValues(map[string]string{"role": member.Role}))
+	}
+
+	return err
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Modes of importing recipes, deciding what happens to recipes which are already stored
+const (
+	// ImportModeCreateOnly creates recipes which are not stored and leaves stored recipes unchanged
+	ImportModeCreateOnly = "create-only"
+	// ImportModeUpdateExisting creates recipes which are not stored and updates stored recipes which have changed
+	ImportModeUpdateExisting = "update-existing"
+	// ImportModeReplaceAll updates as ImportModeUpdateExisting and also deletes imported recipes which are no longer in
+	// the file
+	ImportModeReplaceAll = "replace-all"
+)
+
+// ImportModes lists the valid modes of importing recipes
+var ImportModes = []string{ImportModeCreateOnly, ImportModeUpdateExisting, ImportModeReplaceAll}
+
+// ImportRun summarises a run of the recipe import, a summary is stored for every run including dry runs
+type ImportRun struct {
+	ID         string    `bson:"_id"             json:"id"`
+	Mode       string    `bson:"mode"            json:"mode"`
+	DryRun     bool      `bson:"dry_run"         json:"dry_run"`
+	StartedAt  time.Time `bson:"started_at"      json:"started_at"`
+	FinishedAt time.Time `bson:"finished_at"     json:"finished_at"`
+	Created    int       `bson:"created"         json:"created"`
+	Updated    int       `bson:"updated"         json:"updated"`
+	Skipped    int       `bson:"skipped"         json:"skipped"`
+	Deleted    int       `bson:"deleted"         json:"deleted"`
+	Failed     int       `bson:"failed"          json:"failed"`
+	// Partial is true if recipes missing from the file were kept, rather than deleted, because rows of the file could
+	// not be read
+	Partial bool   `bson:"partial,omitempty" json:"partial,omitempty"`
+	Error   string `bson:"error,omitempty"   json:"error,omitempty"`
+}
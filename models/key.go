@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKeys contains a list of API keys
+type APIKeys struct {
+	Count int      `json:"count"`
+	Items []APIKey `json:"items"`
+}
+
+// APIKey contains information of an issued API key, the key itself is never stored
+type APIKey struct {
+	Name       string     `bson:"_id"                    json:"name"`
+	Hash       string     `bson:"hash"                   json:"-"`
+	Scopes     []string   `bson:"scopes"                 json:"scopes"`
+	CreatedAt  time.Time  `bson:"created_at"             json:"created_at"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty"   json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `bson:"revoked_at,omitempty"   json:"revoked_at,omitempty"`
+}
+
+// IssuedAPIKey contains a newly issued API key, this is the only time the key is returned
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// NewAPIKey contains the details of an API key to issue
+type NewAPIKey struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyExpiry contains the time an API key expires, a null time removes the expiry
+type APIKeyExpiry struct {
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IsActive returns true if the API key has not been revoked and has not expired
+func (key *APIKey) IsActive(now time.Time) bool {
+	if key.RevokedAt != nil {
+		return false
+	}
+
+	return key.ExpiresAt == nil || now.Before(*key.ExpiresAt)
+}
@@ -1,14 +1,14 @@
 package models
 
 import (
-	"errors"
 	"strconv"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
 )
 
 // ErrorMaximumOffsetReached creates a unique error
-func ErrorMaximumOffsetReached(m int) error {
-	err := errors.New("the maximum offset has been reached, the offset cannot be more than " + strconv.Itoa(m))
-	return err
+func ErrorMaximumOffsetReached(m int) *errs.Error {
+	return errs.ErrOffsetExceeded.WithMessage("the maximum offset has been reached, the offset cannot be more than " + strconv.Itoa(m))
 }
 
 // PageVariables are the necessary fields to determine paging
@@ -19,21 +19,9 @@ type PageVariables struct {
 }
 
 // ValidatePage represents a model for validating combination of offset and limit
-func ValidatePage(page PageVariables) []*ErrorObject {
-	var errorObjects []*ErrorObject
-
+func ValidatePage(page PageVariables) error {
 	if page.Offset >= page.DefaultMaxResults {
-		pagingErrorValue := make(map[string]string)
-		pagingErrorValue["offset"] = strconv.Itoa(page.Offset)
-		errorObjects = append(errorObjects, &ErrorObject{Error: ErrorMaximumOffsetReached(page.DefaultMaxResults).Error(), ErrorValues: pagingErrorValue})
-	}
-
-	if errorObjects != nil {
-		return errorObjects
-	}
-
-	if page.Offset+page.Limit > page.DefaultMaxResults {
-		page.Limit = page.DefaultMaxResults - page.Offset
+		return ErrorMaximumOffsetReached(page.DefaultMaxResults).WithValues(map[string]string{"offset": strconv.Itoa(page.Offset)})
 	}
 
 	return nil
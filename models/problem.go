@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-net/v2/request"
+	"github.com/ONSdigital/log.go/v2/log"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+)
+
+// ProblemMediaType is the media type of problem details, as described in RFC 7807
+const ProblemMediaType = "application/problem+json"
+
+// ProblemDetails describes an unsuccessful request in the format described in RFC 7807. The problem is the first error
+// reported, the code, field and error values of which are included as extension members. When more than one error is
+// reported every error is listed in errors.
+type ProblemDetails struct {
+	Type        string            `json:"type"`
+	Title       string            `json:"title"`
+	Status      int               `json:"status"`
+	Detail      string            `json:"detail,omitempty"`
+	Instance    string            `json:"instance,omitempty"`
+	Code        string            `json:"code"`
+	Field       string            `json:"field,omitempty"`
+	ErrorValues map[string]string `json:"error_values,omitempty"`
+	Errors      []*ErrorObject    `json:"errors,omitempty"`
+}
+
+// CreateProblemDetails formulates the problem details for an error, the instance identifies the occurrence of the
+// problem. Errors which are not API errors are reported as an internal server error so their details are not exposed.
+func CreateProblemDetails(err error, instance string) *ProblemDetails {
+	list := errorList(err)
+	apiErr := apiError(list[0])
+
+	problem := &ProblemDetails{
+		Type:        apiErr.Type(),
+		Title:       apiErr.Title(),
+		Status:      apiErr.Status,
+		Detail:      apiErr.Message,
+		Instance:    instance,
+		Code:        apiErr.Code,
+		Field:       apiErr.Field,
+		ErrorValues: apiErr.Values,
+	}
+
+	if len(list) > 1 {
+		for _, e := range list {
+			problem.Errors = append(problem.Errors, CreateErrorObject(e))
+		}
+	}
+
+	return problem
+}
+
+// NegotiateErrorResponse returns the status, content type and body to respond to an error with, given the Accept header
+// of the request. Problem details are responded with if they are accepted, otherwise a list of errors, both identified
+// by the ID of the request.
+func NegotiateErrorResponse(err error, requestID, accept string) (status int, contentType string, body interface{}) {
+	if AcceptsProblemDetails(accept) {
+		problem := CreateProblemDetails(err, requestID)
+		return problem.Status, ProblemMediaType, problem
+	}
+
+	status, errorResponse := CreateErrorResponse(err)
+	errorResponse.RequestID = requestID
+
+	return status, "application/json", errorResponse
+}
+
+// WriteErrorResponse responds to a request with an error, along with the ID of the request. The status is that of the
+// error, or of the first error in a list of errors, errors which are not API errors are reported as an internal server
+// error without exposing their details. Callers accepting problem details are responded to with problem details, as
+// described in RFC 7807, in place of a list of errors.
+func WriteErrorResponse(w http.ResponseWriter, req *http.Request, err error) {
+	ctx := req.Context()
+
+	status, contentType, body := NegotiateErrorResponse(err, request.GetRequestId(ctx), req.Header.Get("Accept"))
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, errs.ErrInternalServer.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+
+	if _, err = w.Write(b); err != nil {
+		log.Error(ctx, "failed to write error response body", err)
+	}
+}
+
+// AcceptsProblemDetails returns true if the Accept header of a request includes problem details, in which case errors are
+// responded with as problem details in place of the default error response
+func AcceptsProblemDetails(accept string) bool {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(mediaRange)
+		if err != nil || mediaType != ProblemMediaType {
+			continue
+		}
+
+		if q, ok := params["q"]; ok {
+			if weight, parseErr := strconv.ParseFloat(q, 64); parseErr != nil || weight <= 0 {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,118 @@
+package models_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCreateProblemDetails(t *testing.T) {
+	Convey("Given an API error", t, func() {
+		err := errs.ErrRecipeNotFound
+
+		Convey("Then the problem details describe the error, identified by the request", func() {
+			problem := models.CreateProblemDetails(err, "abc123")
+
+			So(problem, ShouldResemble, &models.ProblemDetails{
+				Type:     "urn:food-recipes:problem:recipe_not_found",
+				Title:    "Recipe not found",
+				Status:   http.StatusNotFound,
+				Detail:   "recipe not found",
+				Instance: "abc123",
+				Code:     "recipe_not_found",
+			})
+		})
+	})
+
+	Convey("Given a list of API errors", t, func() {
+		err := errs.Append(nil,
+			errs.ErrLimitExceeded.WithMessage("limit cannot be greater than [1000]").WithValues(map[string]string{"limit": "2000"}),
+			errs.ErrNegativeOffset.WithValues(map[string]string{"offset": "-1"}),
+		)
+
+		Convey("Then the problem is the first error and every error is listed", func() {
+			problem := models.CreateProblemDetails(err, "abc123")
+
+			So(problem.Type, ShouldEqual, "urn:food-recipes:problem:limit_exceeded")
+			So(problem.Title, ShouldEqual, "Limit exceeded")
+			So(problem.Status, ShouldEqual, http.StatusBadRequest)
+			So(problem.Detail, ShouldEqual, "limit cannot be greater than [1000]")
+			So(problem.ErrorValues, ShouldResemble, map[string]string{"limit": "2000"})
+			So(problem.Errors, ShouldHaveLength, 2)
+			So(problem.Errors[1].Code, ShouldEqual, "negative_offset")
+		})
+	})
+
+	Convey("Given an error which is not an API error", t, func() {
+		Convey("Then an internal server error is described without the details of the error", func() {
+			problem := models.CreateProblemDetails(errors.New("connection refused"), "")
+
+			So(problem.Status, ShouldEqual, http.StatusInternalServerError)
+			So(problem.Detail, ShouldEqual, "internal server error")
+		})
+	})
+}
+
+func TestAcceptsProblemDetails(t *testing.T) {
+	tables := []struct {
+		accept   string
+		expected bool
+	}{
+		{"", false},
+		{"application/json", false},
+		{"*/*", false},
+		{"application/problem+json", true},
+		{"application/json, application/problem+json;q=0.9", true},
+		{"application/problem+json;q=0", false},
+	}
+
+	for _, table := range tables {
+		Convey("Given the Accept header "+table.accept, t, func() {
+			Convey("Then whether problem details are accepted is returned", func() {
+				So(models.AcceptsProblemDetails(table.accept), ShouldEqual, table.expected)
+			})
+		})
+	}
+}
+
+func TestWriteErrorResponse(t *testing.T) {
+	Convey("Given an error responded to a caller accepting problem details", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/recipes/pancakes", http.NoBody)
+		req.Header.Set("Accept", models.ProblemMediaType)
+		w := httptest.NewRecorder()
+
+		models.WriteErrorResponse(w, req, errs.ErrRecipeNotFound)
+
+		Convey("Then the response is the problem details of the error", func() {
+			So(w.Code, ShouldEqual, http.StatusNotFound)
+			So(w.Header().Get("Content-Type"), ShouldEqual, models.ProblemMediaType)
+			So(w.Header().Get("Vary"), ShouldEqual, "Accept")
+
+			var problem models.ProblemDetails
+			So(json.Unmarshal(w.Body.Bytes(), &problem), ShouldBeNil)
+			So(problem.Code, ShouldEqual, "recipe_not_found")
+		})
+	})
+
+	Convey("Given an error responded to a caller which does not accept problem details", t, func() {
+		req := httptest.NewRequest(http.MethodGet, "/recipes", http.NoBody)
+		w := httptest.NewRecorder()
+
+		models.WriteErrorResponse(w, req, errs.ErrNegativeLimit)
+
+		Convey("Then the response is a list of errors", func() {
+			So(w.Code, ShouldEqual, http.StatusBadRequest)
+			So(w.Header().Get("Content-Type"), ShouldEqual, "application/json")
+
+			var errorResponse models.ErrorResponse
+			So(json.Unmarshal(w.Body.Bytes(), &errorResponse), ShouldBeNil)
+			So(errorResponse.Errors[0].Code, ShouldEqual, "negative_limit")
+		})
+	})
+}
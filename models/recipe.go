@@ -30,6 +30,65 @@ type Recipe struct {
 	PortionSize int          `bson:"portion_size"                json:"portion_size"`
 	Tags        []string     `bson:"tags,omitempty"              json:"tags,omitempty"`
 	Title       string       `bson:"title"                       json:"title"`
+	Owner       string       `bson:"owner,omitempty"             json:"owner,omitempty"`
+	Visibility  string       `bson:"visibility,omitempty"        json:"visibility,omitempty"`
+	Household   string       `bson:"household,omitempty"         json:"household,omitempty"`
+}
+
+// Visibility of a recipe to callers other than its owner
+const (
+	// VisibilityPrivate recipes can only be seen by their owner
+	VisibilityPrivate = "private"
+	// VisibilityHousehold recipes can be seen by the members of the household they are shared with
+	VisibilityHousehold = "household"
+	// VisibilityPublic recipes can be seen by anyone, recipes stored without a visibility are public
+	VisibilityPublic = "public"
+)
+
+var visibilities = map[string]bool{
+	VisibilityPrivate:   true,
+	VisibilityHousehold: true,
+	VisibilityPublic:    true,
+}
+
+// IsPublic returns true if the recipe can be seen by anyone
+func (recipe *Recipe) IsPublic() bool {
+	return recipe.Visibility == "" || recipe.Visibility == VisibilityPublic
+}
+
+// RecipeFilter selects the recipes visible to a caller, recipes which are public or have no owner are always selected
+type RecipeFilter struct {
+	// All selects every recipe, regardless of who can see it
+	All bool
+	// Owner selects the recipes owned by the caller
+	Owner string
+	// Households selects the recipes shared with the households the caller is a member of
+	Households []string
+}
+
+// Matches returns true if the filter selects the recipe
+func (filter *RecipeFilter) Matches(recipe *Recipe) bool {
+	if filter.All || recipe.IsPublic() || recipe.Owner == "" || (filter.Owner != "" && recipe.Owner == filter.Owner) {
+		return true
+	}
+
+	if recipe.Visibility != VisibilityHousehold {
+		return false
+	}
+
+	for _, household := range filter.Households {
+		if household == recipe.Household {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RecipeAlias maps a previous identifier of a recipe to its current identifier
+type RecipeAlias struct {
+	ID       string `bson:"_id"       json:"id"`
+	RecipeID string `bson:"recipe_id" json:"recipe_id"`
 }
 
 // UpdateRecipe TODO probably needs to be removed and logic using this updated to use Patch
@@ -44,6 +103,8 @@ type UpdateRecipe struct {
 	PortionSize int          `bson:"portion_size"                json:"portion_size"`
 	Tags        []string     `bson:"tags,omitempty"              json:"tags,omitempty"`
 	Title       string       `bson:"title"                       json:"title"`
+	Visibility  string       `bson:"visibility,omitempty"        json:"visibility,omitempty"`
+	Household   string       `bson:"household,omitempty"         json:"household,omitempty"`
 }
 
 // Location contains location information for recipe
@@ -78,13 +139,19 @@ var validUnits = map[string]bool{
 }
 
 // Validate recipe creation
-func (recipe *Recipe) Validate() []*ErrorObject {
+func (recipe *Recipe) Validate() error {
 	return validate(recipe, false)
 }
 
 // Validate recipe creation
-func (updateRecipe *UpdateRecipe) Validate() []*ErrorObject {
-	recipe := &Recipe{
+func (updateRecipe *UpdateRecipe) Validate() error {
+	return validate(updateRecipe.ToRecipe(""), true)
+}
+
+// ToRecipe returns the recipe which is stored when replacing the recipe with the given id
+func (updateRecipe *UpdateRecipe) ToRecipe(id string) *Recipe {
+	return &Recipe{
+		ID:          id,
 		CookTime:    updateRecipe.CookTime,
 		Difficulty:  updateRecipe.Difficulty,
 		Extras:      updateRecipe.Extras,
@@ -95,14 +162,32 @@ func (updateRecipe *UpdateRecipe) Validate() []*ErrorObject {
 		PortionSize: updateRecipe.PortionSize,
 		Tags:        updateRecipe.Tags,
 		Title:       updateRecipe.Title,
+		Visibility:  updateRecipe.Visibility,
+		Household:   updateRecipe.Household,
 	}
+}
 
-	return validate(recipe, true)
+// ToUpdateRecipe returns the members of the recipe which can be changed by replacing it
+func (recipe *Recipe) ToUpdateRecipe() *UpdateRecipe {
+	return &UpdateRecipe{
+		CookTime:    recipe.CookTime,
+		Difficulty:  recipe.Difficulty,
+		Extras:      recipe.Extras,
+		Favourite:   recipe.Favourite,
+		Ingredients: recipe.Ingredients,
+		Location:    recipe.Location,
+		Notes:       recipe.Notes,
+		PortionSize: recipe.PortionSize,
+		Tags:        recipe.Tags,
+		Title:       recipe.Title,
+		Visibility:  recipe.Visibility,
+		Household:   recipe.Household,
+	}
 }
 
-func validate(recipe *Recipe, isUpdate bool) []*ErrorObject {
+func validate(recipe *Recipe, isUpdate bool) error {
 	var (
-		errorObjects  []*ErrorObject
+		err           error
 		missingFields []string
 		invalidUnits  = make(map[string]string)
 	)
@@ -114,7 +199,7 @@ func validate(recipe *Recipe, isUpdate bool) []*ErrorObject {
 	lcDiff := strings.ToLower(recipe.Difficulty)
 	if !difficulty[lcDiff] {
 		invalidDifficulty := map[string]string{"difficulty": recipe.Difficulty}
-		errorObjects = append(errorObjects, &ErrorObject{Error: errs.ErrMissingFields.Error(), ErrorValues: invalidDifficulty})
+		err = errs.Append(err, errs.ErrInvalidDifficulty.WithField("/difficulty").WithValues(invalidDifficulty))
 	}
 
 	// use lower case difficulty value
@@ -128,8 +213,8 @@ func validate(recipe *Recipe, isUpdate bool) []*ErrorObject {
 
 	missingFields = append(missingFields, validateIngredients("ingredients", recipe.Ingredients, invalidUnits)...)
 
-	if errorObject := validateLocation(recipe.Location); errorObject != nil {
-		errorObjects = append(errorObjects, errorObject)
+	if locationErr := validateLocation(recipe.Location); locationErr != nil {
+		err = errs.Append(err, locationErr)
 	}
 
 	if recipe.PortionSize == 0 {
@@ -137,29 +222,34 @@ func validate(recipe *Recipe, isUpdate bool) []*ErrorObject {
 	}
 
 	if recipe.PortionSize < 0 {
-		errorObjects = append(errorObjects, &ErrorObject{Error: errs.ErrInvalidPortionSize.Error(), ErrorValues: map[string]string{"portion_size": strconv.Itoa(recipe.PortionSize)}})
+		portionSize := map[string]string{"portion_size": strconv.Itoa(recipe.PortionSize)}
+		err = errs.Append(err, errs.ErrInvalidPortionSize.WithField("/portion_size").WithValues(portionSize))
+	}
+
+	if recipe.Visibility != "" && !visibilities[recipe.Visibility] {
+		err = errs.Append(err, errs.ErrInvalidVisibility.WithField("/visibility").WithValues(map[string]string{"visibility": recipe.Visibility}))
+	}
+
+	if recipe.Visibility == VisibilityHousehold && recipe.Household == "" {
+		err = errs.Append(err, errs.ErrHouseholdRequired.WithField("/household").WithValues(map[string]string{"household": ""}))
 	}
 
 	if !isUpdate && recipe.Title == "" {
 		missingFields = append(missingFields, "title")
 	} else if isUpdate && recipe.Title != "" {
-		errorObjects = append(errorObjects, &ErrorObject{Error: errs.ErrUnableToChangeTitle.Error(), ErrorValues: map[string]string{"title": recipe.Title}})
+		err = errs.Append(err, errs.ErrUnableToChangeTitle.WithField("/title").WithValues(map[string]string{"title": recipe.Title}))
 	}
 
 	if len(missingFields) > 0 {
 		missingFieldList := map[string]string{"fields": helpers.StringifyWords(missingFields)}
-		errorObjects = append(errorObjects, &ErrorObject{Error: errs.ErrMissingFields.Error(), ErrorValues: missingFieldList})
+		err = errs.Append(err, errs.ErrMissingFields.WithValues(missingFieldList))
 	}
 
 	if len(invalidUnits) > 0 {
-		errorObjects = append(errorObjects, &ErrorObject{Error: errs.ErrInvalidUnits.Error(), ErrorValues: invalidUnits})
-	}
-
-	if errorObjects != nil {
-		return errorObjects
+		err = errs.Append(err, errs.ErrInvalidUnits.WithValues(invalidUnits))
 	}
 
-	return nil
+	return err
 }
 
 func validateIngredients(fieldName string, ingredients []Ingredient, invalidUnits map[string]string) (missingFields []string) {
@@ -182,7 +272,7 @@ func validateIngredients(fieldName string, ingredients []Ingredient, invalidUnit
 	return
 }
 
-func validateLocation(location Location) (err *ErrorObject) {
+func validateLocation(location Location) (err error) {
 	var isLink bool
 
 	if location.Link != "" {
@@ -199,23 +289,27 @@ func validateLocation(location Location) (err *ErrorObject) {
 		// have cookbook details
 		if isLink {
 			// cant have both link and cookbook
-			err = &ErrorObject{Error: "cannot contain both cook book details and link", ErrorValues: errorValues}
+			err = invalidLocation("cannot contain both cook book details and link", errorValues)
 		}
 	} else if location.CookBook == "" && location.Page == 0 {
 		// do not have cookbook details
 		if !isLink {
 			// cant have neither link or cookbook
-			err = &ErrorObject{Error: "missing link or cook book details", ErrorValues: map[string]string{"location": "{}"}}
+			err = invalidLocation("missing link or cook book details", map[string]string{"location": "{}"})
 		}
 	} else {
 		// invalid cookbook details
 		if isLink {
-			err = &ErrorObject{Error: "invalid cookbook details and competing link", ErrorValues: errorValues}
+			err = invalidLocation("invalid cookbook details and competing link", errorValues)
 		} else {
 			delete(errorValues, "location.link")
-			err = &ErrorObject{Error: "invalid cookbook details", ErrorValues: errorValues}
+			err = invalidLocation("invalid cookbook details", errorValues)
 		}
 	}
 
 	return err
 }
+
+func invalidLocation(message string, values map[string]string) error {
+	return errs.ErrInvalidLocation.WithMessage(message).WithField("/location").WithValues(values)
+}
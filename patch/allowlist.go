@@ -0,0 +1,116 @@
+package patch
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+)
+
+// PathRule decides whether a patch can be applied to a path in the allowlist, returning an error to reject the patch
+type PathRule func(p *Patch) error
+
+// Allowlist maps the top level members of a document to the rule governing patches against them. A nil rule allows
+// any patch against the member and its children, members missing from the allowlist cannot be patched.
+type Allowlist map[string]PathRule
+
+var (
+	pointerEscaper   = strings.NewReplacer("~", "~0", "/", "~1")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// PathError is returned by Check for a patch rejected because of one of its paths, identifying the member of the
+// patch holding the path, either "path" or "from"
+type PathError struct {
+	Member string
+	Path   string
+	Err    error
+}
+
+func (e *PathError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+// Check returns a *PathError if the patch modifies a path which is not in the allowlist or is rejected by the path's
+// rule; for move operations the from path is also checked as its value is removed from the document
+func (a Allowlist) Check(p *Patch) error {
+	if err := a.check(p, "path", p.Path); err != nil {
+		return err
+	}
+
+	if p.Op == OpMove.String() {
+		return a.check(p, from, p.From)
+	}
+
+	return nil
+}
+
+// check returns a *PathError if the path held by the member of the patch is rejected by the allowlist
+func (a Allowlist) check(p *Patch, member, path string) error {
+	rule, ok := a[Member(path)]
+	if !ok {
+		return &PathError{Member: member, Path: path, Err: errs.ErrPathNotPatchable}
+	}
+
+	if rule == nil {
+		return nil
+	}
+
+	if err := rule(p); err != nil {
+		return &PathError{Member: member, Path: path, Err: err}
+	}
+
+	return nil
+}
+
+// Member returns the top level member of a document referenced by a JSON pointer,
+// e.g. "/ingredients/0/unit" returns "ingredients"
+func Member(pointer string) string {
+	if !strings.HasPrefix(pointer, "/") {
+		return ""
+	}
+
+	member := pointer[1:]
+	if i := strings.IndexByte(member, '/'); i >= 0 {
+		member = member[:i]
+	}
+
+	return pointerUnescaper.Replace(member)
+}
+
+// MergeOps returns the patch operations equivalent to each top level member of a merge patch, sorted by member name.
+// Members set to null remove the value from the document and all other members replace it.
+func MergeOps(mergePatch []byte) ([]Patch, error) {
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(mergePatch, &members); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(members))
+	for name := range members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	patches := make([]Patch, 0, len(names))
+	for _, name := range names {
+		p := Patch{Op: OpReplace.String(), Path: "/" + pointerEscaper.Replace(name)}
+
+		if err := json.Unmarshal(members[name], &p.Value); err != nil {
+			return nil, err
+		}
+
+		if p.Value == nil {
+			p.Op = OpRemove.String()
+		}
+
+		patches = append(patches, p)
+	}
+
+	return patches, nil
+}
@@ -0,0 +1,115 @@
+package patch_test
+
+import (
+	"errors"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errRejected = errors.New("rejected by rule")
+
+func TestAllowlistCheck(t *testing.T) {
+	allowlist := patch.Allowlist{
+		"notes": nil,
+		"title": func(p *patch.Patch) error {
+			if p.Op != opTest {
+				return errRejected
+			}
+			return nil
+		},
+	}
+
+	tables := []struct {
+		givenTitle     string
+		thenTitle      string
+		patch          *patch.Patch
+		expectedError  error
+		expectedMember string
+		expectedPath   string
+	}{
+		{
+			"Given a patch against an allowed member", "Then the patch is permitted",
+			&patch.Patch{Op: opReplace, Path: "/notes", Value: "serve warm"}, nil, "", "",
+		},
+		{
+			"Given a patch against the child of an allowed member", "Then the patch is permitted",
+			&patch.Patch{Op: opAdd, Path: "/notes/0", Value: "serve warm"}, nil, "", "",
+		},
+		{
+			"Given a patch against a member missing from the allowlist", "Then the patch is rejected",
+			&patch.Patch{Op: opReplace, Path: "/id", Value: "new-id"}, errs.ErrPathNotPatchable, "path", "/id",
+		},
+		{
+			"Given a patch against the whole document", "Then the patch is rejected",
+			&patch.Patch{Op: opReplace, Path: "", Value: "{}"}, errs.ErrPathNotPatchable, "path", "",
+		},
+		{
+			"Given a move operation from a member missing from the allowlist", "Then the patch is rejected because of its from path",
+			&patch.Patch{Op: opMove, Path: "/notes", From: "/id"}, errs.ErrPathNotPatchable, "from", "/id",
+		},
+		{
+			"Given a copy operation from a member missing from the allowlist", "Then the patch is permitted",
+			&patch.Patch{Op: opCopy, Path: "/notes", From: "/id"}, nil, "", "",
+		},
+		{
+			"Given a patch which satisfies the rule for the member", "Then the patch is permitted",
+			&patch.Patch{Op: opTest, Path: "/title", Value: "Pad Thai"}, nil, "", "",
+		},
+		{
+			"Given a patch which does not satisfy the rule for the member", "Then the error from the rule is returned",
+			&patch.Patch{Op: opReplace, Path: "/title", Value: "Pad Thai"}, errRejected, "path", "/title",
+		},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			err := allowlist.Check(table.patch)
+
+			Convey(table.thenTitle, func() {
+				if table.expectedError == nil {
+					So(err, ShouldBeNil)
+					return
+				}
+
+				var pathErr *patch.PathError
+				So(errors.As(err, &pathErr), ShouldBeTrue)
+				So(pathErr.Err, ShouldEqual, table.expectedError)
+				So(pathErr.Member, ShouldEqual, table.expectedMember)
+				So(pathErr.Path, ShouldEqual, table.expectedPath)
+				So(errors.Is(err, table.expectedError), ShouldBeTrue)
+			})
+		})
+	}
+}
+
+func TestMember(t *testing.T) {
+	Convey("Given a JSON pointer", t, func() {
+		Convey("Then the top level member it references is returned", func() {
+			So(patch.Member("/title"), ShouldEqual, "title")
+			So(patch.Member("/ingredients/0/unit"), ShouldEqual, "ingredients")
+			So(patch.Member("/a~1b~0c/d"), ShouldEqual, "a/b~c")
+			So(patch.Member("/"), ShouldEqual, "")
+			So(patch.Member("title"), ShouldEqual, "")
+		})
+	})
+}
+
+func TestMergeOps(t *testing.T) {
+	Convey("Given a merge patch", t, func() {
+		mergePatch := []byte(`{"title": "Pad Thai", "notes": null, "location": {"page": 12}}`)
+
+		Convey("Then each member is converted into an equivalent operation sorted by member name", func() {
+			ops, err := patch.MergeOps(mergePatch)
+
+			So(err, ShouldBeNil)
+			So(ops, ShouldResemble, []patch.Patch{
+				{Op: opReplace, Path: "/location", Value: map[string]interface{}{"page": float64(12)}},
+				{Op: opRemove, Path: "/notes"},
+				{Op: opReplace, Path: "/title", Value: "Pad Thai"},
+			})
+		})
+	})
+}
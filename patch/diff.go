@@ -0,0 +1,68 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+)
+
+// Diff returns the patch operations which change the original JSON document into the modified document. Objects are
+// compared member by member, members which are added or removed produce add and remove operations and changed values
+// produce replace operations. Arrays are replaced as a whole when they differ and members set to null are removed, as
+// a patch cannot set a null value.
+func Diff(original, modified []byte) ([]Patch, error) {
+	var from, to interface{}
+	if err := json.Unmarshal(original, &from); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(modified, &to); err != nil {
+		return nil, err
+	}
+
+	return diff("", from, to, []Patch{}), nil
+}
+
+func diff(path string, from, to interface{}, patches []Patch) []Patch {
+	if reflect.DeepEqual(from, to) {
+		return patches
+	}
+
+	fromObject, fromIsObject := from.(map[string]interface{})
+	toObject, toIsObject := to.(map[string]interface{})
+
+	if !fromIsObject || !toIsObject {
+		if to == nil {
+			return append(patches, Patch{Op: OpRemove.String(), Path: path})
+		}
+		return append(patches, Patch{Op: OpReplace.String(), Path: path, Value: to})
+	}
+
+	names := make([]string, 0, len(fromObject)+len(toObject))
+	for name := range fromObject {
+		names = append(names, name)
+	}
+	for name := range toObject {
+		if _, ok := fromObject[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		memberPath := path + "/" + pointerEscaper.Replace(name)
+		fromValue, inFrom := fromObject[name]
+		toValue, inTo := toObject[name]
+
+		switch {
+		case !inTo || (toValue == nil && inFrom && fromValue != nil):
+			patches = append(patches, Patch{Op: OpRemove.String(), Path: memberPath})
+		case !inFrom && toValue != nil:
+			patches = append(patches, Patch{Op: OpAdd.String(), Path: memberPath, Value: toValue})
+		case inFrom:
+			patches = diff(memberPath, fromValue, toValue, patches)
+		}
+	}
+
+	return patches
+}
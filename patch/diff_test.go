@@ -0,0 +1,57 @@
+package patch_test
+
+import (
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/patch"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiff(t *testing.T) {
+	Convey("Given two identical documents", t, func() {
+		doc := []byte(`{"title":"Pad Thai","tags":["noodles"],"location":{"page":12}}`)
+
+		Convey("Then no operations are returned", func() {
+			patches, err := patch.Diff(doc, doc)
+			So(err, ShouldBeNil)
+			So(patches, ShouldBeEmpty)
+		})
+	})
+
+	Convey("Given a document with added, removed and changed members", t, func() {
+		original := []byte(`{"title":"Pad Thai","notes":"serve warm","tags":["noodles"],"location":{"page":12,"cook_book":"Thai"}}`)
+		modified := []byte(`{"title":"Pad Thai","cook_time":20,"tags":["noodles","quick"],"location":{"page":14,"cook_book":"Thai"}}`)
+
+		Convey("Then operations are returned for each change, sorted by path", func() {
+			patches, err := patch.Diff(original, modified)
+			So(err, ShouldBeNil)
+			So(patches, ShouldResemble, []patch.Patch{
+				{Op: opAdd, Path: "/cook_time", Value: float64(20)},
+				{Op: opReplace, Path: "/location/page", Value: float64(14)},
+				{Op: opRemove, Path: "/notes"},
+				{Op: opReplace, Path: "/tags", Value: []interface{}{"noodles", "quick"}},
+			})
+		})
+	})
+
+	Convey("Given a document with members set to null", t, func() {
+		original := []byte(`{"notes":"serve warm","a/b":1}`)
+		modified := []byte(`{"notes":null,"a/b":2,"tags":null}`)
+
+		Convey("Then the members are removed and member names are escaped", func() {
+			patches, err := patch.Diff(original, modified)
+			So(err, ShouldBeNil)
+			So(patches, ShouldResemble, []patch.Patch{
+				{Op: opReplace, Path: "/a~1b", Value: float64(2)},
+				{Op: opRemove, Path: "/notes"},
+			})
+		})
+	})
+
+	Convey("Given a document which is not valid JSON", t, func() {
+		Convey("Then an error is returned", func() {
+			_, err := patch.Diff([]byte(`{}`), []byte(`{`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
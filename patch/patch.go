@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	errs "github.com/nshumoogum/food-recipes/apierrors"
 )
 
 // Possible patch operations
@@ -24,6 +25,12 @@ const (
 	from  = "from"
 )
 
+// Media types of the supported patch document formats
+const (
+	JSONPatchMediaType  = "application/json-patch+json"
+	MergePatchMediaType = "application/merge-patch+json"
+)
+
 var validOps = []string{"add", "copy", "move", "remove", "replace", "test"}
 
 // Ops - a list of patch operations
@@ -56,32 +63,55 @@ func (ops Ops) StringSlice() []string {
 func Get(ctx context.Context, requestBody io.ReadCloser) ([]byte, *[]Patch, error) {
 	b, err := io.ReadAll(requestBody)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read and get patch request body")
+		return nil, nil, errs.ErrUnableToReadMessage
 	}
 
 	if len(b) == 0 {
-		return nil, nil, fmt.Errorf("empty request body given")
+		return nil, nil, errs.ErrEmptyRequestBody
 	}
 
 	patches := []Patch{}
 	err = json.Unmarshal(b, &patches)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal patch request body")
+		return nil, nil, errs.ErrUnableToParsePatch
 	}
 
 	if len(patches) < 1 {
-		return nil, nil, fmt.Errorf("no patches given in request body")
+		return nil, nil, errs.ErrNoPatches
 	}
 
 	return b, &patches, nil
 }
 
+// GetMerge gets a merge patch from the request body, according to RFC 7396, and returns the raw
+// document; only JSON objects are accepted as a merge patch can only be applied to a whole resource
+func GetMerge(ctx context.Context, requestBody io.ReadCloser) ([]byte, error) {
+	b, err := io.ReadAll(requestBody)
+	if err != nil {
+		return nil, errs.ErrUnableToReadMessage
+	}
+
+	if len(b) == 0 {
+		return nil, errs.ErrEmptyRequestBody
+	}
+
+	members := map[string]json.RawMessage{}
+	err = json.Unmarshal(b, &members)
+	if err != nil {
+		return nil, errs.ErrUnableToParseMergePatch
+	}
+
+	if len(members) < 1 {
+		return nil, errs.ErrNoChanges
+	}
+
+	return b, nil
+}
+
 // Validate against patch object to check fundamental data of the patch object
 func (p *Patch) Validate(supportedOps *Ops) error {
 	validate := validator.New()
 
-	fmt.Printf("Patch is: %v", p)
-
 	// Validate possible operations
 	if err := validate.RegisterValidation("supportedops", getOpsValidator(supportedOps, p)); err != nil {
 		return fmt.Errorf("failed to register ops validator: %w", err)
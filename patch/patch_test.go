@@ -1,6 +1,9 @@
 package patch_test
 
 import (
+	"context"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
@@ -25,6 +28,10 @@ var (
 	missingFrom           = "Key: 'Patch.Op' Error:Field validation for 'Op' failed on the 'requirefromifopis' tag"
 	sameFromAndPathValues = "Key: 'Patch.From' Error:Field validation for 'From' failed on the 'nefield' tag"
 
+	emptyBody   = "empty request body given"
+	notAnObject = "failed to unmarshal merge patch request body, expected a json object"
+	noChanges   = "no changes given in request body"
+
 	allSupportedOps = patch.Ops{patch.OpAdd, patch.OpCopy, patch.OpMove, patch.OpRemove, patch.OpReplace, patch.OpTest}
 )
 
@@ -125,6 +132,49 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestGetMerge(t *testing.T) {
+	tables := []struct {
+		givenTitle          string
+		thenTitle           string
+		body                string
+		expectedErrorString *string
+	}{
+		{
+			"Given a merge patch containing changes", "Then the merge patch is returned",
+			`{"notes": "serve warm", "tags": null}`, nil,
+		},
+		{
+			"Given an empty request body", "Then an error is returned",
+			"", &emptyBody,
+		},
+		{
+			"Given a request body containing a list of patch operations", "Then an error is returned",
+			`[{"op": "remove", "path": "/notes"}]`, &notAnObject,
+		},
+		{
+			"Given a merge patch without any members", "Then an error is returned",
+			`{}`, &noChanges,
+		},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			Convey(table.thenTitle, func() {
+				b, err := patch.GetMerge(context.Background(), io.NopCloser(strings.NewReader(table.body)))
+
+				if table.expectedErrorString != nil {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, *table.expectedErrorString)
+					So(b, ShouldBeNil)
+				} else {
+					So(err, ShouldBeNil)
+					So(string(b), ShouldEqual, table.body)
+				}
+			})
+		})
+	}
+}
+
 func getPatch(patchType, missingField string, fromIsSameAsPath bool) *patch.Patch {
 	p := &patch.Patch{
 		Op: patchType,
@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
+	"time"
 
 	"github.com/ONSdigital/go-ns/server"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -10,8 +13,14 @@ import (
 	"github.com/ONSdigital/log.go/v2/log"
 	"github.com/gorilla/mux"
 	"github.com/nshumoogum/food-recipes/api"
+	"github.com/nshumoogum/food-recipes/auth"
 	"github.com/nshumoogum/food-recipes/config"
-	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/health"
+	"github.com/nshumoogum/food-recipes/metrics"
+	"github.com/nshumoogum/food-recipes/middleware"
+	"github.com/nshumoogum/food-recipes/store"
+	"github.com/nshumoogum/food-recipes/tlsconfig"
+	"github.com/nshumoogum/food-recipes/tracing"
 	"github.com/pkg/errors"
 )
 
@@ -39,15 +48,28 @@ type Closer interface {
 type Service struct {
 	api         *api.FoodRecipeAPI
 	config      *config.Configuration
+	dataStore   *store.Mongo
+	health      *health.Health
+	metrics     *metrics.Metrics
 	mongoClient *mongo.Client
 	server      HTTPServer
 }
 
-// New creates a new service
-func New(cfg *config.Configuration, mongoClient *mongo.Client) *Service {
+// New creates a new service for the build, reporting the result of the import of recipes from the Google Sheet in its
+// health checks and recording metrics about the API
+func New(cfg *config.Configuration, mongoClient *mongo.Client, build health.BuildInfo, importStatus *health.ImportStatus,
+	appMetrics *metrics.Metrics) *Service {
+	dataStore := store.NewMongo(mongoClient, cfg.MongoConfig.Database, cfg.MongoConfig.Collection)
+
 	svc := &Service{
-		api:         &api.FoodRecipeAPI{},
-		config:      cfg,
+		api:       &api.FoodRecipeAPI{},
+		config:    cfg,
+		dataStore: dataStore,
+		health: health.New(build,
+			health.Check{Name: "mongodb", Critical: true, Run: dataStore.Check},
+			health.Check{Name: "google sheet import", Run: importStatus.Check},
+		),
+		metrics:     appMetrics,
 		mongoClient: mongoClient,
 	}
 
@@ -55,16 +77,108 @@ func New(cfg *config.Configuration, mongoClient *mongo.Client) *Service {
 }
 
 // Run the service
-func (svc *Service) Run(ctx context.Context, recipeData map[string]models.Recipe, svcErrors chan error) (err error) {
+func (svc *Service) Run(ctx context.Context, svcErrors chan error) (err error) {
 	// Get HTTP router and server with middleware
 	router := mux.NewRouter()
-	svc.api = api.NewFoodRecipeAPI(ctx, svc.config.ConnectionString, svc.mongoClient, recipeData, svc.config.DefaultMaxResults, router)
+	apiKeys, err := auth.ParseAPIKeys(svc.config.APIKeys)
+	if err != nil {
+		return errors.Wrap(err, "invalid api keys configuration")
+	}
+
+	if len(apiKeys) == 0 {
+		log.Warn(ctx, "no api keys configured, configure a key with the admin scope to issue api keys")
+	}
+
+	reservedKeyNames := make([]string, 0, len(apiKeys))
+	for i := range apiKeys {
+		reservedKeyNames = append(reservedKeyNames, apiKeys[i].Name)
+	}
 
-	s := server.New(svc.config.BindAddr, router)
+	dataStore := svc.dataStore
+	if err = dataStore.Init(ctx); err != nil {
+		log.Error(ctx, "failed to initialise data store, continuing to load API", err)
+	}
+
+	authenticator := &auth.Authenticator{Keys: auth.NewKeyStore(apiKeys, dataStore)}
+
+	if jwtConfig := svc.config.JWTConfig; jwtConfig.JWKSFile != "" {
+		keys, err := auth.LoadJWKS(jwtConfig.JWKSFile)
+		if err != nil {
+			return errors.Wrap(err, "invalid jwt configuration")
+		}
+
+		authenticator.JWT = auth.NewJWTAuthenticator(keys, auth.JWTConfig{
+			Audience:   jwtConfig.Audience,
+			Issuer:     jwtConfig.Issuer,
+			Leeway:     jwtConfig.Leeway,
+			ScopeClaim: jwtConfig.ScopeClaim,
+		})
+
+		log.Info(ctx, "jwt authentication enabled", log.Data{"jwks_file": jwtConfig.JWKSFile, "keys": len(keys)})
+	}
+
+	svc.metrics.RegisterRecipeCounter(dataStore.CountRecipesByDifficulty)
+
+	rateLimitConfig := svc.config.RateLimitConfig
+	rateLimiter := middleware.NewRateLimiter(
+		middleware.Limit{Rate: rateLimitConfig.KeyRate, Burst: rateLimitConfig.KeyBurst},
+		middleware.Limit{Rate: rateLimitConfig.IPRate, Burst: rateLimitConfig.IPBurst},
+		rateLimitConfig.TrustForwardedFor,
+		authenticator,
+	)
+	router.Use(rateLimiter.Middleware)
+
+	tlsConfig, err := getTLSConfig(svc.config.TLSConfig)
+	if err != nil {
+		return errors.Wrap(err, "invalid tls configuration")
+	}
+
+	if tlsConfig != nil && tlsConfig.ClientCAs != nil {
+		router.Use(middleware.RequireClientCertificate)
+	}
+
+	router.Use(middleware.BodyLimit(svc.config.MaxBodyBytes))
+
+	router.HandleFunc("/health", svc.health.Readiness).Methods(http.MethodGet)
+	router.HandleFunc("/health/live", svc.health.Liveness).Methods(http.MethodGet)
+	router.HandleFunc("/health/ready", svc.health.Readiness).Methods(http.MethodGet)
+	router.Handle("/metrics", svc.metrics.Handler()).Methods(http.MethodGet)
+
+	svc.api = api.NewFoodRecipeAPI(ctx, authenticator, dataStore, reservedKeyNames, svc.config.DefaultMaxResults, router)
+
+	corsConfig := svc.config.CORSConfig
+	cors, err := middleware.CORS(middleware.CORSOptions{
+		AllowedOrigins:   corsConfig.AllowedOrigins,
+		AllowedMethods:   corsConfig.AllowedMethods,
+		AllowedHeaders:   corsConfig.AllowedHeaders,
+		ExposedHeaders:   corsConfig.ExposedHeaders,
+		AllowCredentials: corsConfig.AllowCredentials,
+		MaxAge:           corsConfig.MaxAge,
+	})
+	if err != nil {
+		return errors.Wrap(err, "invalid cors configuration")
+	}
+
+	// trace and record metrics for every request, including those which do not match a route or are rejected by the
+	// router's middleware
+	traced := tracing.Middleware(router, svc.config.TracingConfig.ServiceName)
+	handler := middleware.AccessLog(router)(traced(svc.metrics.Middleware(router)(cors(router))))
+
+	s := server.New(svc.config.BindAddr, middleware.RequestID(handler))
 
 	// Disable this here to allow main to manage graceful shutdown of the entire app.
 	s.HandleOSSignals = false
 
+	// Request IDs and access logs are handled by the middleware above, in place of the server's own
+	s.Middleware = nil
+	s.MiddlewareOrder = nil
+
+	if tlsConfig != nil {
+		s.CertFile = svc.config.TLSConfig.CertFile
+		s.KeyFile = svc.config.TLSConfig.KeyFile
+		s.Server.TLSConfig = tlsConfig
+	}
+
 	svc.server = s
 
 	// Run the http server in a new go-routine
@@ -77,8 +191,48 @@ func (svc *Service) Run(ctx context.Context, recipeData map[string]models.Recipe
 	return nil
 }
 
-// Close gracefully shuts the service down in the required order, with timeout
+// getTLSConfig returns the configuration to serve the API over HTTPS, or nil to serve it over HTTP if no certificate
+// is configured. Client certificates are verified if a client CA bundle is configured.
+func getTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	if cfg.CertFile == "" && cfg.KeyFile == "" {
+		if cfg.ClientCAFile != "" {
+			return nil, errors.New("client certificates can only be verified when serving over https")
+		}
+		return nil, nil
+	}
+
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, errors.New("both a certificate and key file are required")
+	}
+
+	reloader, err := tlsconfig.NewCertReloader(cfg.CertFile, cfg.KeyFile, cfg.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientCAs *x509.CertPool
+	if cfg.ClientCAFile != "" {
+		if clientCAs, err = tlsconfig.LoadCertPool(cfg.ClientCAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return tlsconfig.ServerConfig(reloader, clientCAs), nil
+}
+
+// Close gracefully shuts the service down in the required order, with timeout. The service reports it is not ready
+// while draining, for the configured drain period before the server stops accepting requests.
 func (svc *Service) Close(ctx context.Context) error {
+	svc.health.StartDraining()
+
+	if drainPeriod := svc.config.DrainPeriod; drainPeriod > 0 {
+		log.Info(ctx, "draining before shutdown", log.Data{"drain_period": drainPeriod})
+		select {
+		case <-time.After(drainPeriod):
+		case <-ctx.Done():
+		}
+	}
+
 	timeout := svc.config.GracefulShutdownTimeout
 	log.Info(ctx, "commencing graceful shutdown", log.Data{"graceful_shutdown_timeout": timeout})
 	shutdownContext, cancel := context.WithTimeout(ctx, timeout)
@@ -0,0 +1,103 @@
+package slug
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the maximum number of characters in a generated slug
+const MaxLength = 64
+
+const separator = '-'
+
+// replacements contains lower case letters that do not decompose into an
+// ASCII base character and a combining mark, along with their transliteration
+var replacements = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'œ': "oe",
+	'ø': "o",
+	'ł': "l",
+	'đ': "d",
+	'ð': "d",
+	'þ': "th",
+	'ı': "i",
+	'&': " and ",
+	'@': " at ",
+}
+
+// apostrophes are dropped rather than treated as a separator, so "Mum's Pie" becomes "mums-pie"
+var apostrophes = map[rune]bool{
+	'\'': true,
+	'’':  true,
+	'‘':  true,
+}
+
+// Make generates a slug from the given text, for example a recipe title, which is safe to use as a
+// path segment. Letters are transliterated to ASCII, punctuation and whitespace are collapsed into a
+// single separator and the result is truncated at a word boundary so it does not exceed MaxLength.
+// An empty string is returned if the text contains no letters or numbers.
+func Make(text string) string {
+	stripMarks := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	decomposed, _, err := transform.String(stripMarks, strings.ToLower(text))
+	if err != nil {
+		decomposed = strings.ToLower(text)
+	}
+
+	var (
+		b            strings.Builder
+		addSeparator bool
+	)
+
+	write := func(r rune) {
+		switch {
+		case r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if addSeparator && b.Len() > 0 {
+				b.WriteRune(separator)
+			}
+			addSeparator = false
+			b.WriteRune(r)
+		case apostrophes[r]:
+			// join the surrounding words
+		default:
+			addSeparator = true
+		}
+	}
+
+	for _, r := range decomposed {
+		if replacement, ok := replacements[r]; ok {
+			for _, rr := range replacement {
+				write(rr)
+			}
+			continue
+		}
+
+		write(r)
+	}
+
+	return truncate(b.String(), MaxLength)
+}
+
+// truncate shortens a slug to the maximum length, dropping any partial word left at the end
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+
+	if s[maxLength] == separator {
+		return s[:maxLength]
+	}
+
+	s = s[:maxLength]
+	if i := strings.LastIndexByte(s, separator); i > 0 {
+		s = s[:i]
+	}
+
+	return s
+}
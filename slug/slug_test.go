@@ -0,0 +1,74 @@
+package slug_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/nshumoogum/food-recipes/slug"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMake(t *testing.T) {
+	tables := []struct {
+		givenTitle   string
+		thenTitle    string
+		text         string
+		expectedSlug string
+	}{
+		{
+			"Given a plain title", "Then the title is lower cased and hyphenated",
+			"Chicken Curry", "chicken-curry",
+		},
+		{
+			"Given a title containing accented characters and a slash", "Then the accents are removed and the slash becomes a separator",
+			"Crème Brûlée / Quick", "creme-brulee-quick",
+		},
+		{
+			"Given a title containing letters without a decomposition", "Then the letters are transliterated",
+			"Smørrebrød Straße Œufs", "smorrebrod-strasse-oeufs",
+		},
+		{
+			"Given a title containing an apostrophe", "Then the surrounding words are joined",
+			"Mum’s Apple Pie", "mums-apple-pie",
+		},
+		{
+			"Given a title containing an ampersand", "Then the ampersand is spelt out",
+			"Fish & Chips", "fish-and-chips",
+		},
+		{
+			"Given a title with leading, trailing and repeated punctuation", "Then separators are collapsed and trimmed",
+			"  --Pad Thai!!  (v2) -- ", "pad-thai-v2",
+		},
+		{
+			"Given a title with compatibility characters", "Then they are normalised",
+			"Ｆｕｌｌ Width ½ Cake", "full-width-1-2-cake",
+		},
+		{
+			"Given a title with no letters or numbers", "Then the slug is empty",
+			"!!! ??? 寿司", "",
+		},
+	}
+
+	for _, table := range tables {
+		Convey(table.givenTitle, t, func() {
+			Convey(table.thenTitle, func() {
+				So(slug.Make(table.text), ShouldEqual, table.expectedSlug)
+			})
+		})
+	}
+}
+
+func TestMakeTruncates(t *testing.T) {
+	Convey("Given a title longer than the maximum length", t, func() {
+		title := strings.Repeat("Slow Roasted Lamb ", 6)
+
+		Convey("Then the slug is truncated at a word boundary", func() {
+			s := slug.Make(title)
+
+			So(len(s), ShouldBeLessThanOrEqualTo, slug.MaxLength)
+			So(s, ShouldStartWith, "slow-roasted-lamb-slow-roasted-lamb")
+			So(s, ShouldNotEndWith, "-")
+			So(strings.HasSuffix(s, "slow") || strings.HasSuffix(s, "roasted") || strings.HasSuffix(s, "lamb"), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+
+	"github.com/nshumoogum/food-recipes/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const auditCollection = "audit"
+
+// CreateAuditEntry stores an entry in the audit log, generating an id for the entry if it does not have one
+func (m *Mongo) CreateAuditEntry(ctx context.Context, entry *models.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := m.collection(auditCollection).InsertOne(ctx, entry)
+	return err
+}
+
+// GetAuditEntries returns a page of the audit entries matching the filter, oldest first, and the total number of
+// matching entries
+func (m *Mongo) GetAuditEntries(ctx context.Context, filter models.AuditFilter, offset, limit int) ([]models.AuditEntry, int64, error) {
+	query := bson.M{}
+	if filter.RecipeID != "" {
+		query["recipe_id"] = filter.RecipeID
+	}
+
+	if filter.Since != nil {
+		query["timestamp"] = bson.M{"$gte": filter.Since}
+	}
+
+	collection := m.collection(auditCollection)
+
+	count, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	entries := []models.AuditEntry{}
+	if count == 0 || limit == 0 {
+		return entries, count, nil
+	}
+
+	opts := options.Find().
+		SetSort(primitive.D{{Key: "timestamp", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(offset)).
+		SetLimit(int64(limit))
+
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, count, nil
+}
@@ -0,0 +1,158 @@
+package store
+
+import (
+	"context"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const householdsCollection = "households"
+
+// CreateHousehold stores a new household, households are identified by an id which must be unique
+func (m *Mongo) CreateHousehold(ctx context.Context, household *models.Household) error {
+	if _, err := m.collection(householdsCollection).InsertOne(ctx, household); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.ErrHouseholdAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetHouseholds returns the households the caller with the given name is a member of, ordered by id.
+// All households are returned if no name is given.
+func (m *Mongo) GetHouseholds(ctx context.Context, member string) ([]models.Household, error) {
+	filter := bson.M{}
+	if member != "" {
+		filter["members.name"] = member
+	}
+
+	cur, err := m.collection(householdsCollection).Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	households := []models.Household{}
+	if err = cur.All(ctx, &households); err != nil {
+		return nil, err
+	}
+
+	return households, nil
+}
+
+// GetHousehold returns the household with the given id
+func (m *Mongo) GetHousehold(ctx context.Context, id string) (*models.Household, error) {
+	var household models.Household
+	if err := m.collection(householdsCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&household); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errs.ErrHouseholdNotFound
+		}
+		return nil, err
+	}
+
+	return &household, nil
+}
+
+// InviteHouseholdMember adds an invitation to a household, a caller cannot be invited if they are already a member or
+// have already been invited
+func (m *Mongo) InviteHouseholdMember(ctx context.Context, id string, invitation models.HouseholdInvitation) error {
+	res, err := m.collection(householdsCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "members.name": bson.M{"$ne": invitation.Name}, "invitations.name": bson.M{"$ne": invitation.Name}},
+		bson.M{"$push": bson.M{"invitations": invitation}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		// distinguish between a missing household, an existing member and an existing invitation
+		household, getErr := m.GetHousehold(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+		if household.Role(invitation.Name) != "" {
+			return errs.ErrMemberAlreadyExists
+		}
+		return errs.ErrInvitationExists
+	}
+
+	return nil
+}
+
+// AcceptHouseholdInvitation makes the invited caller a member of the household with the role they were invited with,
+// removing the invitation
+func (m *Mongo) AcceptHouseholdInvitation(ctx context.Context, id string, invitation models.HouseholdInvitation) error {
+	res, err := m.collection(householdsCollection).UpdateOne(ctx,
+		bson.M{
+			"_id":          id,
+			"invitations":  bson.M{"$elemMatch": bson.M{"name": invitation.Name, "role": invitation.Role}},
+			"members.name": bson.M{"$ne": invitation.Name},
+		},
+		bson.M{
+			"$pull": bson.M{"invitations": bson.M{"name": invitation.Name}},
+			"$push": bson.M{"members": models.HouseholdMember{Name: invitation.Name, Role: invitation.Role}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errs.ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// RemoveHouseholdInvitation removes the invitation for the caller with the given name from a household
+func (m *Mongo) RemoveHouseholdInvitation(ctx context.Context, id, name string) error {
+	res, err := m.collection(householdsCollection).UpdateOne(ctx,
+		bson.M{"_id": id, "invitations.name": name},
+		bson.M{"$pull": bson.M{"invitations": bson.M{"name": name}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errs.ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// RemoveHouseholdMember removes a member from a household, as long as the household has another owner, so concurrent
+// removals cannot leave a household without an owner
+func (m *Mongo) RemoveHouseholdMember(ctx context.Context, id, name string) error {
+	res, err := m.collection(householdsCollection).UpdateOne(ctx,
+		bson.M{
+			"_id":          id,
+			"members.name": name,
+			"members":      bson.M{"$elemMatch": bson.M{"role": models.RoleOwner, "name": bson.M{"$ne": name}}},
+		},
+		bson.M{"$pull": bson.M{"members": bson.M{"name": name}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		// distinguish between a missing household, a caller who is not a member and the last owner
+		household, getErr := m.GetHousehold(ctx, id)
+		if getErr != nil {
+			return getErr
+		}
+		if household.Role(name) == "" {
+			return errs.ErrMemberNotFound
+		}
+		return errs.ErrLastHouseholdOwner
+	}
+
+	return nil
+}
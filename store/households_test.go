@@ -0,0 +1,73 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/store"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const householdsNamespace = database + ".households"
+
+func householdDocument(members ...models.HouseholdMember) bson.D {
+	list := bson.A{}
+	for _, member := range members {
+		list = append(list, bson.D{{Key: "name", Value: member.Name}, {Key: "role", Value: member.Role}})
+	}
+
+	return bson.D{{Key: "_id", Value: "smiths"}, {Key: "name", Value: "Smiths"}, {Key: "members", Value: list}}
+}
+
+func TestRemoveHouseholdMember(t *testing.T) {
+	ctx := context.Background()
+	alice := models.HouseholdMember{Name: "alice", Role: models.RoleOwner}
+	bob := models.HouseholdMember{Name: "bob", Role: models.RoleViewer}
+
+	withMockStore(t, "removed", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given a member of a household with another owner", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+			Convey("Then the member is only removed while the household has an owner other than the member", func() {
+				So(m.RemoveHouseholdMember(ctx, "smiths", "bob"), ShouldBeNil)
+
+				filter := sentCommand(mt).Lookup("updates", "0", "q")
+				So(filter.Document().Lookup("members.name").StringValue(), ShouldEqual, "bob")
+				So(filter.Document().Lookup("members", "$elemMatch", "role").StringValue(), ShouldEqual, models.RoleOwner)
+				So(filter.Document().Lookup("members", "$elemMatch", "name", "$ne").StringValue(), ShouldEqual, "bob")
+			})
+		})
+	})
+
+	tables := []struct {
+		givenTitle    string
+		name          string
+		household     []bson.D
+		expectedError error
+	}{
+		{"Given the last owner of a household, such as after another owner was removed concurrently", "alice",
+			[]bson.D{householdDocument(alice, bob)}, errs.ErrLastHouseholdOwner},
+		{"Given a caller who is not a member of the household", "carol",
+			[]bson.D{householdDocument(alice, bob)}, errs.ErrMemberNotFound},
+		{"Given a household which does not exist", "alice", nil, errs.ErrHouseholdNotFound},
+	}
+
+	for _, table := range tables {
+		withMockStore(t, table.givenTitle, func(mt *mtest.T, m *store.Mongo) {
+			Convey(table.givenTitle, mt.T, func() {
+				mt.AddMockResponses(
+					mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+					mtest.CreateCursorResponse(0, householdsNamespace, mtest.FirstBatch, table.household...),
+				)
+
+				Convey("Then the member is not removed and the reason is returned", func() {
+					So(m.RemoveHouseholdMember(ctx, "smiths", table.name), ShouldEqual, table.expectedError)
+				})
+			})
+		})
+	}
+}
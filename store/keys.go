@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const apiKeysCollection = "api_keys"
+
+// CreateAPIKey stores a newly issued API key, keys are identified by their name which must be unique
+func (m *Mongo) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	if _, err := m.collection(apiKeysCollection).InsertOne(ctx, key); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.ErrAPIKeyAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetAPIKeys returns all issued API keys, including those which have expired or been revoked, ordered by name
+func (m *Mongo) GetAPIKeys(ctx context.Context) ([]models.APIKey, error) {
+	cur, err := m.collection(apiKeysCollection).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err = cur.All(ctx, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// GetAPIKey returns the API key with the given name
+func (m *Mongo) GetAPIKey(ctx context.Context, name string) (*models.APIKey, error) {
+	return m.findAPIKey(ctx, bson.M{"_id": name})
+}
+
+// GetAPIKeyByHash returns the API key with the given hex encoded hash
+func (m *Mongo) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	return m.findAPIKey(ctx, bson.M{"hash": hash})
+}
+
+func (m *Mongo) findAPIKey(ctx context.Context, filter bson.M) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := m.collection(apiKeysCollection).FindOne(ctx, filter).Decode(&key); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errs.ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// UpdateAPIKeyExpiry sets the time an API key expires at, a nil time means the key does not expire
+func (m *Mongo) UpdateAPIKeyExpiry(ctx context.Context, name string, expiresAt *time.Time) error {
+	update := bson.M{"$unset": bson.M{"expires_at": ""}}
+	if expiresAt != nil {
+		update = bson.M{"$set": bson.M{"expires_at": expiresAt}}
+	}
+
+	return m.updateAPIKey(ctx, name, update)
+}
+
+// RevokeAPIKey marks an API key as revoked, revoked keys are kept so their names cannot be reissued
+func (m *Mongo) RevokeAPIKey(ctx context.Context, name string, revokedAt time.Time) error {
+	return m.updateAPIKey(ctx, name, bson.M{"$set": bson.M{"revoked_at": revokedAt}})
+}
+
+// TouchAPIKey records the time an API key was last used
+func (m *Mongo) TouchAPIKey(ctx context.Context, name string, usedAt time.Time) error {
+	return m.updateAPIKey(ctx, name, bson.M{"$set": bson.M{"last_used_at": usedAt}})
+}
+
+func (m *Mongo) updateAPIKey(ctx context.Context, name string, update bson.M) error {
+	res, err := m.collection(apiKeysCollection).UpdateOne(ctx, bson.M{"_id": name}, update)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errs.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/store"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const (
+	database       = "food-recipes"
+	keysNamespace  = database + ".api_keys"
+	duplicateKeyID = 11000
+)
+
+// withMockStore runs the test against a store whose client is answered by the responses added to mt, rather than a
+// database
+func withMockStore(t *testing.T, name string, test func(mt *mtest.T, m *store.Mongo)) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	defer mt.Close()
+
+	mt.Run(name, func(mt *mtest.T) {
+		test(mt, store.NewMongo(mt.Client, database, "recipes"))
+	})
+}
+
+// sentCommand returns the last command sent to the database
+func sentCommand(mt *mtest.T) bson.Raw {
+	event := mt.GetStartedEvent()
+	if event == nil {
+		return nil
+	}
+
+	return event.Command
+}
+
+func keyDocument(name string) bson.D {
+	return bson.D{
+		{Key: "_id", Value: name},
+		{Key: "hash", Value: "9f86d081"},
+		{Key: "scopes", Value: bson.A{"recipes:write"}},
+		{Key: "created_at", Value: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	key := &models.APIKey{Name: "ci", Hash: "9f86d081", Scopes: []string{"recipes:write"}}
+
+	withMockStore(t, "created", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given an api key with a new name", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+
+			Convey("Then the key is inserted into the api keys collection", func() {
+				So(m.CreateAPIKey(ctx, key), ShouldBeNil)
+
+				command := sentCommand(mt)
+				So(command.Lookup("insert").StringValue(), ShouldEqual, "api_keys")
+				So(command.Lookup("documents", "0", "_id").StringValue(), ShouldEqual, "ci")
+				So(command.Lookup("documents", "0", "hash").StringValue(), ShouldEqual, "9f86d081")
+			})
+		})
+	})
+
+	withMockStore(t, "duplicate", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given an api key with the name of a stored key", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: duplicateKeyID, Message: "duplicate key"}))
+
+			Convey("Then an already exists error is returned", func() {
+				So(m.CreateAPIKey(ctx, key), ShouldEqual, errs.ErrAPIKeyAlreadyExists)
+			})
+		})
+	})
+}
+
+func TestGetAPIKeys(t *testing.T) {
+	withMockStore(t, "keys", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given two stored api keys", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, keysNamespace, mtest.FirstBatch, keyDocument("admin"), keyDocument("ci")))
+
+			Convey("Then the keys are returned ordered by name", func() {
+				keys, err := m.GetAPIKeys(context.Background())
+
+				So(err, ShouldBeNil)
+				So(keys, ShouldHaveLength, 2)
+				So(keys[0].Name, ShouldEqual, "admin")
+				So(keys[1].Scopes, ShouldResemble, []string{"recipes:write"})
+				So(sentCommand(mt).Lookup("sort", "_id").Int32(), ShouldEqual, 1)
+			})
+		})
+	})
+
+	withMockStore(t, "empty", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given no stored api keys", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, keysNamespace, mtest.FirstBatch))
+
+			Convey("Then an empty list is returned", func() {
+				keys, err := m.GetAPIKeys(context.Background())
+
+				So(err, ShouldBeNil)
+				So(keys, ShouldNotBeNil)
+				So(keys, ShouldBeEmpty)
+			})
+		})
+	})
+}
+
+func TestGetAPIKey(t *testing.T) {
+	ctx := context.Background()
+
+	withMockStore(t, "by name", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given a stored api key", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, keysNamespace, mtest.FirstBatch, keyDocument("ci")))
+
+			Convey("Then the key is found by its name", func() {
+				key, err := m.GetAPIKey(ctx, "ci")
+
+				So(err, ShouldBeNil)
+				So(key.Name, ShouldEqual, "ci")
+				So(sentCommand(mt).Lookup("filter", "_id").StringValue(), ShouldEqual, "ci")
+			})
+		})
+	})
+
+	withMockStore(t, "by hash", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given a stored api key", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, keysNamespace, mtest.FirstBatch, keyDocument("ci")))
+
+			Convey("Then the key is found by its hash", func() {
+				key, err := m.GetAPIKeyByHash(ctx, "9f86d081")
+
+				So(err, ShouldBeNil)
+				So(key.Name, ShouldEqual, "ci")
+				So(sentCommand(mt).Lookup("filter", "hash").StringValue(), ShouldEqual, "9f86d081")
+			})
+		})
+	})
+
+	withMockStore(t, "not found", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given no stored api key with the hash", mt.T, func() {
+			mt.AddMockResponses(mtest.CreateCursorResponse(0, keysNamespace, mtest.FirstBatch))
+
+			Convey("Then a not found error is returned", func() {
+				key, err := m.GetAPIKeyByHash(ctx, "9f86d081")
+
+				So(err, ShouldEqual, errs.ErrAPIKeyNotFound)
+				So(key, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestUpdateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tables := []struct {
+		givenTitle     string
+		update         func(m *store.Mongo) error
+		expectedUpdate string
+		expectedField  string
+	}{
+		{"Given an expiry for an api key", func(m *store.Mongo) error { return m.UpdateAPIKeyExpiry(ctx, "ci", &now) },
+			"$set", "expires_at"},
+		{"Given an api key which should no longer expire", func(m *store.Mongo) error { return m.UpdateAPIKeyExpiry(ctx, "ci", nil) },
+			"$unset", "expires_at"},
+		{"Given an api key to revoke", func(m *store.Mongo) error { return m.RevokeAPIKey(ctx, "ci", now) },
+			"$set", "revoked_at"},
+		{"Given an api key which has been used", func(m *store.Mongo) error { return m.TouchAPIKey(ctx, "ci", now) },
+			"$set", "last_used_at"},
+	}
+
+	for _, table := range tables {
+		withMockStore(t, table.expectedField, func(mt *mtest.T, m *store.Mongo) {
+			Convey(table.givenTitle, mt.T, func() {
+				Convey("When the api key is stored", func() {
+					mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+
+					Convey("Then the field of the key is updated", func() {
+						So(table.update(m), ShouldBeNil)
+
+						command := sentCommand(mt)
+						So(command.Lookup("updates", "0", "q", "_id").StringValue(), ShouldEqual, "ci")
+						_, err := command.LookupErr("updates", "0", "u", table.expectedUpdate, table.expectedField)
+						So(err, ShouldBeNil)
+					})
+				})
+
+				Convey("When the api key does not exist", func() {
+					mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}))
+
+					Convey("Then a not found error is returned", func() {
+						So(table.update(m), ShouldEqual, errs.ErrAPIKeyNotFound)
+					})
+				})
+			})
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package store
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Mongo provides access to the data held by the API in MongoDB
+type Mongo struct {
+	Client            *mongo.Client
+	Database          string
+	RecipesCollection string
+}
+
+// NewMongo creates a store using the given client, database and collection of recipes, the other data held by the API
+// is kept in collections with fixed names in the same database
+func NewMongo(client *mongo.Client, database, recipesCollection string) *Mongo {
+	return &Mongo{
+		Client:            client,
+		Database:          database,
+		RecipesCollection: recipesCollection,
+	}
+}
+
+func (m *Mongo) collection(name string) *mongo.Collection {
+	return m.Client.Database(m.Database).Collection(name)
+}
+
+// Init creates the indexes required by the store, it is safe to call if the indexes already exist
+func (m *Mongo) Init(ctx context.Context) error {
+	_, err := m.collection(apiKeysCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"hash": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = m.collection(auditCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: primitive.D{{Key: "recipe_id", Value: 1}, {Key: "timestamp", Value: 1}},
+	})
+
+	return err
+}
+
+// Check pings the primary to check MongoDB is available, returning a message describing the database checked
+func (m *Mongo) Check(ctx context.Context) (string, error) {
+	if err := m.Client.Ping(ctx, readpref.Primary()); err != nil {
+		return "", err
+	}
+
+	return "connected to database " + m.Database, nil
+}
+
+// CountRecipesByDifficulty returns the number of recipes stored for each difficulty
+func (m *Mongo) CountRecipesByDifficulty(ctx context.Context) (map[string]int, error) {
+	cur, err := m.collection(m.RecipesCollection).Aggregate(ctx, []bson.M{
+		{"$group": bson.M{"_id": "$difficulty", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var groups []struct {
+		Difficulty string `bson:"_id"`
+		Count      int    `bson:"count"`
+	}
+	if err = cur.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(groups))
+	for _, group := range groups {
+		counts[group.Difficulty] += group.Count
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"reflect"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	aliasesCollection = "aliases"
+	importsCollection = "imports"
+)
+
+// recipeQuery returns the query selecting the same recipes as filter.Matches
+func recipeQuery(filter models.RecipeFilter) bson.M {
+	if filter.All {
+		return bson.M{}
+	}
+
+	visible := []bson.M{
+		{"visibility": bson.M{"$exists": false}},
+		{"visibility": models.VisibilityPublic},
+		{"owner": bson.M{"$exists": false}},
+	}
+
+	if filter.Owner != "" {
+		visible = append(visible, bson.M{"owner": filter.Owner})
+	}
+
+	if len(filter.Households) > 0 {
+		visible = append(visible, bson.M{"visibility": models.VisibilityHousehold, "household": bson.M{"$in": filter.Households}})
+	}
+
+	return bson.M{"$or": visible}
+}
+
+// GetRecipes returns a page of the recipes selected by the filter, ordered by id, and the total number of recipes
+// selected
+func (m *Mongo) GetRecipes(ctx context.Context, filter models.RecipeFilter, offset, limit int) ([]models.Recipe, int64, error) {
+	query := recipeQuery(filter)
+	collection := m.collection(m.RecipesCollection)
+
+	count, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	recipes := []models.Recipe{}
+	if count == 0 || limit == 0 {
+		return recipes, count, nil
+	}
+
+	opts := options.Find().SetSort(bson.M{"_id": 1}).SetSkip(int64(offset)).SetLimit(int64(limit))
+
+	cur, err := collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cur.Close(ctx)
+
+	if err = cur.All(ctx, &recipes); err != nil {
+		return nil, 0, err
+	}
+
+	return recipes, count, nil
+}
+
+// GetAllRecipes returns every stored recipe, ordered by id
+func (m *Mongo) GetAllRecipes(ctx context.Context) ([]models.Recipe, error) {
+	cur, err := m.collection(m.RecipesCollection).Find(ctx, bson.M{}, options.Find().SetSort(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	recipes := []models.Recipe{}
+	if err = cur.All(ctx, &recipes); err != nil {
+		return nil, err
+	}
+
+	return recipes, nil
+}
+
+// GetRecipe returns the recipe with the given id
+func (m *Mongo) GetRecipe(ctx context.Context, id string) (*models.Recipe, error) {
+	var recipe models.Recipe
+	if err := m.collection(m.RecipesCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&recipe); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errs.ErrRecipeNotFound
+		}
+		return nil, err
+	}
+
+	return &recipe, nil
+}
+
+// GetRecipeAlias returns the alias recording that a recipe was previously identified by the given id
+func (m *Mongo) GetRecipeAlias(ctx context.Context, id string) (*models.RecipeAlias, error) {
+	var alias models.RecipeAlias
+	if err := m.collection(aliasesCollection).FindOne(ctx, bson.M{"_id": id}).Decode(&alias); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errs.ErrRecipeNotFound
+		}
+		return nil, err
+	}
+
+	return &alias, nil
+}
+
+// CreateRecipe stores a new recipe, returning an error if a recipe with the same id is already stored
+func (m *Mongo) CreateRecipe(ctx context.Context, recipe *models.Recipe) error {
+	if _, err := m.collection(m.RecipesCollection).InsertOne(ctx, recipe); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.ErrRecipeAlreadyExists
+		}
+		return err
+	}
+
+	return nil
+}
+
+// unchangedRecipeQuery selects the recipe only while it has the owner and household it had when it was read, so a
+// recipe is not changed by a caller who is no longer permitted to
+func unchangedRecipeQuery(recipe *models.Recipe) bson.M {
+	query := bson.M{"_id": recipe.ID}
+	for field, value := range map[string]string{"owner": recipe.Owner, "household": recipe.Household} {
+		if value == "" {
+			query[field] = bson.M{"$exists": false}
+			continue
+		}
+		query[field] = value
+	}
+
+	return query
+}
+
+// ReplaceRecipe replaces the previous version of a recipe, as long as its owner and household have not changed since
+// it was read
+func (m *Mongo) ReplaceRecipe(ctx context.Context, previous, recipe *models.Recipe) error {
+	res, err := m.collection(m.RecipesCollection).ReplaceOne(ctx, unchangedRecipeQuery(previous), recipe)
+	if err != nil {
+		return err
+	}
+
+	if res.MatchedCount == 0 {
+		return errs.ErrRecipeChanged
+	}
+
+	return nil
+}
+
+// DeleteRecipe deletes a recipe, as long as its owner and household have not changed since it was read
+func (m *Mongo) DeleteRecipe(ctx context.Context, recipe *models.Recipe) error {
+	res, err := m.collection(m.RecipesCollection).DeleteOne(ctx, unchangedRecipeQuery(recipe))
+	if err != nil {
+		return err
+	}
+
+	if res.DeletedCount == 0 {
+		return errs.ErrRecipeChanged
+	}
+
+	return nil
+}
+
+// RenameRecipe moves a recipe to a new id and records the previous id as an alias of the new one, moving aliases of the
+// previous id to the new id so they do not require more than one redirect. The recipe is stored under the new id
+// before the previous one is removed, so a rename which is interrupted is completed by renaming the recipe again. An
+// already exists error is returned if a different recipe is stored with the new id.
+func (m *Mongo) RenameRecipe(ctx context.Context, recipe *models.Recipe, newID string) error {
+	renamed := *recipe
+	renamed.ID = newID
+
+	if err := m.CreateRecipe(ctx, &renamed); err != nil {
+		if err != errs.ErrRecipeAlreadyExists {
+			return err
+		}
+
+		existing, getErr := m.GetRecipe(ctx, newID)
+		if getErr != nil {
+			return getErr
+		}
+
+		if !reflect.DeepEqual(existing, &renamed) {
+			return errs.ErrRecipeAlreadyExists
+		}
+	}
+
+	aliases := m.collection(aliasesCollection)
+
+	if _, err := aliases.UpdateMany(ctx, bson.M{"recipe_id": recipe.ID}, bson.M{"$set": bson.M{"recipe_id": newID}}); err != nil {
+		return err
+	}
+
+	alias := models.RecipeAlias{ID: recipe.ID, RecipeID: newID}
+	if _, err := aliases.ReplaceOne(ctx, bson.M{"_id": recipe.ID}, alias, options.Replace().SetUpsert(true)); err != nil {
+		return err
+	}
+
+	_, err := m.collection(m.RecipesCollection).DeleteOne(ctx, bson.M{"_id": recipe.ID})
+	return err
+}
+
+// CreateImportRun stores the summary of an import run, generating an id for the run if it does not have one
+func (m *Mongo) CreateImportRun(ctx context.Context, run *models.ImportRun) error {
+	if run.ID == "" {
+		run.ID = primitive.NewObjectID().Hex()
+	}
+
+	_, err := m.collection(importsCollection).InsertOne(ctx, run)
+	return err
+}
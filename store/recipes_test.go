@@ -0,0 +1,93 @@
+package store_test
+
+import (
+	"context"
+	"testing"
+
+	errs "github.com/nshumoogum/food-recipes/apierrors"
+	"github.com/nshumoogum/food-recipes/models"
+	"github.com/nshumoogum/food-recipes/store"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+const recipesNamespace = database + ".recipes"
+
+// recipeDocument returns the document a recipe is stored as
+func recipeDocument(recipe *models.Recipe) bson.D {
+	b, err := bson.Marshal(recipe)
+	So(err, ShouldBeNil)
+
+	var document bson.D
+	So(bson.Unmarshal(b, &document), ShouldBeNil)
+
+	return document
+}
+
+// sentCommands returns the names of the commands sent to the database, in the order they were sent
+func sentCommands(mt *mtest.T) []string {
+	names := []string{}
+	for _, event := range mt.GetAllStartedEvents() {
+		names = append(names, event.CommandName)
+	}
+
+	return names
+}
+
+func TestRenameRecipe(t *testing.T) {
+	ctx := context.Background()
+	recipe := &models.Recipe{ID: "pancake", Title: "Pancakes", Owner: "writer", Visibility: models.VisibilityPublic}
+	renamed := *recipe
+	renamed.ID = "pancakes"
+
+	withMockStore(t, "renamed", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given a recipe to rename", mt.T, func() {
+			mt.AddMockResponses(
+				mtest.CreateSuccessResponse(),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			)
+
+			Convey("Then the recipe is stored under the new id before the previous one is removed", func() {
+				So(m.RenameRecipe(ctx, recipe, "pancakes"), ShouldBeNil)
+				So(sentCommands(mt), ShouldResemble, []string{"insert", "update", "update", "delete"})
+			})
+		})
+	})
+
+	withMockStore(t, "resumed", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given a recipe which was stored under the new id by an interrupted rename", mt.T, func() {
+			mt.AddMockResponses(
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: duplicateKeyID, Message: "duplicate key"}),
+				mtest.CreateCursorResponse(0, recipesNamespace, mtest.FirstBatch, recipeDocument(&renamed)),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+				mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+			)
+
+			Convey("Then the rename is completed", func() {
+				So(m.RenameRecipe(ctx, recipe, "pancakes"), ShouldBeNil)
+				So(sentCommands(mt), ShouldResemble, []string{"insert", "find", "update", "update", "delete"})
+			})
+		})
+	})
+
+	withMockStore(t, "conflict", func(mt *mtest.T, m *store.Mongo) {
+		Convey("Given a different recipe stored with the new id", mt.T, func() {
+			other := renamed
+			other.Owner = "admin"
+
+			mt.AddMockResponses(
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Code: duplicateKeyID, Message: "duplicate key"}),
+				mtest.CreateCursorResponse(0, recipesNamespace, mtest.FirstBatch, recipeDocument(&other)),
+			)
+
+			Convey("Then an already exists error is returned and the recipe is not removed", func() {
+				So(m.RenameRecipe(ctx, recipe, "pancakes"), ShouldEqual, errs.ErrRecipeAlreadyExists)
+				So(sentCommands(mt), ShouldResemble, []string{"insert", "find"})
+			})
+		})
+	})
+}
@@ -0,0 +1,152 @@
+// Package tlsconfig builds the TLS configuration used to serve the API over HTTPS, reloading the certificate when it
+// is rotated
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/v2/log"
+)
+
+// CertReloader provides a certificate loaded from a certificate and key file, checking whether either file has changed
+// at most once per interval and reloading the certificate if so. If a reload fails, for example because only one of
+// the files has been replaced so far, the previous certificate continues to be used.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration
+	now      func() time.Time
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewCertReloader loads the certificate from the given files, returning an error if it cannot be loaded
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+		now:      time.Now,
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Certificate returns the current certificate, reloading it first if the files have changed
+func (r *CertReloader) Certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastCheck) < r.interval {
+		return r.cert
+	}
+	r.lastCheck = now
+
+	logData := log.Data{"cert_file": r.certFile, "key_file": r.keyFile}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		log.Error(context.Background(), "unable to check tls certificate files for changes", err, logData)
+		return r.cert
+	}
+
+	if !modTime.After(r.modTime) {
+		return r.cert
+	}
+
+	if err = r.load(modTime); err != nil {
+		log.Error(context.Background(), "unable to reload tls certificate, continuing to use previous certificate", err, logData)
+		return r.cert
+	}
+
+	log.Info(context.Background(), "reloaded tls certificate", logData)
+
+	return r.cert
+}
+
+func (r *CertReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+
+	return nil
+}
+
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// ServerConfig returns the TLS configuration serving the current certificate of the reloader. If client CAs are
+// given, certificates presented by clients are verified against them; whether a certificate is required is left to
+// the handlers, as the TLS handshake happens before the route is known.
+func ServerConfig(reloader *CertReloader, clientCAs *x509.CertPool) *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if clientCAs != nil {
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	// the certificate is set for each connection, rather than using GetCertificate, so it takes precedence over any
+	// certificate loaded by the server from the same files at startup, including for clients not sending a server name
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		connConfig := config.Clone()
+		connConfig.GetConfigForClient = nil
+		connConfig.Certificates = []tls.Certificate{*reloader.Certificate()}
+		return connConfig, nil
+	}
+
+	return config
+}
+
+// LoadCertPool loads a bundle of PEM encoded CA certificates
+func LoadCertPool(path string) (*x509.CertPool, error) {
+	b, err := os.ReadFile(path) //nolint:gosec // path is provided by configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(b) {
+		return nil, fmt.Errorf("ca bundle %q does not contain any certificates", path)
+	}
+
+	return pool, nil
+}
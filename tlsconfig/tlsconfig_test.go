@@ -0,0 +1,151 @@
+package tlsconfig_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nshumoogum/food-recipes/tlsconfig"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	Convey("Given a certificate which is rotated after it was loaded", t, func() {
+		writeCertificate(t, certFile, keyFile, 1, time.Now().Add(-time.Hour))
+
+		reloader, err := tlsconfig.NewCertReloader(certFile, keyFile, 0)
+		So(err, ShouldBeNil)
+		So(serialNumber(t, reloader.Certificate()), ShouldEqual, 1)
+
+		writeCertificate(t, certFile, keyFile, 2, time.Now())
+
+		Convey("Then the rotated certificate is served to new connections", func() {
+			config := tlsconfig.ServerConfig(reloader, nil)
+			connConfig, err := config.GetConfigForClient(&tls.ClientHelloInfo{})
+
+			So(err, ShouldBeNil)
+			So(connConfig.Certificates, ShouldHaveLength, 1)
+			So(serialNumber(t, &connConfig.Certificates[0]), ShouldEqual, 2)
+			So(connConfig.MinVersion, ShouldEqual, tls.VersionTLS12)
+		})
+	})
+
+	Convey("Given a certificate whose key has not been rotated yet", t, func() {
+		writeCertificate(t, certFile, keyFile, 1, time.Now().Add(-time.Hour))
+
+		reloader, err := tlsconfig.NewCertReloader(certFile, keyFile, 0)
+		So(err, ShouldBeNil)
+
+		So(os.WriteFile(certFile, []byte("not a certificate"), 0o600), ShouldBeNil)
+
+		Convey("Then the previous certificate continues to be served", func() {
+			So(serialNumber(t, reloader.Certificate()), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given certificate files which do not exist", t, func() {
+		Convey("Then an error is returned", func() {
+			_, err := tlsconfig.NewCertReloader(filepath.Join(dir, "missing.pem"), keyFile, time.Minute)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestServerConfig(t *testing.T) {
+	Convey("Given a client ca bundle", t, func() {
+		dir := t.TempDir()
+		certFile := filepath.Join(dir, "cert.pem")
+		keyFile := filepath.Join(dir, "key.pem")
+		writeCertificate(t, certFile, keyFile, 1, time.Now())
+
+		reloader, err := tlsconfig.NewCertReloader(certFile, keyFile, time.Minute)
+		So(err, ShouldBeNil)
+
+		clientCAs, err := tlsconfig.LoadCertPool(certFile)
+		So(err, ShouldBeNil)
+
+		Convey("Then client certificates are verified if given", func() {
+			config := tlsconfig.ServerConfig(reloader, clientCAs)
+
+			So(config.ClientAuth, ShouldEqual, tls.VerifyClientCertIfGiven)
+			So(config.ClientCAs, ShouldEqual, clientCAs)
+		})
+	})
+
+	Convey("Given a ca bundle without any certificates", t, func() {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		So(os.WriteFile(path, []byte("empty"), 0o600), ShouldBeNil)
+
+		Convey("Then an error is returned", func() {
+			_, err := tlsconfig.LoadCertPool(path)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+// writeCertificate writes a self-signed certificate with the given serial number, setting the modification time of
+// the files so changes are detected regardless of the resolution of the file system's timestamps
+func writeCertificate(t *testing.T, certFile, keyFile string, serial int64, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("unable to marshal key: %v", err)
+	}
+
+	files := map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: der},
+		keyFile:  {Type: "EC PRIVATE KEY", Bytes: keyDER},
+	}
+
+	for file, block := range files {
+		if err = os.WriteFile(file, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatalf("unable to write %s: %v", file, err)
+		}
+
+		if err = os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatalf("unable to set modification time of %s: %v", file, err)
+		}
+	}
+}
+
+func serialNumber(t *testing.T, cert *tls.Certificate) int64 {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("unable to parse certificate: %v", err)
+	}
+
+	return leaf.SerialNumber.Int64()
+}
@@ -0,0 +1,123 @@
+// Package tracing sets up OpenTelemetry tracing of requests to the API, the storage operations they make and imports
+// of recipes, propagating traces in the W3C Trace Context format
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/nshumoogum/food-recipes/middleware"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/contrib/instrumentation/go.mongodb.org/mongo-driver/mongo/otelmongo"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters spans can be sent to
+const (
+	// ExporterNone disables tracing, traces are still propagated to downstream services
+	ExporterNone = ""
+	// ExporterStdout writes spans to stdout, for local development
+	ExporterStdout = "stdout"
+	// ExporterOTLP sends spans to an OpenTelemetry collector using OTLP over HTTP
+	ExporterOTLP = "otlp"
+)
+
+// instrumentationName identifies the spans created by the API itself
+const instrumentationName = "github.com/nshumoogum/food-recipes"
+
+// Config configures where spans are exported to and how many traces are sampled
+type Config struct {
+	Exporter       string
+	OTLPEndpoint   string
+	OTLPInsecure   bool
+	SampleRatio    float64
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Init sets the global tracer provider to export spans to the configured exporter, and the global propagator to
+// the W3C Trace Context and Baggage formats. The returned function flushes any spans not yet exported and must be
+// called on shutdown.
+func Init(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch cfg.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	case ExporterOTLP:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, expected %q or %q", cfg.Exporter, ExporterStdout, ExporterOTLP)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.ServiceVersion(cfg.ServiceVersion),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer returns the tracer for spans created by the API
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Middleware creates a span for each request, named by the template of the route matched in the router, such as
+// /recipes/{id}. It wraps the router so requests which do not match a route, including preflight requests, are also
+// traced, in spans named unmatched.
+func Middleware(router *mux.Router, serviceName string) func(http.Handler) http.Handler {
+	traced := otelmux.Middleware(serviceName, otelmux.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		return middleware.RouteTemplate(router, req)
+	}))
+
+	return func(h http.Handler) http.Handler {
+		return traced(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if route := middleware.RouteTemplate(router, req); route != middleware.UnmatchedRoute {
+				trace.SpanFromContext(req.Context()).SetAttributes(semconv.HTTPRoute(route))
+			}
+
+			h.ServeHTTP(w, req)
+		}))
+	}
+}
+
+// MongoMonitor returns a monitor creating a span for each command sent to MongoDB, set on the client used by the store
+func MongoMonitor() *event.CommandMonitor {
+	return otelmongo.NewMonitor()
+}
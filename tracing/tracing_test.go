@@ -0,0 +1,98 @@
+package tracing_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/nshumoogum/food-recipes/tracing"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInit(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given tracing is initialised without an exporter", t, func() {
+		shutdown, err := tracing.Init(ctx, tracing.Config{Exporter: tracing.ExporterNone, SampleRatio: 1})
+		So(err, ShouldBeNil)
+		defer shutdown(ctx)
+
+		Convey("Then a W3C traceparent header is propagated", func() {
+			traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+			spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+			spanCtx := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled})
+
+			carrier := propagation.MapCarrier{}
+			otel.GetTextMapPropagator().Inject(trace.ContextWithSpanContext(ctx, spanCtx), carrier)
+			So(carrier.Get("traceparent"), ShouldEqual, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		})
+	})
+
+	Convey("Given tracing is initialised with the stdout exporter", t, func() {
+		shutdown, err := tracing.Init(ctx, tracing.Config{Exporter: tracing.ExporterStdout, SampleRatio: 1, ServiceName: "food-recipes"})
+		So(err, ShouldBeNil)
+
+		Convey("Then spans are recorded and sampled", func() {
+			_, span := tracing.Tracer().Start(ctx, "test")
+			So(span.IsRecording(), ShouldBeTrue)
+			So(span.SpanContext().IsSampled(), ShouldBeTrue)
+			span.End()
+
+			So(shutdown(ctx), ShouldBeNil)
+		})
+	})
+
+	Convey("Given an unknown exporter", t, func() {
+		_, err := tracing.Init(ctx, tracing.Config{Exporter: "jaeger"})
+
+		Convey("Then an error is returned", func() {
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, `unknown tracing exporter "jaeger"`)
+		})
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given a router wrapped by the tracing middleware", t, func() {
+		recorder := tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+		router := mux.NewRouter()
+		router.HandleFunc("/recipes/{id}", func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}).Methods(http.MethodGet)
+		handler := tracing.Middleware(router, "food-recipes")(router)
+
+		Convey("When a request matching a route is made", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/recipes/pie", nil))
+
+			Convey("Then its span is named by the route template", func() {
+				spans := recorder.Ended()
+				So(spans, ShouldHaveLength, 1)
+				So(spans[0].Name(), ShouldEqual, "/recipes/{id}")
+				So(spans[0].Attributes(), ShouldContain, semconv.HTTPRoute("/recipes/{id}"))
+			})
+		})
+
+		Convey("When requests which do not match a route are made", func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cookbooks/pie", nil))
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodOptions, "/recipes/pie", nil))
+
+			Convey("Then they are traced in spans named unmatched", func() {
+				spans := recorder.Ended()
+				So(spans, ShouldHaveLength, 2)
+				for _, span := range spans {
+					So(span.Name(), ShouldEqual, "unmatched")
+				}
+			})
+		})
+	})
+}